@@ -0,0 +1,6 @@
+package dto
+
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+type CheckUsernameDTO struct {
+	Username string
+}
+
+type UsernameAvailabilityResponse struct {
+	Username  string `json:"username"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason"` // available, reserved or taken
+}
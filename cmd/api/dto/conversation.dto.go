@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+type ConversationResponse struct {
+	ChannelID     string                 `json:"channel_id"`
+	MemberIDs     []string               `json:"member_ids"`
+	Friend        *PublicUserResponse    `json:"friend,omitempty"` // nil once the friend deleted their account
+	Frozen        bool                   `json:"frozen"`
+	Reads         []*ReadReceiptResponse `json:"reads"`
+	LastMessage   *MessageResponse       `json:"last_message"`
+	LastMessageAt *time.Time             `json:"last_message_at"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+func NewConversationResponse(conversation *models.Conversation, friend *models.User, lastMessage *models.Message, viewer *models.User) *ConversationResponse {
+	response := &ConversationResponse{
+		ChannelID:     conversation.ChannelID,
+		MemberIDs:     make([]string, 0, len(conversation.MemberIDs)),
+		Frozen:        conversation.Frozen,
+		Reads:         make([]*ReadReceiptResponse, 0, len(conversation.Reads)),
+		LastMessageAt: conversation.LastMessageAt,
+		CreatedAt:     conversation.CreatedAt,
+	}
+	for _, memberID := range conversation.MemberIDs {
+		response.MemberIDs = append(response.MemberIDs, memberID.Hex())
+	}
+	for _, read := range conversation.Reads {
+		response.Reads = append(response.Reads, NewReadReceiptResponse(&read))
+	}
+	if friend != nil {
+		response.Friend = NewPublicUserResponse(friend, viewer)
+	}
+	if lastMessage != nil {
+		response.LastMessage = NewMessageResponse(lastMessage)
+	}
+	return response
+}
+
+func NewConversationsResponse(conversations []*models.ConversationWithFriend, viewer *models.User) []*ConversationResponse {
+	response := make([]*ConversationResponse, 0, len(conversations))
+	for _, conversation := range conversations {
+		response = append(response, NewConversationResponse(&conversation.Conversation, conversation.Friend, conversation.LastMessage, viewer))
+	}
+	return response
+}
@@ -0,0 +1,23 @@
+package dto
+
+import "github.com/ucok-man/streamify/internal/models"
+
+type EmailPreferencesResponse struct {
+	Locale                string `json:"locale"`
+	FriendRequestReceived bool   `json:"friend_request_received"`
+	FriendRequestAccepted bool   `json:"friend_request_accepted"`
+	InviteRedeemed        bool   `json:"invite_redeemed"`
+	InactivityReminder    bool   `json:"inactivity_reminder"`
+	Digest                string `json:"digest"` // off, daily or weekly
+}
+
+func NewEmailPreferencesResponse(preferences models.EmailPreferences) EmailPreferencesResponse {
+	return EmailPreferencesResponse{
+		Locale:                preferences.Locale,
+		FriendRequestReceived: preferences.Enabled(models.EmailFriendRequestReceived),
+		FriendRequestAccepted: preferences.Enabled(models.EmailFriendRequestAccepted),
+		InviteRedeemed:        preferences.Enabled(models.EmailInviteRedeemed),
+		InactivityReminder:    preferences.Enabled(models.EmailInactivityReminder),
+		Digest:                preferences.Cadence(),
+	}
+}
@@ -0,0 +1,73 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+type FriendRequestResponse struct {
+	ID          string    `json:"id"`
+	SenderID    string    `json:"sender_id"`
+	RecipientID string    `json:"recipient_id"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func NewFriendRequestResponse(request *models.FriendRequest) *FriendRequestResponse {
+	return &FriendRequestResponse{
+		ID:          request.ID.Hex(),
+		SenderID:    request.SenderID.Hex(),
+		RecipientID: request.RecipientID.Hex(),
+		Status:      request.Status,
+		CreatedAt:   request.CreatedAt,
+		UpdatedAt:   request.UpdatedAt,
+	}
+}
+
+type ReceivedFriendRequestResponse struct {
+	FriendRequestResponse
+	Sender *PublicUserResponse `json:"sender"`
+}
+
+func NewReceivedFriendRequestsResponse(requests []*models.FriendRequestWithSender, viewer *models.User) []*ReceivedFriendRequestResponse {
+	response := make([]*ReceivedFriendRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		response = append(response, &ReceivedFriendRequestResponse{
+			FriendRequestResponse: FriendRequestResponse{
+				ID:          request.ID.Hex(),
+				SenderID:    request.SenderID.Hex(),
+				RecipientID: request.RecipientID.Hex(),
+				Status:      request.Status,
+				CreatedAt:   request.CreatedAt,
+				UpdatedAt:   request.UpdatedAt,
+			},
+			Sender: NewPublicUserResponse(&request.Sender, viewer),
+		})
+	}
+	return response
+}
+
+type SentFriendRequestResponse struct {
+	FriendRequestResponse
+	Recipient *PublicUserResponse `json:"recipient"`
+}
+
+func NewSentFriendRequestsResponse(requests []*models.FriendRequestWithRecipient, viewer *models.User) []*SentFriendRequestResponse {
+	response := make([]*SentFriendRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		response = append(response, &SentFriendRequestResponse{
+			FriendRequestResponse: FriendRequestResponse{
+				ID:          request.ID.Hex(),
+				SenderID:    request.SenderID.Hex(),
+				RecipientID: request.RecipientID.Hex(),
+				Status:      request.Status,
+				CreatedAt:   request.CreatedAt,
+				UpdatedAt:   request.UpdatedAt,
+			},
+			Recipient: NewPublicUserResponse(&request.Recipient, viewer),
+		})
+	}
+	return response
+}
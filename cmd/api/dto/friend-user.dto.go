@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+// FriendUserResponse is a user as seen by one of their friends, the fields
+// shared with friends only are set by the privacy projection. Presence and
+// last seen are left empty when the user hides them.
+type FriendUserResponse struct {
+	PublicUserResponse
+	Presence     string     `json:"presence,omitempty"` // online, away or offline
+	LastActiveAt *time.Time `json:"last_active_at,omitempty"`
+}
+
+func NewFriendUserResponse(user *models.User, viewer *models.User) *FriendUserResponse {
+	profile := user.PublicProfile(viewer)
+
+	return &FriendUserResponse{
+		PublicUserResponse: *NewPublicUserResponse(user, viewer),
+		Presence:           profile.Presence,
+		LastActiveAt:       profile.LastActiveAt,
+	}
+}
+
+func NewFriendUsersResponse(users []*models.User, viewer *models.User) []*FriendUserResponse {
+	response := make([]*FriendUserResponse, 0, len(users))
+	for _, user := range users {
+		response = append(response, NewFriendUserResponse(user, viewer))
+	}
+	return response
+}
@@ -3,6 +3,8 @@ package dto
 type GetAllFromFriendRequestDTO struct {
 	Status       string
 	SearchSender string
+	Sort         string
 	Page         int
 	PageSize     int
+	Cursor       string
 }
@@ -3,6 +3,8 @@ package dto
 type GetAllSendFriendRequestDTO struct {
 	Status          string
 	SearchRecipient string
+	Sort            string
 	Page            int
 	PageSize        int
+	Cursor          string
 }
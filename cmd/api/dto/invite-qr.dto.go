@@ -0,0 +1,6 @@
+package dto
+
+type InviteQRDTO struct {
+	Format string
+	Size   int
+}
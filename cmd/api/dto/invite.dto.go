@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+type InviteResponse struct {
+	ID        string     `json:"id"`
+	Token     string     `json:"token"`
+	URL       string     `json:"url"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	Usable    bool       `json:"usable"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// NewInviteResponse maps invite, url is the link sharing it.
+func NewInviteResponse(invite *models.Invite, url string) *InviteResponse {
+	return &InviteResponse{
+		ID:        invite.ID.Hex(),
+		Token:     invite.Token,
+		URL:       url,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		Usable:    invite.Usable(),
+		ExpiresAt: invite.ExpiresAt,
+		CreatedAt: invite.CreatedAt,
+		UpdatedAt: invite.UpdatedAt,
+	}
+}
+
+// InvitePreviewResponse is what the holder of an invite link sees before
+// redeeming it.
+type InvitePreviewResponse struct {
+	Inviter *PublicUserResponse `json:"inviter"`
+	Action  string              `json:"action"` // befriend or request
+	Usable  bool                `json:"usable"`
+}
+
+func NewInvitePreviewResponse(invite *models.Invite, inviter *models.User, viewer *models.User) *InvitePreviewResponse {
+	return &InvitePreviewResponse{
+		Inviter: NewPublicUserResponse(inviter, viewer),
+		Action:  inviter.Privacy.InviteAction,
+		Usable:  invite.Usable(),
+	}
+}
@@ -0,0 +1,7 @@
+package dto
+
+type ListConversationsDTO struct {
+	Page     int
+	PageSize int
+	Cursor   string
+}
@@ -0,0 +1,6 @@
+package dto
+
+type ListLanguagesDTO struct {
+	Query string
+	Limit int
+}
@@ -0,0 +1,7 @@
+package dto
+
+type ListMessagesDTO struct {
+	Page     int
+	PageSize int
+	Cursor   string
+}
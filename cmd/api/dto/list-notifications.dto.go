@@ -0,0 +1,8 @@
+package dto
+
+type ListNotificationsDTO struct {
+	UnreadOnly bool
+	Page       int
+	PageSize   int
+	Cursor     string
+}
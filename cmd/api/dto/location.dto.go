@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"github.com/ucok-man/streamify/internal/geo"
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+type LocationResponse struct {
+	City        string   `json:"city"`
+	CountryCode string   `json:"country_code"`
+	Country     string   `json:"country"`
+	Timezone    string   `json:"timezone"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+}
+
+// NewLocationResponse returns nil for a hidden or missing location.
+func NewLocationResponse(location *models.Location) *LocationResponse {
+	if location == nil || (location.City == "" && location.CountryCode == "") {
+		return nil
+	}
+
+	response := &LocationResponse{
+		City:        location.City,
+		CountryCode: location.CountryCode,
+		Country:     geo.CountryName(location.CountryCode),
+		Timezone:    location.Timezone,
+	}
+	if location.Point != nil && len(location.Point.Coordinates) == 2 {
+		response.Longitude = &location.Point.Coordinates[0]
+		response.Latitude = &location.Point.Coordinates[1]
+	}
+	return response
+}
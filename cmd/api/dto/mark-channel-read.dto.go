@@ -0,0 +1,5 @@
+package dto
+
+type MarkChannelReadDTO struct {
+	MessageID string `json:"message_id"`
+}
@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+type MessageResponse struct {
+	ID        string     `json:"id"`
+	ChannelID string     `json:"channel_id"`
+	SenderID  string     `json:"sender_id"`
+	Text      string     `json:"text"`
+	Deleted   bool       `json:"deleted"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+func NewMessageResponse(message *models.Message) *MessageResponse {
+	return &MessageResponse{
+		ID:        message.ID.Hex(),
+		ChannelID: message.ChannelID,
+		SenderID:  message.SenderID.Hex(),
+		Text:      message.Text,
+		Deleted:   message.DeletedAt != nil,
+		CreatedAt: message.CreatedAt,
+		EditedAt:  message.EditedAt,
+		DeletedAt: message.DeletedAt,
+	}
+}
+
+func NewMessagesResponse(messages []*models.Message) []*MessageResponse {
+	response := make([]*MessageResponse, 0, len(messages))
+	for _, message := range messages {
+		response = append(response, NewMessageResponse(message))
+	}
+	return response
+}
+
+type ReadReceiptResponse struct {
+	UserID    string     `json:"user_id"`
+	MessageID string     `json:"message_id,omitempty"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+func NewReadReceiptResponse(read *models.ConversationRead) *ReadReceiptResponse {
+	response := &ReadReceiptResponse{
+		UserID: read.UserID.Hex(),
+		ReadAt: read.ReadAt,
+	}
+	if read.MessageID != nil {
+		response.MessageID = read.MessageID.Hex()
+	}
+	return response
+}
@@ -4,4 +4,5 @@ type MyFriendsDTO struct {
 	Query    string
 	Page     int
 	PageSize int
+	Cursor   string
 }
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+type NotificationResponse struct {
+	ID              string              `json:"id"`
+	Type            string              `json:"type"`
+	Actor           *PublicUserResponse `json:"actor"` // nil once the actor deleted their account
+	FriendRequestID string              `json:"friend_request_id,omitempty"`
+	InviteID        string              `json:"invite_id,omitempty"`
+	Read            bool                `json:"read"`
+	ReadAt          *time.Time          `json:"read_at"`
+	CreatedAt       time.Time           `json:"created_at"`
+}
+
+func NewNotificationResponse(notification *models.Notification, actor *models.User, viewer *models.User) *NotificationResponse {
+	response := &NotificationResponse{
+		ID:        notification.ID.Hex(),
+		Type:      notification.Type,
+		Read:      notification.ReadAt != nil,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+	if actor != nil {
+		response.Actor = NewPublicUserResponse(actor, viewer)
+	}
+	if notification.FriendRequestID != nil {
+		response.FriendRequestID = notification.FriendRequestID.Hex()
+	}
+	if notification.InviteID != nil {
+		response.InviteID = notification.InviteID.Hex()
+	}
+	return response
+}
+
+func NewNotificationsResponse(notifications []*models.NotificationWithActor, viewer *models.User) []*NotificationResponse {
+	response := make([]*NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		response = append(response, NewNotificationResponse(&notification.Notification, notification.Actor, viewer))
+	}
+	return response
+}
@@ -1,10 +1,15 @@
 package dto
 
 type OnboardingDTO struct {
-	Fullname    string `json:"fullname"`
-	Bio         string `json:"bio"`
-	NativeLng   string `json:"native_lng"`
-	LearningLng string `json:"learning_lng"`
-	Location    string `json:"location"`
-	ProfilePic  string `json:"profile_pic"`
+	Fullname   string        `json:"fullname"`
+	Bio        string        `json:"bio"`
+	Languages  []LanguageDTO `json:"languages"`
+	Location   string        `json:"location"`
+	ProfilePic string        `json:"profile_pic"`
+}
+
+type LanguageDTO struct {
+	Code     string `json:"code"`
+	Level    string `json:"level"`
+	Learning bool   `json:"learning"`
 }
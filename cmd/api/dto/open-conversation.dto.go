@@ -0,0 +1,5 @@
+package dto
+
+type OpenConversationDTO struct {
+	FriendID string `json:"friend_id"`
+}
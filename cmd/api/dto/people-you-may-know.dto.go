@@ -0,0 +1,6 @@
+package dto
+
+type PeopleYouMayKnowDTO struct {
+	Page     int
+	PageSize int
+}
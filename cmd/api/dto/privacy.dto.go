@@ -0,0 +1,25 @@
+package dto
+
+import "github.com/ucok-man/streamify/internal/models"
+
+type PrivacyResponse struct {
+	Discoverable         bool   `json:"discoverable"`
+	FriendRequests       string `json:"friend_requests"`
+	EmailVisibility      string `json:"email_visibility"`
+	LocationVisibility   string `json:"location_visibility"`
+	FriendListVisibility string `json:"friend_list_visibility"`
+	InviteAction         string `json:"invite_action"`
+	PresenceVisibility   string `json:"presence_visibility"`
+}
+
+func NewPrivacyResponse(privacy models.PrivacySettings) PrivacyResponse {
+	return PrivacyResponse{
+		Discoverable:         privacy.Discoverable,
+		FriendRequests:       privacy.FriendRequests,
+		EmailVisibility:      privacy.EmailVisibility,
+		LocationVisibility:   privacy.LocationVisibility,
+		FriendListVisibility: privacy.FriendListVisibility,
+		InviteAction:         privacy.InviteAction,
+		PresenceVisibility:   privacy.PresenceVisibility,
+	}
+}
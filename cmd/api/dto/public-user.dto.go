@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PublicUserResponse is a user as seen by another user. Email, location and
+// friend list are only set when the privacy settings of the user share them
+// with the viewer.
+type PublicUserResponse struct {
+	ID         string                 `json:"id"`
+	FullName   string                 `json:"full_name"`
+	Username   string                 `json:"username,omitempty"`
+	Email      string                 `json:"email,omitempty"`
+	Bio        string                 `json:"bio"`
+	ProfilePic string                 `json:"profile_pic"`
+	Languages  []UserLanguageResponse `json:"languages"`
+	Location   *LocationResponse      `json:"location,omitempty"`
+	FriendIDs  []string               `json:"friend_ids,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+func NewPublicUserResponse(user *models.User, viewer *models.User) *PublicUserResponse {
+	profile := user.PublicProfile(viewer)
+
+	return &PublicUserResponse{
+		ID:         profile.ID.Hex(),
+		FullName:   profile.FullName,
+		Username:   profile.Username,
+		Email:      profile.Email,
+		Bio:        profile.Bio,
+		ProfilePic: profile.ProfilePic,
+		Languages:  NewUserLanguagesResponse(profile.Languages),
+		Location:   NewLocationResponse(profile.Location),
+		FriendIDs:  hexIDs(profile.FriendIDs),
+		CreatedAt:  profile.CreatedAt,
+	}
+}
+
+func hexIDs(ids []bson.ObjectID) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+	hex := make([]string, 0, len(ids))
+	for _, id := range ids {
+		hex = append(hex, id.Hex())
+	}
+	return hex
+}
@@ -0,0 +1,245 @@
+package dto
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// testUser returns a user with every field set, sharing nothing but their
+// public profile.
+func testUser(viewer *models.User) *models.User {
+	now := time.Now()
+	user := &models.User{
+		ID:          bson.NewObjectID(),
+		FullName:    "Olivia Owner",
+		Username:    "olivia",
+		UsernameKey: "olivia",
+		Email:       "olivia@example.com",
+		Bio:         "Learning Portuguese",
+		ProfilePic:  "https://example.com/olivia.png",
+		Languages:   []models.UserLanguage{{Code: "en", Level: models.LanguageLevelNative}},
+		Location: models.Location{
+			City:        "Lisbon",
+			CountryCode: "PT",
+			Point:       &models.GeoPoint{Type: "Point", Coordinates: []float64{-9.1393, 38.7223}},
+			Timezone:    "Europe/Lisbon",
+		},
+		IsOnboarded: true,
+		FriendIDs:   []bson.ObjectID{bson.NewObjectID(), viewer.ID},
+		BlockedIDs:  []bson.ObjectID{bson.NewObjectID()},
+		Privacy: models.PrivacySettings{
+			Discoverable:         true,
+			FriendRequests:       models.FriendRequestPolicyFriendsOfFriends,
+			EmailVisibility:      models.VisibilityOnlyMe,
+			LocationVisibility:   models.VisibilityOnlyMe,
+			FriendListVisibility: models.VisibilityOnlyMe,
+			InviteAction:         models.InviteActionRequest,
+			PresenceVisibility:   models.VisibilityOnlyMe,
+		},
+		UsernameChangedAt:    &now,
+		ReferralCode:         "REFCODE42",
+		LastActiveAt:         &now,
+		InactivityRemindedAt: &now,
+		DigestSentAt:         &now,
+		SuspendedAt:          &now,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+	user.Password.Hash = []byte("$2a$12$passwordhashpasswordhash")
+	return user
+}
+
+// jsonKeys returns every object key of the JSON encoding of v, nested ones
+// included, and the encoding itself.
+func jsonKeys(t *testing.T, v any) (map[string]bool, string) {
+	t.Helper()
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshaling: %v", err)
+	}
+	var decoded any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("unmarshaling: %v", err)
+	}
+
+	keys := map[string]bool{}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for key, value := range v {
+				keys[key] = true
+				walk(value)
+			}
+		case []any:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+	walk(decoded)
+	return keys, string(encoded)
+}
+
+// Keys of the internals of a user, never sent to another user.
+var internalKeys = []string{
+	"password", "username_key", "blocked_ids", "privacy", "discoverable",
+	"friend_requests", "email_visibility", "location_visibility",
+	"friend_list_visibility", "invite_action", "presence_visibility",
+	"username_changed_at", "referral_code", "email_preferences",
+	"inactivity_reminded_at", "digest_sent_at", "suspended_at",
+	"sent_friend_request", "from_friend_request",
+}
+
+// Keys of the fields the test user hides.
+var hiddenKeys = []string{
+	"email", "location", "city", "country_code", "latitude", "longitude",
+	"timezone", "friend_ids", "presence", "last_active_at", "mutual_friends",
+	"mutual_friends_count",
+}
+
+func TestNonOwnerResponsesHideSensitiveFields(t *testing.T) {
+	stranger := &models.User{ID: bson.NewObjectID()}
+	friend := &models.User{ID: bson.NewObjectID()}
+	user := testUser(friend)
+	friend.FriendIDs = []bson.ObjectID{user.ID}
+
+	withRequests := &models.UserWithFriendRequest{
+		User:               *user,
+		SendtFriendRequest: []*models.FriendRequest{{ID: bson.NewObjectID(), SenderID: stranger.ID, RecipientID: user.ID}},
+		FromFriendRequest:  []*models.FriendRequest{{ID: bson.NewObjectID(), SenderID: user.ID, RecipientID: stranger.ID}},
+		HasFriendRequest:   true,
+		MutualFriendsCount: 1,
+		MutualFriends:      []*models.MutualFriend{{ID: user.FriendIDs[0], FullName: "Mia Mutual"}},
+	}
+
+	tests := []struct {
+		name     string
+		response any
+	}{
+		{"public", NewPublicUserResponse(user, stranger)},
+		{"public to a friend", NewPublicUserResponse(user, friend)},
+		{"friend", NewFriendUserResponse(user, friend)},
+		{"friends", NewFriendUsersResponse([]*models.User{user}, friend)},
+		{"suggested", NewSuggestedUserResponse(withRequests, stranger)},
+		{"search result", NewSearchUserResultsResponse([]*models.UserSearchResult{{UserWithFriendRequest: *withRequests}}, stranger)},
+		{"received friend request", NewReceivedFriendRequestsResponse([]*models.FriendRequestWithSender{{
+			ID:          bson.NewObjectID(),
+			SenderID:    user.ID,
+			RecipientID: stranger.ID,
+			Sender:      *user,
+		}}, stranger)},
+		{"sent friend request", NewSentFriendRequestsResponse([]*models.FriendRequestWithRecipient{{
+			ID:          bson.NewObjectID(),
+			SenderID:    stranger.ID,
+			RecipientID: user.ID,
+			Recipient:   *user,
+		}}, stranger)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, encoded := jsonKeys(t, tt.response)
+
+			for _, key := range slices.Concat(internalKeys, hiddenKeys) {
+				if keys[key] {
+					t.Errorf("key %q is present", key)
+				}
+			}
+			for _, value := range []string{user.Email, user.Location.City, user.ReferralCode, string(user.Password.Hash), user.BlockedIDs[0].Hex()} {
+				if strings.Contains(encoded, value) {
+					t.Errorf("value %q is present", value)
+				}
+			}
+		})
+	}
+}
+
+// withMutualFriends returns user listed with a friend in common.
+func withMutualFriends(user *models.User) *models.UserWithFriendRequest {
+	return &models.UserWithFriendRequest{
+		User:               *user,
+		MutualFriendsCount: 1,
+		MutualFriends:      []*models.MutualFriend{{ID: user.FriendIDs[0], FullName: "Mia Mutual"}},
+	}
+}
+
+func TestNonOwnerResponsesShowSharedFields(t *testing.T) {
+	stranger := &models.User{ID: bson.NewObjectID()}
+	friend := &models.User{ID: bson.NewObjectID()}
+	user := testUser(friend)
+	friend.FriendIDs = []bson.ObjectID{user.ID}
+
+	tests := []struct {
+		name       string
+		visibility models.Visibility
+		response   func(user *models.User) any
+		want       []string
+		wantHidden []string
+	}{
+		{
+			name:       "public with everyone",
+			visibility: models.VisibilityEveryone,
+			response:   func(user *models.User) any { return NewPublicUserResponse(user, stranger) },
+			want:       []string{"email", "location", "friend_ids"},
+		},
+		{
+			name:       "public with friends",
+			visibility: models.VisibilityFriends,
+			response:   func(user *models.User) any { return NewPublicUserResponse(user, stranger) },
+			wantHidden: []string{"email", "location", "friend_ids"},
+		},
+		{
+			name:       "friend with friends",
+			visibility: models.VisibilityFriends,
+			response:   func(user *models.User) any { return NewFriendUserResponse(user, friend) },
+			want:       []string{"email", "location", "friend_ids", "presence", "last_active_at"},
+		},
+		{
+			name:       "suggested with everyone",
+			visibility: models.VisibilityEveryone,
+			response:   func(user *models.User) any { return NewSuggestedUserResponse(withMutualFriends(user), stranger) },
+			want:       []string{"mutual_friends_count", "mutual_friends"},
+		},
+		{
+			name:       "suggested with friends",
+			visibility: models.VisibilityFriends,
+			response:   func(user *models.User) any { return NewSuggestedUserResponse(withMutualFriends(user), stranger) },
+			wantHidden: []string{"mutual_friends_count", "mutual_friends"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared := *user
+			shared.Privacy.EmailVisibility = tt.visibility
+			shared.Privacy.LocationVisibility = tt.visibility
+			shared.Privacy.FriendListVisibility = tt.visibility
+			shared.Privacy.PresenceVisibility = tt.visibility
+
+			keys, _ := jsonKeys(t, tt.response(&shared))
+			for _, key := range tt.want {
+				if !keys[key] {
+					t.Errorf("key %q is missing", key)
+				}
+			}
+			for _, key := range tt.wantHidden {
+				if keys[key] {
+					t.Errorf("key %q is present", key)
+				}
+			}
+			for _, key := range internalKeys {
+				if keys[key] {
+					t.Errorf("key %q is present", key)
+				}
+			}
+		})
+	}
+}
@@ -1,7 +1,14 @@
 package dto
 
 type RecommendedUserDTO struct {
-	Page     int
-	PageSize int
-	Query    string
+	Page             int
+	PageSize         int
+	Cursor           string
+	Query            string
+	Location         string
+	ActiveWithinDays int
+	NativeLng        []string
+	LearningLng      []string
+	WithinKm         int
+	OverlapHours     int
 }
@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+type ReferralStatsResponse struct {
+	Code            string     `json:"code"`
+	URL             string     `json:"url"`
+	Total           int64      `json:"total"`
+	Onboarded       int64      `json:"onboarded"`
+	FirstReferredAt *time.Time `json:"first_referred_at"`
+	LastReferredAt  *time.Time `json:"last_referred_at"`
+}
+
+// NewReferralStatsResponse maps the stats of the referral code, url is the
+// signup link carrying it.
+func NewReferralStatsResponse(code string, url string, stats models.ReferralStats) *ReferralStatsResponse {
+	return &ReferralStatsResponse{
+		Code:            code,
+		URL:             url,
+		Total:           stats.Total,
+		Onboarded:       stats.Onboarded,
+		FirstReferredAt: stats.FirstReferredAt,
+		LastReferredAt:  stats.LastReferredAt,
+	}
+}
@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+type SearchUserResultResponse struct {
+	SuggestedUserResponse
+	SearchScore float64                  `json:"search_score"`
+	Highlights  []models.SearchHighlight `json:"highlights"`
+}
+
+func NewSearchUserResultsResponse(results []*models.UserSearchResult, viewer *models.User) []*SearchUserResultResponse {
+	response := make([]*SearchUserResultResponse, 0, len(results))
+	for _, result := range results {
+		highlights := result.Highlights
+		if highlights == nil {
+			highlights = []models.SearchHighlight{}
+		}
+		response = append(response, &SearchUserResultResponse{
+			SuggestedUserResponse: *NewSuggestedUserResponse(&result.UserWithFriendRequest, viewer),
+			SearchScore:           result.SearchScore,
+			Highlights:            highlights,
+		})
+	}
+	return response
+}
@@ -0,0 +1,11 @@
+package dto
+
+type SearchUsersDTO struct {
+	Page         int
+	PageSize     int
+	Query        string
+	Fuzzy        bool
+	Autocomplete bool
+	NativeLng    []string
+	LearningLng  []string
+}
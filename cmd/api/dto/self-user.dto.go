@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+// SelfUserResponse is the current user as seen by themselves.
+type SelfUserResponse struct {
+	ID           string                 `json:"id"`
+	FullName     string                 `json:"full_name"`
+	Username     string                 `json:"username"`
+	Email        string                 `json:"email"`
+	Bio          string                 `json:"bio"`
+	ProfilePic   string                 `json:"profile_pic"`
+	Languages    []UserLanguageResponse `json:"languages"`
+	Location     *LocationResponse      `json:"location"`
+	IsOnboarded  bool                   `json:"is_onboarded"`
+	FriendsCount int                    `json:"friends_count"`
+	Privacy      PrivacyResponse        `json:"privacy"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+}
+
+func NewSelfUserResponse(user *models.User) *SelfUserResponse {
+	return &SelfUserResponse{
+		ID:           user.ID.Hex(),
+		FullName:     user.FullName,
+		Username:     user.Username,
+		Email:        user.Email,
+		Bio:          user.Bio,
+		ProfilePic:   user.ProfilePic,
+		Languages:    NewUserLanguagesResponse(user.Languages),
+		Location:     NewLocationResponse(&user.Location),
+		IsOnboarded:  user.IsOnboarded,
+		FriendsCount: len(user.FriendIDs),
+		Privacy:      NewPrivacyResponse(user.Privacy),
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
+	}
+}
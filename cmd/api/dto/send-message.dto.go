@@ -0,0 +1,6 @@
+package dto
+
+// SendMessageDTO is the body of both sending and editing a message.
+type SendMessageDTO struct {
+	Text string `json:"text"`
+}
@@ -1,7 +1,8 @@
 package dto
 
 type SignupDTO struct {
-	Fullname string `json:"fullname"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Fullname     string `json:"fullname"`
+	Email        string `json:"email"`
+	Password     string `json:"password"`
+	ReferralCode string `json:"referral_code"` // referral code or invite token, optional
 }
@@ -0,0 +1,12 @@
+package dto
+
+// SubscribePushDTO is the PushSubscription of the browser, as serialized by
+// its toJSON method.
+type SubscribePushDTO struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+	ExpirationTime *int64 `json:"expirationTime"` // unused, push services report expiry with 404 or 410
+}
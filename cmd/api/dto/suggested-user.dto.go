@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+// SuggestedUserResponse is a user listed for discovery, with how they relate
+// to the viewer. The mutual friends are only set when the user shares their
+// friend list with the viewer.
+type SuggestedUserResponse struct {
+	PublicUserResponse
+	FriendRequest      *FriendRequestStateResponse `json:"friend_request"`
+	HasFriendRequest   bool                        `json:"has_friend_request"`
+	MutualFriendsCount *int64                      `json:"mutual_friends_count,omitempty"`
+	MutualFriends      []MutualFriendResponse      `json:"mutual_friends,omitempty"`
+	MatchScore         int                         `json:"match_score"`
+	MatchReason        string                      `json:"match_reason"`
+}
+
+// FriendRequestStateResponse is the pending or accepted request between the
+// viewer and a user, Direction is sent when the viewer sent it.
+type FriendRequestStateResponse struct {
+	ID        string `json:"id"`
+	Direction string `json:"direction"`
+	Status    string `json:"status"`
+}
+
+type MutualFriendResponse struct {
+	ID         string `json:"id"`
+	FullName   string `json:"full_name"`
+	ProfilePic string `json:"profile_pic"`
+}
+
+func NewSuggestedUserResponse(user *models.UserWithFriendRequest, viewer *models.User) *SuggestedUserResponse {
+	response := &SuggestedUserResponse{
+		PublicUserResponse: *NewPublicUserResponse(&user.User, viewer),
+		HasFriendRequest:   user.HasFriendRequest,
+		MatchScore:         user.MatchScore,
+		MatchReason:        user.MatchReason,
+	}
+	if user.SharesFriendListWith(viewer) {
+		response.MutualFriendsCount = &user.MutualFriendsCount
+		response.MutualFriends = NewMutualFriendsResponse(user.MutualFriends)
+	}
+
+	switch {
+	case len(user.SendtFriendRequest) > 0:
+		response.FriendRequest = &FriendRequestStateResponse{
+			ID:        user.SendtFriendRequest[0].ID.Hex(),
+			Direction: "sent",
+			Status:    user.SendtFriendRequest[0].Status,
+		}
+	case len(user.FromFriendRequest) > 0:
+		response.FriendRequest = &FriendRequestStateResponse{
+			ID:        user.FromFriendRequest[0].ID.Hex(),
+			Direction: "received",
+			Status:    user.FromFriendRequest[0].Status,
+		}
+	}
+
+	return response
+}
+
+func NewSuggestedUsersResponse(users []*models.UserWithFriendRequest, viewer *models.User) []*SuggestedUserResponse {
+	response := make([]*SuggestedUserResponse, 0, len(users))
+	for _, user := range users {
+		response = append(response, NewSuggestedUserResponse(user, viewer))
+	}
+	return response
+}
+
+func NewMutualFriendsResponse(friends []*models.MutualFriend) []MutualFriendResponse {
+	response := make([]MutualFriendResponse, 0, len(friends))
+	for _, friend := range friends {
+		response = append(response, MutualFriendResponse{
+			ID:         friend.ID.Hex(),
+			FullName:   friend.FullName,
+			ProfilePic: friend.ProfilePic,
+		})
+	}
+	return response
+}
@@ -0,0 +1,5 @@
+package dto
+
+type UnsubscribeEmailDTO struct {
+	Token string
+}
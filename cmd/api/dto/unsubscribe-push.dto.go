@@ -0,0 +1,5 @@
+package dto
+
+type UnsubscribePushDTO struct {
+	Endpoint string `json:"endpoint"`
+}
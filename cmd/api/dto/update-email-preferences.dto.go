@@ -0,0 +1,10 @@
+package dto
+
+type UpdateEmailPreferencesDTO struct {
+	Locale                string `json:"locale"`
+	FriendRequestReceived bool   `json:"friend_request_received"`
+	FriendRequestAccepted bool   `json:"friend_request_accepted"`
+	InviteRedeemed        bool   `json:"invite_redeemed"`
+	InactivityReminder    bool   `json:"inactivity_reminder"`
+	Digest                string `json:"digest"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+type UpdateInviteDTO struct {
+	MaxUses        int `json:"max_uses"`         // 0 for unlimited
+	ExpiresInHours int `json:"expires_in_hours"` // 0 never expires, counted from now
+}
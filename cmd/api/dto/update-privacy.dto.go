@@ -0,0 +1,11 @@
+package dto
+
+type UpdatePrivacyDTO struct {
+	Discoverable         bool   `json:"discoverable"`
+	FriendRequests       string `json:"friend_requests"`
+	EmailVisibility      string `json:"email_visibility"`
+	LocationVisibility   string `json:"location_visibility"`
+	FriendListVisibility string `json:"friend_list_visibility"`
+	InviteAction         string `json:"invite_action"`
+	PresenceVisibility   string `json:"presence_visibility"`
+}
@@ -0,0 +1,5 @@
+package dto
+
+type UpdateUsernameDTO struct {
+	Username string `json:"username"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"github.com/ucok-man/streamify/internal/languages"
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+type UserLanguageResponse struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Level    string `json:"level"`
+	Learning bool   `json:"learning"`
+}
+
+func NewUserLanguagesResponse(lngs []models.UserLanguage) []UserLanguageResponse {
+	response := make([]UserLanguageResponse, 0, len(lngs))
+	for _, lng := range lngs {
+		var name string
+		if found, ok := languages.Lookup(lng.Code); ok {
+			name = found.Name
+		}
+		response = append(response, UserLanguageResponse{
+			Code:     lng.Code,
+			Name:     name,
+			Level:    lng.Level,
+			Learning: lng.Learning,
+		})
+	}
+	return response
+}
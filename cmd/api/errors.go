@@ -79,3 +79,18 @@ func (app *application) errNotPermitted(w http.ResponseWriter, r *http.Request)
 	message := "your user account doesn't have the necessary permissions to access this resource"
 	app.errorResponse(w, r, http.StatusForbidden, message)
 }
+
+func (app *application) errAccountSuspended(w http.ResponseWriter, r *http.Request) {
+	message := "your user account is suspended"
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
+func (app *application) errServiceUnavailable(w http.ResponseWriter, r *http.Request) {
+	message := "the server is shutting down, please try again"
+	app.errorResponse(w, r, http.StatusServiceUnavailable, message)
+}
+
+func (app *application) errNotConfigured(w http.ResponseWriter, r *http.Request, feature string) {
+	message := fmt.Sprintf("%s is not enabled on this server", feature)
+	app.errorResponse(w, r, http.StatusNotImplemented, message)
+}
@@ -6,33 +6,51 @@ import (
 	"net/http"
 	"time"
 
-	stream "github.com/GetStream/stream-chat-go/v5"
 	"github.com/ucok-man/streamify/cmd/api/dto"
+	"github.com/ucok-man/streamify/internal/chat"
+	"github.com/ucok-man/streamify/internal/geo"
 	"github.com/ucok-man/streamify/internal/models"
 	"github.com/ucok-man/streamify/internal/validator"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 func (app *application) signup(w http.ResponseWriter, r *http.Request) {
-	var dto dto.SignupDTO
-	err := app.readJSON(w, r, &dto)
+	var input dto.SignupDTO
+	err := app.readJSON(w, r, &input)
 	if err != nil {
 		app.errBadRequest(w, r, err)
 		return
 	}
 
-	errmap := validator.Schema().SignupDTO.Validate(&dto)
+	errmap := validator.Schema().SignupDTO.Validate(&input)
 	if errmap != nil {
 		app.errFailedValidation(w, r, validator.Sanitize(errmap))
 		return
 	}
 
+	var referral *models.Referral
+	if input.ReferralCode != "" {
+		referral, err = app.resolveReferral(input.ReferralCode)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrRecordNotFound):
+				app.errFailedValidation(w, r, map[string][]string{
+					"referral_code": {"Invalid referral code"},
+				})
+			default:
+				app.errInternalServer(w, r, err)
+			}
+			return
+		}
+	}
+
 	user := &models.User{
-		FullName:   dto.Fullname,
-		Email:      dto.Email,
+		FullName:   input.Fullname,
+		Email:      input.Email,
 		ProfilePic: app.getRandomPicturePlaceholder(),
 	}
 
-	if err := user.Password.Set(dto.Password); err != nil {
+	if err := user.Password.Set(input.Password); err != nil {
 		app.errInternalServer(w, r, err)
 		return
 	}
@@ -50,8 +68,16 @@ func (app *application) signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create user in getstream.io
-	_, err = app.stream.UpsertUser(context.Background(), &stream.User{
+	// The account exists at this point, a failed attribution must not fail the signup
+	if referral != nil {
+		referral.RefereeID = user.ID
+		if _, err := app.models.Referral.Insert(referral); err != nil {
+			app.logError(r, err)
+		}
+	}
+
+	// Create user in the chat service
+	err = app.chat.UpsertUser(context.Background(), chat.User{
 		ID:    user.ID.Hex(),
 		Name:  user.FullName,
 		Image: user.ProfilePic,
@@ -79,27 +105,27 @@ func (app *application) signup(w http.ResponseWriter, r *http.Request) {
 		Secure:   app.config.Env == "production",
 	})
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"user": user}, nil)
+	err = app.writeJSON(w, http.StatusCreated, envelope{"user": dto.NewSelfUserResponse(user)}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
 }
 
 func (app *application) signin(w http.ResponseWriter, r *http.Request) {
-	var dto dto.SigninDTO
-	err := app.readJSON(w, r, &dto)
+	var input dto.SigninDTO
+	err := app.readJSON(w, r, &input)
 	if err != nil {
 		app.errBadRequest(w, r, err)
 		return
 	}
 
-	errmap := validator.Schema().SigninDTO.Validate(&dto)
+	errmap := validator.Schema().SigninDTO.Validate(&input)
 	if errmap != nil {
 		app.errFailedValidation(w, r, validator.Sanitize(errmap))
 		return
 	}
 
-	user, err := app.models.User.GetByEmail(dto.Email)
+	user, err := app.models.User.GetByEmail(input.Email)
 	if err != nil {
 		switch {
 		case errors.Is(err, models.ErrRecordNotFound):
@@ -110,7 +136,7 @@ func (app *application) signin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	match, err := user.Password.Matches(dto.Password)
+	match, err := user.Password.Matches(input.Password)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 		return
@@ -119,6 +145,10 @@ func (app *application) signin(w http.ResponseWriter, r *http.Request) {
 		app.errInvalidCredentials(w, r)
 		return
 	}
+	if user.SuspendedAt != nil {
+		app.errAccountSuspended(w, r)
+		return
+	}
 
 	expiration := time.Now().Add(7 * 24 * time.Hour)
 	claim := app.NewJWTClaim(user.ID.Hex(), expiration)
@@ -138,13 +168,24 @@ func (app *application) signin(w http.ResponseWriter, r *http.Request) {
 		Secure:   app.config.Env == "production",
 	})
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"user": user}, nil)
+	err = app.writeJSON(w, http.StatusCreated, envelope{"user": dto.NewSelfUserResponse(user)}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
 }
 
+// signout ends the session and revokes the chat tokens of its user, a signed
+// out browser can't chat anymore.
 func (app *application) signout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie("jwt-auth-token.streamify"); err == nil {
+		var claim JWTClaim
+		if err := app.DecodeJwtToken(cookie.Value, &claim, app.config.JWT.AuthSecret); err == nil {
+			if uid, err := bson.ObjectIDFromHex(claim.UserID); err == nil {
+				app.revokeChatTokens(uid)
+			}
+		}
+	}
+
 	http.SetCookie(w, &http.Cookie{
 		Name:     "jwt-auth-token.streamify",
 		Value:    "",
@@ -161,27 +202,90 @@ func (app *application) signout(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// changePassword replaces the password of the current user, who has to give
+// the current one. The chat tokens issued so far are revoked.
+func (app *application) changePassword(w http.ResponseWriter, r *http.Request) {
+	var input dto.ChangePasswordDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().ChangePassword.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	match, err := currentUser.Password.Matches(input.CurrentPassword)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+	if !match {
+		app.errFailedValidation(w, r, map[string][]string{"current_password": {"Password is incorrect"}})
+		return
+	}
+
+	if err := currentUser.Password.Set(input.NewPassword); err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+	if _, err := app.models.User.SetPassword(currentUser); err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	app.revokeChatTokens(currentUser.ID)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "password successfully changed"}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
 func (app *application) onboarding(w http.ResponseWriter, r *http.Request) {
-	var dto dto.OnboardingDTO
-	err := app.readJSON(w, r, &dto)
+	var input dto.OnboardingDTO
+	err := app.readJSON(w, r, &input)
 	if err != nil {
 		app.errBadRequest(w, r, err)
 		return
 	}
 
-	errmap := validator.Schema().OnboardingDTO.Validate(&dto)
+	errmap := validator.Schema().OnboardingDTO.Validate(&input)
 	if errmap != nil {
 		app.errFailedValidation(w, r, validator.Sanitize(errmap))
 		return
 	}
 
+	place, err := app.geocoder.Geocode(r.Context(), input.Location)
+	if err != nil {
+		switch {
+		case errors.Is(err, geo.ErrPlaceNotFound):
+			app.errFailedValidation(w, r, map[string][]string{
+				"location": {"Unknown location, use the format: City, Country"},
+			})
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
 	user := app.contextGetUser(r)
-	user.Bio = dto.Bio
-	user.FullName = dto.Fullname
-	user.NativeLng = dto.NativeLng
-	user.LearningLng = dto.LearningLng
-	user.Location = dto.Location
-	user.ProfilePic = dto.ProfilePic
+	user.Bio = input.Bio
+	user.FullName = input.Fullname
+	user.Languages = make([]models.UserLanguage, 0, len(input.Languages))
+	for _, lng := range input.Languages {
+		user.Languages = append(user.Languages, models.UserLanguage{
+			Code:     lng.Code,
+			Level:    lng.Level,
+			Learning: lng.Learning,
+		})
+	}
+	user.Location = models.NewLocation(place)
+	user.ProfilePic = input.ProfilePic
 	user.IsOnboarded = true
 
 	user, err = app.models.User.Update(user)
@@ -190,8 +294,12 @@ func (app *application) onboarding(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update user in getstream.io
-	_, err = app.stream.UpsertUser(context.Background(), &stream.User{
+	if err := app.models.Referral.MarkOnboarded(user.ID); err != nil {
+		app.logError(r, err)
+	}
+
+	// Update user in the chat service
+	err = app.chat.UpsertUser(context.Background(), chat.User{
 		ID:    user.ID.Hex(),
 		Name:  user.FullName,
 		Image: user.ProfilePic,
@@ -201,7 +309,7 @@ func (app *application) onboarding(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"user": user}, nil)
+	err = app.writeJSON(w, http.StatusCreated, envelope{"user": dto.NewSelfUserResponse(user)}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
@@ -209,7 +317,7 @@ func (app *application) onboarding(w http.ResponseWriter, r *http.Request) {
 
 func (app *application) whoami(w http.ResponseWriter, r *http.Request) {
 	user := app.contextGetUser(r)
-	err := app.writeJSON(w, http.StatusCreated, envelope{"user": user}, nil)
+	err := app.writeJSON(w, http.StatusCreated, envelope{"user": dto.NewSelfUserResponse(user)}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
@@ -1,20 +1,123 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ucok-man/streamify/internal/chat"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 func (app *application) getStreamToken(w http.ResponseWriter, r *http.Request) {
+	app.writeChatToken(w, r, http.StatusCreated)
+}
+
+// refreshStreamToken issues a new token before the current one expires, the
+// chat clients call it from their token provider.
+func (app *application) refreshStreamToken(w http.ResponseWriter, r *http.Request) {
+	app.writeChatToken(w, r, http.StatusOK)
+}
+
+// writeChatToken responds with a chat token of the current user, expiring
+// after API_CHAT_TOKEN_TTL.
+func (app *application) writeChatToken(w http.ResponseWriter, r *http.Request, status int) {
 	currentUser := app.contextGetUser(r)
-	token, err := app.stream.CreateToken(currentUser.ID.Hex(), time.Time{}) // zero time expired
+	issuedAt := time.Now()
+	expiresAt := issuedAt.Add(app.config.Chat.TokenTTL)
+
+	token, err := app.chat.CreateToken(currentUser.ID.Hex(), expiresAt, issuedAt)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"token": token}, nil)
+	err = app.writeJSON(w, status, envelope{
+		"token":      token,
+		"provider":   app.chat.Name(),
+		"expires_at": expiresAt.UTC().Truncate(time.Second),
+	}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
 }
+
+// getChatChannel returns the channel the current user chats with a friend in.
+// The server creates it when they become friends, clients must not derive the
+// id themselves.
+func (app *application) getChatChannel(w http.ResponseWriter, r *http.Request) {
+	friendId, err := bson.ObjectIDFromHex(chi.URLParam(r, "friendId"))
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("invalid friend id value"))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	if !slices.Contains(currentUser.FriendIDs, friendId) {
+		app.errNotFound(w, r)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"channel_id":   chat.DirectChannelID(currentUser.ID.Hex(), friendId.Hex()),
+		"channel_type": chat.ChannelType,
+		"provider":     app.chat.Name(),
+	}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// provisionChatChannel creates the channel of two users who just became
+// friends, in the background. userID is the one who made them friends.
+func (app *application) provisionChatChannel(userID, friendID bson.ObjectID) {
+	channelID := chat.DirectChannelID(userID.Hex(), friendID.Hex())
+
+	app.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := app.chat.CreateChannel(ctx, channelID, userID.Hex(), []string{userID.Hex(), friendID.Hex()})
+		if err != nil {
+			app.logger.Error().Err(err).Str("channel_id", channelID).Msg("Failed creating chat channel")
+		}
+	})
+}
+
+// freezeChatChannel freezes the channel of two users who aren't friends
+// anymore, in the background. Their history stays readable.
+func (app *application) freezeChatChannel(userID, otherID bson.ObjectID) {
+	channelID := chat.DirectChannelID(userID.Hex(), otherID.Hex())
+
+	app.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := app.chat.FreezeChannel(ctx, channelID)
+		if err != nil && !errors.Is(err, chat.ErrChannelNotFound) {
+			app.logger.Error().Err(err).Str("channel_id", channelID).Msg("Failed freezing chat channel")
+		}
+	})
+}
+
+// revokeChatTokens invalidates the chat tokens userID was issued until now,
+// in the background.
+func (app *application) revokeChatTokens(userID bson.ObjectID) {
+	// Tokens carry their issue time in whole seconds, one issued later in
+	// this same second must stay valid.
+	before := time.Now().Truncate(time.Second)
+
+	app.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := app.chat.RevokeTokens(ctx, userID.Hex(), before)
+		if err != nil {
+			app.logger.Error().Err(err).Str("user_id", userID.Hex()).Msg("Failed revoking chat tokens")
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ucok-man/streamify/cmd/api/dto"
+	"github.com/ucok-man/streamify/internal/email"
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/validator"
+)
+
+func (app *application) getEmailPreferences(w http.ResponseWriter, r *http.Request) {
+	currentUser := app.contextGetUser(r)
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"email_preferences": dto.NewEmailPreferencesResponse(currentUser.EmailPreferences)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) updateEmailPreferences(w http.ResponseWriter, r *http.Request) {
+	var input dto.UpdateEmailPreferencesDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().UpdateEmailPreferences.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	preferences := models.EmailPreferences{Locale: input.Locale, Disabled: []models.EmailEvent{}, Digest: input.Digest}
+	for event, enabled := range map[models.EmailEvent]bool{
+		models.EmailFriendRequestReceived: input.FriendRequestReceived,
+		models.EmailFriendRequestAccepted: input.FriendRequestAccepted,
+		models.EmailInviteRedeemed:        input.InviteRedeemed,
+		models.EmailInactivityReminder:    input.InactivityReminder,
+	} {
+		if !enabled {
+			preferences.Disabled = append(preferences.Disabled, event)
+		}
+	}
+
+	user, err := app.models.User.SetEmailPreferences(app.contextGetUser(r), preferences)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"email_preferences": dto.NewEmailPreferencesResponse(user.EmailPreferences)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// unsubscribeEmail opts the user of the signed token out of one kind of
+// email. It needs no session, mail clients post to it for one-click
+// unsubscribe (RFC 8058), the web app page of the email links too.
+func (app *application) unsubscribeEmail(w http.ResponseWriter, r *http.Request) {
+	var input dto.UnsubscribeEmailDTO
+	input.Token = app.queryString(r.URL.Query(), "token", "")
+
+	errmap := validator.Schema().UnsubscribeEmail.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	userID, event, err := app.unsubscribes.Verify(input.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, email.ErrInvalidUnsubscribeToken):
+			app.errFailedValidation(w, r, map[string][]string{"token": {"invalid unsubscribe token"}})
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.User.DisableEmailEvent(userID, event)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "successfully unsubscribed", "event": event}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
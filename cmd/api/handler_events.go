@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/realtime"
+)
+
+// streamEvents sends the events of the current user as server-sent events
+// until the client leaves or the server shuts down. A client reconnecting
+// with Last-Event-ID first gets what it missed, or a resync event when that
+// is no longer known.
+func (app *application) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		app.errInternalServer(w, r, errors.New("streaming unsupported by the response writer"))
+		return
+	}
+
+	// The server write timeout would cut the stream
+	err := http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+
+	// Subscribe before replaying so nothing published in between is lost
+	sub, err := app.events.Subscribe(currentUser.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, realtime.ErrClosed):
+			app.errServiceUnavailable(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+	defer sub.Close()
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = app.queryString(r.URL.Query(), "last_event_id", "")
+	}
+
+	var missed []realtime.Event
+	if lastEventID != "" {
+		var found bool
+		missed, found, err = app.events.Replay(r.Context(), currentUser.ID, lastEventID)
+		if err != nil {
+			app.errInternalServer(w, r, err)
+			return
+		}
+		if !found {
+			resync, err := realtime.NewEvent(currentUser.ID, realtime.EventResync, envelope{"last_event_id": lastEventID})
+			if err != nil {
+				app.errInternalServer(w, r, err)
+				return
+			}
+			missed = []realtime.Event{resync}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Keep reverse proxies from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Events published after subscribing can be both replayed and received
+	sent := make(map[string]struct{}, len(missed))
+	for _, event := range missed {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+		sent[event.ID] = struct{}{}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(app.config.Events.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind or shutting down, the client
+				// reconnects with Last-Event-ID
+				return
+			}
+			if _, ok := sent[event.ID]; ok {
+				delete(sent, event.ID)
+				continue
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event realtime.Event) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
+
+// publishPresence tells the friends of user allowed to see it that they are
+// now in presence, in the background so the request isn't held. The presence
+// tracker announces the next transitions.
+func (app *application) publishPresence(user *models.User, presence models.Presence) {
+	app.presence.Announced(user, presence)
+
+	events, err := realtime.PresenceEvents(user, presence)
+	if err != nil {
+		app.logger.Error().Err(err).Msg("Failed building presence events")
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	app.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := app.events.Publish(ctx, events...); err != nil {
+			app.logger.Error().Err(err).Msg("Failed publishing presence events")
+		}
+	})
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ucok-man/streamify/cmd/api/dto"
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/qr"
+	"github.com/ucok-man/streamify/internal/validator"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	inviteResultBefriended = "befriended"
+	inviteResultRequested  = "requested"
+)
+
+func (app *application) listInvites(w http.ResponseWriter, r *http.Request) {
+	currentUser := app.contextGetUser(r)
+
+	invites, err := app.models.Invite.GetAllByInviter(currentUser.ID)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	response := make([]*dto.InviteResponse, 0, len(invites))
+	for _, invite := range invites {
+		response = append(response, dto.NewInviteResponse(invite, app.inviteURL(invite)))
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"invites": response}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) createInvite(w http.ResponseWriter, r *http.Request) {
+	var input dto.CreateInviteDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().CreateInvite.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+
+	count, err := app.models.Invite.CountActive(currentUser.ID)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+	if count >= models.MaxActiveInvites {
+		app.errBadRequest(w, r, fmt.Errorf("you can't have more than %d invites, revoke one first", models.MaxActiveInvites))
+		return
+	}
+
+	invite := &models.Invite{
+		InviterID: currentUser.ID,
+		MaxUses:   input.MaxUses,
+		ExpiresAt: inviteExpiry(input.ExpiresInHours),
+	}
+
+	invite, err = app.models.Invite.Create(invite)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"invite": dto.NewInviteResponse(invite, app.inviteURL(invite))}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) updateInvite(w http.ResponseWriter, r *http.Request) {
+	invite, ok := app.ownInvite(w, r)
+	if !ok {
+		return
+	}
+
+	var input dto.UpdateInviteDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().UpdateInvite.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	invite.MaxUses = input.MaxUses
+	invite.ExpiresAt = inviteExpiry(input.ExpiresInHours)
+
+	invite, err = app.models.Invite.Update(invite)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"invite": dto.NewInviteResponse(invite, app.inviteURL(invite))}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) rotateInvite(w http.ResponseWriter, r *http.Request) {
+	invite, ok := app.ownInvite(w, r)
+	if !ok {
+		return
+	}
+
+	invite, err := app.models.Invite.Rotate(invite)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"invite": dto.NewInviteResponse(invite, app.inviteURL(invite))}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) revokeInvite(w http.ResponseWriter, r *http.Request) {
+	invite, ok := app.ownInvite(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.Invite.Revoke(invite)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "invite successfully revoked"}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) getInviteQRCode(w http.ResponseWriter, r *http.Request) {
+	invite, ok := app.ownInvite(w, r)
+	if !ok {
+		return
+	}
+
+	var input dto.InviteQRDTO
+	var err error
+
+	input.Format = app.queryString(r.URL.Query(), "format", qr.FormatPNG)
+	input.Size, err = app.queryInt(r.URL.Query(), "size", 256)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("size, %v", err))
+		return
+	}
+
+	errmap := validator.Schema().InviteQR.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	image, err := qr.Render(app.inviteURL(invite), input.Format, input.Size)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", qr.ContentType(input.Format))
+	w.Header().Set("Content-Length", strconv.Itoa(len(image)))
+	// The token changes on rotation, a cached image would share a dead link
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(image)
+}
+
+func (app *application) previewInvite(w http.ResponseWriter, r *http.Request) {
+	invite, err := app.models.Invite.GetByToken(chi.URLParam(r, "token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	inviter, err := app.models.User.GetById(invite.InviterID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"invite": dto.NewInvitePreviewResponse(invite, inviter, app.contextGetUser(r))}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// redeemInvite befriends the current user with the inviter, or sends the
+// inviter a friend request, depending on the invite action of the inviter.
+// Holding the link stands for the inviter's consent, so their friend request
+// policy doesn't apply.
+func (app *application) redeemInvite(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	currentUser := app.contextGetUser(r)
+
+	invite, err := app.models.Invite.GetByToken(token)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	if invite.InviterID == currentUser.ID {
+		app.errBadRequest(w, r, fmt.Errorf("you can't redeem your own invite"))
+		return
+	}
+	if slices.Contains(currentUser.FriendIDs, invite.InviterID) {
+		app.errBadRequest(w, r, fmt.Errorf("already friend with user %v", invite.InviterID.Hex()))
+		return
+	}
+
+	inviter, err := app.models.User.GetById(invite.InviterID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+	if inviter.Blocks(currentUser) {
+		app.errNotPermitted(w, r)
+		return
+	}
+	befriend := inviter.Privacy.InviteAction == models.InviteActionBefriend
+
+	friendRequest, err := app.models.FriendRequest.GetBetween(currentUser.ID, inviter.ID)
+	if err != nil && !errors.Is(err, models.ErrRecordNotFound) {
+		app.errInternalServer(w, r, err)
+		return
+	}
+	if friendRequest != nil && !befriend {
+		app.errBadRequest(w, r, fmt.Errorf(`friend request already exist between you and this user`))
+		return
+	}
+
+	// Count the use last so failed checks don't consume the invite
+	_, err = app.models.Invite.Use(token)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errBadRequest(w, r, fmt.Errorf("invite is expired, revoked or used up"))
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	switch {
+	case friendRequest != nil:
+		friendRequest.Status = models.FriendRequestStatusAccepted
+		friendRequest, err = app.models.FriendRequest.Update(friendRequest)
+	case befriend:
+		friendRequest, err = app.models.FriendRequest.Create(&models.FriendRequest{
+			SenderID:    currentUser.ID,
+			RecipientID: inviter.ID,
+			Status:      models.FriendRequestStatusAccepted,
+		})
+	default:
+		friendRequest, err = app.models.FriendRequest.Create(&models.FriendRequest{
+			SenderID:    currentUser.ID,
+			RecipientID: inviter.ID,
+			Status:      models.FriendRequestStatusPending,
+		})
+	}
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	result := inviteResultRequested
+	if befriend {
+		result = inviteResultBefriended
+
+		if err := app.models.User.AddFriends(currentUser.ID, inviter.ID); err != nil {
+			app.errInternalServer(w, r, err)
+			return
+		}
+		if err := app.models.User.AddFriends(inviter.ID, currentUser.ID); err != nil {
+			app.errInternalServer(w, r, err)
+			return
+		}
+		app.provisionChatChannel(currentUser.ID, inviter.ID)
+		err = app.notifier.InviteRedeemed(r.Context(), invite, currentUser.ID, friendRequest)
+	} else {
+		err = app.notifier.FriendRequestReceived(r.Context(), friendRequest)
+	}
+	if err != nil {
+		app.logError(r, err)
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{
+		"result":         result,
+		"friend_request": dto.NewFriendRequestResponse(friendRequest),
+	}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// ownInvite reads the invite of the inviteId url param, it responds itself and
+// returns false when the invite isn't one of the current user.
+func (app *application) ownInvite(w http.ResponseWriter, r *http.Request) (*models.Invite, bool) {
+	inviteId, err := bson.ObjectIDFromHex(chi.URLParam(r, "inviteId"))
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("invalid invite id value"))
+		return nil, false
+	}
+
+	invite, err := app.models.Invite.GetById(inviteId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return nil, false
+	}
+
+	if invite.InviterID != app.contextGetUser(r).ID || invite.RevokedAt != nil {
+		app.errNotFound(w, r)
+		return nil, false
+	}
+	return invite, true
+}
+
+// inviteURL returns the web app link redeeming invite.
+func (app *application) inviteURL(invite *models.Invite) string {
+	return strings.TrimRight(app.config.App.URL, "/") + "/invite/" + invite.Token
+}
+
+func inviteExpiry(hours int) *time.Time {
+	if hours == 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(time.Duration(hours) * time.Hour)
+	return &expiresAt
+}
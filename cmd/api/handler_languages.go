@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ucok-man/streamify/cmd/api/dto"
+	"github.com/ucok-man/streamify/internal/languages"
+	"github.com/ucok-man/streamify/internal/validator"
+)
+
+func (app *application) listLanguages(w http.ResponseWriter, r *http.Request) {
+	var input dto.ListLanguagesDTO
+	var err error
+
+	input.Query = app.queryString(r.URL.Query(), "query", "")
+	input.Limit, err = app.queryInt(r.URL.Query(), "limit", 500)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("limit, %v", err))
+		return
+	}
+
+	errmap := validator.Schema().ListLanguages.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"languages": languages.Search(input.Query, input.Limit)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ucok-man/streamify/cmd/api/dto"
+	"github.com/ucok-man/streamify/internal/chat"
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/validator"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// The native chat endpoints, when API_CHAT_PROVIDER is native. The changes
+// reach the members live as message.* events, over /events or /ws.
+
+func (app *application) listConversations(w http.ResponseWriter, r *http.Request) {
+	if app.messenger == nil {
+		app.errNotConfigured(w, r, "native chat")
+		return
+	}
+
+	var input dto.ListConversationsDTO
+	var err error
+
+	input.Page, err = app.queryInt(r.URL.Query(), "page", 1)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("page, %v", err))
+		return
+	}
+	input.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 20)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("page_size, %v", err))
+		return
+	}
+	input.Cursor = app.queryString(r.URL.Query(), "cursor", "")
+
+	errmap := validator.Schema().ListConversations.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	conversations, metadata, err := app.models.Conversation.GetAll(models.ConversationListParam{
+		CurrentUser: currentUser,
+		Page:        int64(input.Page),
+		PageSize:    int64(input.PageSize),
+		Cursor:      input.Cursor,
+	})
+	if err != nil {
+		app.errChat(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"conversations": dto.NewConversationsResponse(conversations, currentUser), "metadata": metadata}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// openConversation returns the conversation with a friend, created the first
+// time.
+func (app *application) openConversation(w http.ResponseWriter, r *http.Request) {
+	if app.messenger == nil {
+		app.errNotConfigured(w, r, "native chat")
+		return
+	}
+
+	var input dto.OpenConversationDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().OpenConversation.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	friendID, err := bson.ObjectIDFromHex(input.FriendID)
+	if err != nil {
+		app.errFailedValidation(w, r, map[string][]string{"friend_id": {"Invalid user id"}})
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	conversation, err := app.messenger.Open(currentUser, friendID)
+	if err != nil {
+		app.errChat(w, r, err)
+		return
+	}
+
+	friend, err := app.models.User.GetById(friendID)
+	if err != nil && !errors.Is(err, models.ErrRecordNotFound) {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"conversation": dto.NewConversationResponse(conversation, friend, nil, currentUser)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) listMessages(w http.ResponseWriter, r *http.Request) {
+	if app.messenger == nil {
+		app.errNotConfigured(w, r, "native chat")
+		return
+	}
+
+	var input dto.ListMessagesDTO
+	var err error
+
+	input.Page, err = app.queryInt(r.URL.Query(), "page", 1)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("page, %v", err))
+		return
+	}
+	input.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 50)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("page_size, %v", err))
+		return
+	}
+	input.Cursor = app.queryString(r.URL.Query(), "cursor", "")
+
+	errmap := validator.Schema().ListMessages.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	messages, metadata, err := app.messenger.History(app.contextGetUser(r), models.MessageListParam{
+		ChannelID: chi.URLParam(r, "channelId"),
+		Page:      int64(input.Page),
+		PageSize:  int64(input.PageSize),
+		Cursor:    input.Cursor,
+	})
+	if err != nil {
+		app.errChat(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"messages": dto.NewMessagesResponse(messages), "metadata": metadata}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) sendMessage(w http.ResponseWriter, r *http.Request) {
+	if app.messenger == nil {
+		app.errNotConfigured(w, r, "native chat")
+		return
+	}
+
+	var input dto.SendMessageDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().SendMessage.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	message, err := app.messenger.Send(r.Context(), app.contextGetUser(r), chi.URLParam(r, "channelId"), input.Text)
+	if err != nil {
+		app.errChat(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"message": dto.NewMessageResponse(message)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) editMessage(w http.ResponseWriter, r *http.Request) {
+	if app.messenger == nil {
+		app.errNotConfigured(w, r, "native chat")
+		return
+	}
+
+	messageID, err := bson.ObjectIDFromHex(chi.URLParam(r, "messageId"))
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("invalid message id value"))
+		return
+	}
+
+	var input dto.SendMessageDTO
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().SendMessage.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	message, err := app.messenger.Edit(r.Context(), app.contextGetUser(r), chi.URLParam(r, "channelId"), messageID, input.Text)
+	if err != nil {
+		app.errChat(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": dto.NewMessageResponse(message)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) deleteMessage(w http.ResponseWriter, r *http.Request) {
+	if app.messenger == nil {
+		app.errNotConfigured(w, r, "native chat")
+		return
+	}
+
+	messageID, err := bson.ObjectIDFromHex(chi.URLParam(r, "messageId"))
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("invalid message id value"))
+		return
+	}
+
+	message, err := app.messenger.Delete(r.Context(), app.contextGetUser(r), chi.URLParam(r, "channelId"), messageID)
+	if err != nil {
+		app.errChat(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": dto.NewMessageResponse(message)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// markChannelRead moves the read receipt of the current user forward, it
+// never goes back to an earlier message.
+func (app *application) markChannelRead(w http.ResponseWriter, r *http.Request) {
+	if app.messenger == nil {
+		app.errNotConfigured(w, r, "native chat")
+		return
+	}
+
+	var input dto.MarkChannelReadDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().MarkChannelRead.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	messageID, err := bson.ObjectIDFromHex(input.MessageID)
+	if err != nil {
+		app.errFailedValidation(w, r, map[string][]string{"message_id": {"Invalid message id"}})
+		return
+	}
+
+	read, err := app.messenger.MarkRead(r.Context(), app.contextGetUser(r), chi.URLParam(r, "channelId"), messageID)
+	if err != nil {
+		app.errChat(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"read": dto.NewReadReceiptResponse(read)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// errChat answers the errors of the messenger.
+func (app *application) errChat(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, chat.ErrChannelNotFound), errors.Is(err, chat.ErrMessageNotFound):
+		app.errNotFound(w, r)
+	case errors.Is(err, chat.ErrNotFriends), errors.Is(err, chat.ErrNotSender):
+		app.errNotPermitted(w, r)
+	case errors.Is(err, chat.ErrChannelFrozen):
+		app.errorResponse(w, r, http.StatusConflict, "the conversation is frozen, no new messages can be sent")
+	case errors.Is(err, models.ErrInvalidCursor):
+		app.errFailedValidation(w, r, map[string][]string{"cursor": {"Invalid cursor"}})
+	default:
+		app.errInternalServer(w, r, err)
+	}
+}
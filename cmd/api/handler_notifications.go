@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ucok-man/streamify/cmd/api/dto"
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/validator"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (app *application) listNotifications(w http.ResponseWriter, r *http.Request) {
+	var input dto.ListNotificationsDTO
+	var err error
+
+	input.Page, err = app.queryInt(r.URL.Query(), "page", 1)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("page, %v", err))
+		return
+	}
+	input.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 20)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("page_size, %v", err))
+		return
+	}
+	input.UnreadOnly, err = app.queryBool(r.URL.Query(), "unread_only", false)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("unread_only, %v", err))
+		return
+	}
+	input.Cursor = app.queryString(r.URL.Query(), "cursor", "")
+
+	errmap := validator.Schema().ListNotifications.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	notifications, metadata, err := app.models.Notification.GetAll(models.NotificationListParam{
+		CurrentUser: currentUser,
+		UnreadOnly:  input.UnreadOnly,
+		Page:        int64(input.Page),
+		PageSize:    int64(input.PageSize),
+		Cursor:      input.Cursor,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidCursor):
+			app.errFailedValidation(w, r, map[string][]string{"cursor": {"Invalid cursor"}})
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"notifications": dto.NewNotificationsResponse(notifications, currentUser), "metadata": metadata}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) countUnreadNotifications(w http.ResponseWriter, r *http.Request) {
+	count, err := app.models.Notification.CountUnread(app.contextGetUser(r).ID)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"unread_count": count}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) markNotificationRead(w http.ResponseWriter, r *http.Request) {
+	idparam := chi.URLParam(r, "notificationId")
+	notificationId, err := bson.ObjectIDFromHex(idparam)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("invalid notification id value"))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	notification, err := app.models.Notification.MarkRead(currentUser.ID, notificationId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"notification": dto.NewNotificationResponse(notification, nil, currentUser)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) markAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	count, err := app.models.Notification.MarkAllRead(app.contextGetUser(r).ID)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"marked_read": count}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
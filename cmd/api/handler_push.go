@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ucok-man/streamify/cmd/api/dto"
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/validator"
+	"github.com/ucok-man/streamify/internal/webpush"
+)
+
+// getPushPublicKey returns the VAPID key the browsers subscribe with.
+func (app *application) getPushPublicKey(w http.ResponseWriter, r *http.Request) {
+	if app.push == nil {
+		app.errNotConfigured(w, r, "web push")
+		return
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"public_key": app.push.PublicKey()}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) subscribePush(w http.ResponseWriter, r *http.Request) {
+	if app.push == nil {
+		app.errNotConfigured(w, r, "web push")
+		return
+	}
+
+	var input dto.SubscribePushDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().SubscribePush.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	err = webpush.Subscription{P256dh: input.Keys.P256dh, Auth: input.Keys.Auth}.Validate()
+	if err != nil {
+		app.errFailedValidation(w, r, map[string][]string{"keys": {err.Error()}})
+		return
+	}
+
+	subscription, err := app.models.PushSubscription.Upsert(&models.PushSubscription{
+		UserID:    app.contextGetUser(r).ID,
+		Endpoint:  input.Endpoint,
+		P256dh:    input.Keys.P256dh,
+		Auth:      input.Keys.Auth,
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"subscription": subscription}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) unsubscribePush(w http.ResponseWriter, r *http.Request) {
+	var input dto.UnsubscribePushDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().UnsubscribePush.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	err = app.models.PushSubscription.DeleteByEndpoint(app.contextGetUser(r).ID, input.Endpoint)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "push subscription successfully removed"}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ucok-man/streamify/cmd/api/dto"
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+func (app *application) getReferralStats(w http.ResponseWriter, r *http.Request) {
+	user, err := app.models.User.EnsureReferralCode(app.contextGetUser(r))
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	stats, err := app.models.Referral.Stats(user.ID)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	signupURL := strings.TrimRight(app.config.App.URL, "/") + "/signup?ref=" + url.QueryEscape(user.ReferralCode)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"referrals": dto.NewReferralStatsResponse(user.ReferralCode, signupURL, stats)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// resolveReferral returns the referral of a signup with code, either the
+// referral code of a user or the token of an invite still usable. The referee
+// is left for the caller to set. ErrRecordNotFound is returned for unknown codes.
+func (app *application) resolveReferral(code string) (*models.Referral, error) {
+	referrer, err := app.models.User.GetByReferralCode(code)
+	if err == nil {
+		return &models.Referral{
+			ReferrerID: referrer.ID,
+			Source:     models.ReferralSourceCode,
+			Code:       referrer.ReferralCode,
+		}, nil
+	}
+	if !errors.Is(err, models.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	invite, err := app.models.Invite.GetByToken(code)
+	if err != nil {
+		return nil, err
+	}
+	if !invite.Usable() {
+		return nil, models.ErrRecordNotFound
+	}
+	return &models.Referral{
+		ReferrerID: invite.InviterID,
+		Source:     models.ReferralSourceInvite,
+		Code:       invite.Token,
+	}, nil
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"slices"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/ucok-man/streamify/cmd/api/dto"
@@ -32,69 +34,292 @@ func (app *application) getUserById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	app.writeUserProfile(w, r, user)
+}
+
+func (app *application) getUserByUsername(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+
+	user, err := app.models.User.GetByUsername(username)
+	if err == nil {
+		app.writeUserProfile(w, r, user)
+		return
+	}
+	if !errors.Is(err, models.ErrRecordNotFound) {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	// Old usernames keep resolving during the grace period so shared
+	// links don't break right after a change.
+	redirect, err := app.models.UsernameRedirect.GetByUsername(username)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	user, err = app.models.User.GetById(redirect.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+	if user.Username == "" || user.Blocks(app.contextGetUser(r)) {
+		app.errNotFound(w, r)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", "/api/v1/users/by-username/"+url.PathEscape(user.Username))
+
+	err = app.writeJSON(w, http.StatusMovedPermanently, envelope{"username": user.Username}, headers)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// writeUserProfile responds with user as seen by the current user. Users
+// blocked either way don't exist to each other.
+func (app *application) writeUserProfile(w http.ResponseWriter, r *http.Request, user *models.User) {
+	currentUser := app.contextGetUser(r)
+	if user.Blocks(currentUser) {
+		app.errNotFound(w, r)
+		return
+	}
+
+	var profile any = dto.NewPublicUserResponse(user, currentUser)
+	if slices.Contains(currentUser.FriendIDs, user.ID) {
+		profile = dto.NewFriendUserResponse(user, currentUser)
+	}
+	data := envelope{"user": profile}
+
+	// The friends in common would reveal a hidden friend list
+	if user.SharesFriendListWith(currentUser) {
+		mutualCount, mutualFriends, err := app.models.User.MutualFriends(currentUser, user)
+		if err != nil {
+			app.errInternalServer(w, r, err)
+			return
+		}
+		data["mutual_friends_count"] = mutualCount
+		data["mutual_friends"] = dto.NewMutualFriendsResponse(mutualFriends)
+	}
+
+	err := app.writeJSON(w, http.StatusOK, data, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
 }
 
 func (app *application) recommended(w http.ResponseWriter, r *http.Request) {
-	var dto dto.RecommendedUserDTO
+	var input dto.RecommendedUserDTO
 	var err error
 
-	dto.Page, err = app.queryInt(r.URL.Query(), "page", 1)
+	input.Page, err = app.queryInt(r.URL.Query(), "page", 1)
 	if err != nil {
 		app.errBadRequest(w, r, fmt.Errorf("page, %v", err))
 		return
 	}
-	dto.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 10)
+	input.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 10)
 	if err != nil {
 		app.errBadRequest(w, r, fmt.Errorf("page_size, %v", err))
 		return
 	}
-	dto.Query = app.queryString(r.URL.Query(), "query", "")
+	input.Cursor = app.queryString(r.URL.Query(), "cursor", "")
+	input.Query = app.queryString(r.URL.Query(), "query", "")
+	input.Location = app.queryString(r.URL.Query(), "location", "")
+	input.ActiveWithinDays, err = app.queryInt(r.URL.Query(), "active_within_days", 0)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("active_within_days, %v", err))
+		return
+	}
+	input.NativeLng = app.queryStrings(r.URL.Query(), "native_lng", []string{})
+	input.LearningLng = app.queryStrings(r.URL.Query(), "learning_lng", []string{})
+	input.WithinKm, err = app.queryInt(r.URL.Query(), "within_km", 0)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("within_km, %v", err))
+		return
+	}
+	input.OverlapHours, err = app.queryInt(r.URL.Query(), "overlap_hours", 0)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("overlap_hours, %v", err))
+		return
+	}
 
-	errmap := validator.Schema().RecommendedUser.Validate(&dto)
+	errmap := validator.Schema().RecommendedUser.Validate(&input)
 	if errmap != nil {
 		app.errFailedValidation(w, r, validator.Sanitize(errmap))
 		return
 	}
 
 	currentUser := app.contextGetUser(r)
+	if input.WithinKm > 0 && currentUser.Location.Point == nil {
+		app.errFailedValidation(w, r, map[string][]string{
+			"within_km": {"Set your location before filtering by distance"},
+		})
+		return
+	}
+	if input.OverlapHours > 0 && currentUser.Location.Timezone == "" {
+		app.errFailedValidation(w, r, map[string][]string{
+			"overlap_hours": {"Set your location before filtering by waking hours"},
+		})
+		return
+	}
 	users, metadata, err := app.models.User.Recommended(models.RecommendedUserParam{
+		CurrentUser:      currentUser,
+		Page:             int64(input.Page),
+		PageSize:         int64(input.PageSize),
+		Cursor:           input.Cursor,
+		Query:            input.Query,
+		Location:         input.Location,
+		ActiveWithinDays: int64(input.ActiveWithinDays),
+		NativeLng:        input.NativeLng,
+		LearningLng:      input.LearningLng,
+		WithinKm:         float64(input.WithinKm),
+		OverlapHours:     int64(input.OverlapHours),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidCursor):
+			app.errFailedValidation(w, r, map[string][]string{"cursor": {"Invalid cursor"}})
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"users": dto.NewSuggestedUsersResponse(users, currentUser), "metadata": metadata}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) peopleYouMayKnow(w http.ResponseWriter, r *http.Request) {
+	var input dto.PeopleYouMayKnowDTO
+	var err error
+
+	input.Page, err = app.queryInt(r.URL.Query(), "page", 1)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("page, %v", err))
+		return
+	}
+	input.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 10)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("page_size, %v", err))
+		return
+	}
+
+	errmap := validator.Schema().PeopleYouMayKnow.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	users, metadata, err := app.models.User.PeopleYouMayKnow(models.PeopleYouMayKnowParam{
 		CurrentUser: currentUser,
-		Page:        int64(dto.Page),
-		PageSize:    int64(dto.PageSize),
-		Query:       dto.Query,
+		Page:        int64(input.Page),
+		PageSize:    int64(input.PageSize),
+	})
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"users": dto.NewSuggestedUsersResponse(users, currentUser), "metadata": metadata}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) searchUsers(w http.ResponseWriter, r *http.Request) {
+	var input dto.SearchUsersDTO
+	var err error
+
+	input.Page, err = app.queryInt(r.URL.Query(), "page", 1)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("page, %v", err))
+		return
+	}
+	input.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 10)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("page_size, %v", err))
+		return
+	}
+	input.Query = app.queryString(r.URL.Query(), "query", "")
+	input.Fuzzy, err = app.queryBool(r.URL.Query(), "fuzzy", true)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("fuzzy, %v", err))
+		return
+	}
+	input.Autocomplete, err = app.queryBool(r.URL.Query(), "autocomplete", false)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("autocomplete, %v", err))
+		return
+	}
+	input.NativeLng = app.queryStrings(r.URL.Query(), "native_lng", []string{})
+	input.LearningLng = app.queryStrings(r.URL.Query(), "learning_lng", []string{})
+
+	errmap := validator.Schema().SearchUsers.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	users, facets, metadata, err := app.models.User.Search(models.UserSearchParam{
+		CurrentUser:  currentUser,
+		Query:        input.Query,
+		Fuzzy:        input.Fuzzy,
+		Autocomplete: input.Autocomplete,
+		NativeLng:    input.NativeLng,
+		LearningLng:  input.LearningLng,
+		Ranking: models.UserSearchRanking{
+			Name:      app.config.Search.BoostName,
+			Bio:       app.config.Search.BoostBio,
+			Languages: app.config.Search.BoostLanguages,
+			Location:  app.config.Search.BoostLocation,
+		},
+		Page:     int64(input.Page),
+		PageSize: int64(input.PageSize),
 	})
 	if err != nil {
 		app.errInternalServer(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"users": users, "metadata": metadata}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"users": dto.NewSearchUserResultsResponse(users, currentUser), "facets": facets, "metadata": metadata}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
 }
 
 func (app *application) myfriend(w http.ResponseWriter, r *http.Request) {
-	var dto dto.MyFriendsDTO
+	var input dto.MyFriendsDTO
 	var err error
 
-	dto.Page, err = app.queryInt(r.URL.Query(), "page", 1)
+	input.Page, err = app.queryInt(r.URL.Query(), "page", 1)
 	if err != nil {
 		app.errBadRequest(w, r, fmt.Errorf("page, %v", err))
 		return
 	}
-	dto.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 10)
+	input.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 10)
 	if err != nil {
 		app.errBadRequest(w, r, fmt.Errorf("page_size, %v", err))
 		return
 	}
-	dto.Query = app.queryString(r.URL.Query(), "query", "")
+	input.Cursor = app.queryString(r.URL.Query(), "cursor", "")
+	input.Query = app.queryString(r.URL.Query(), "query", "")
 
-	errmap := validator.Schema().MyFriendsSchema.Validate(&dto)
+	errmap := validator.Schema().MyFriendsSchema.Validate(&input)
 	if errmap != nil {
 		app.errFailedValidation(w, r, validator.Sanitize(errmap))
 		return
@@ -103,16 +328,22 @@ func (app *application) myfriend(w http.ResponseWriter, r *http.Request) {
 	currentUser := app.contextGetUser(r)
 	users, metadata, err := app.models.User.MyFriends(models.MyFriendsParam{
 		CurrentUser: currentUser,
-		Query:       dto.Query,
-		Page:        int64(dto.Page),
-		PageSize:    int64(dto.PageSize),
+		Query:       input.Query,
+		Page:        int64(input.Page),
+		PageSize:    int64(input.PageSize),
+		Cursor:      input.Cursor,
 	})
 	if err != nil {
-		app.errInternalServer(w, r, err)
+		switch {
+		case errors.Is(err, models.ErrInvalidCursor):
+			app.errFailedValidation(w, r, map[string][]string{"cursor": {"Invalid cursor"}})
+		default:
+			app.errInternalServer(w, r, err)
+		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"users": users, "metadata": metadata}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"users": dto.NewFriendUsersResponse(users, currentUser), "metadata": metadata}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
@@ -140,6 +371,11 @@ func (app *application) requestFriend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !recipient.AcceptsFriendRequestFrom(currentUser) {
+		app.errNotPermitted(w, r)
+		return
+	}
+
 	exist, err := app.models.FriendRequest.CheckExisting(currentUser.ID, recipient.ID)
 	if err != nil {
 		app.errInternalServer(w, r, err)
@@ -161,7 +397,11 @@ func (app *application) requestFriend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"friend_request": friendRequest}, nil)
+	if err := app.notifier.FriendRequestReceived(r.Context(), friendRequest); err != nil {
+		app.logError(r, err)
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"friend_request": dto.NewFriendRequestResponse(friendRequest)}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
@@ -211,30 +451,146 @@ func (app *application) acceptFriend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"friend_request": friendRequest}, nil)
+	app.provisionChatChannel(friendRequest.RecipientID, friendRequest.SenderID)
+
+	if err := app.notifier.FriendRequestAccepted(r.Context(), friendRequest); err != nil {
+		app.logError(r, err)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"friend_request": dto.NewFriendRequestResponse(friendRequest)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// unfriend ends the friendship with a friend. Their friend requests are
+// deleted so either can ask again, their chat channel is frozen.
+func (app *application) unfriend(w http.ResponseWriter, r *http.Request) {
+	idparam := chi.URLParam(r, "friendId")
+	friendId, err := bson.ObjectIDFromHex(idparam)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("invalid friend id value"))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	if !slices.Contains(currentUser.FriendIDs, friendId) {
+		app.errNotFound(w, r)
+		return
+	}
+
+	if err := app.models.User.RemoveFriends(currentUser.ID, friendId); err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+	if err := app.models.User.RemoveFriends(friendId, currentUser.ID); err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+	if err := app.models.FriendRequest.DeleteBetween(currentUser.ID, friendId); err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	app.freezeChatChannel(currentUser.ID, friendId)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "friend successfully removed"}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// blockUser stops any contact with a user: the friendship and the friend
+// requests between them end, no new one can be made and their chat channel
+// is frozen.
+func (app *application) blockUser(w http.ResponseWriter, r *http.Request) {
+	idparam := chi.URLParam(r, "userId")
+	userId, err := bson.ObjectIDFromHex(idparam)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("invalid user id value"))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	if userId == currentUser.ID {
+		app.errBadRequest(w, r, fmt.Errorf("you can't block yourself"))
+		return
+	}
+
+	user, err := app.models.User.GetById(userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.errNotFound(w, r)
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	if err := app.models.User.Block(currentUser.ID, user.ID); err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+	if err := app.models.FriendRequest.DeleteBetween(currentUser.ID, user.ID); err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	app.freezeChatChannel(currentUser.ID, user.ID)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "user successfully blocked"}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+// unblockUser lifts a block, the users can send friend requests again.
+func (app *application) unblockUser(w http.ResponseWriter, r *http.Request) {
+	idparam := chi.URLParam(r, "userId")
+	userId, err := bson.ObjectIDFromHex(idparam)
+	if err != nil {
+		app.errBadRequest(w, r, fmt.Errorf("invalid user id value"))
+		return
+	}
+
+	currentUser := app.contextGetUser(r)
+	if !slices.Contains(currentUser.BlockedIDs, userId) {
+		app.errNotFound(w, r)
+		return
+	}
+
+	if err := app.models.User.Unblock(currentUser.ID, userId); err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "user successfully unblocked"}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
 }
 
 func (app *application) getAllFromFriendRequest(w http.ResponseWriter, r *http.Request) {
-	var dto dto.GetAllFromFriendRequestDTO
+	var input dto.GetAllFromFriendRequestDTO
 	var err error
 
-	dto.Page, err = app.queryInt(r.URL.Query(), "page", 1)
+	input.Page, err = app.queryInt(r.URL.Query(), "page", 1)
 	if err != nil {
 		app.errBadRequest(w, r, fmt.Errorf("page, %v", err))
 		return
 	}
-	dto.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 10)
+	input.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 10)
 	if err != nil {
 		app.errBadRequest(w, r, fmt.Errorf("page_size, %v", err))
 		return
 	}
-	dto.SearchSender = app.queryString(r.URL.Query(), "search_sender", "")
-	dto.Status = app.queryString(r.URL.Query(), "status", "All")
+	input.Cursor = app.queryString(r.URL.Query(), "cursor", "")
+	input.SearchSender = app.queryString(r.URL.Query(), "search_sender", "")
+	input.Sort = app.queryString(r.URL.Query(), "sort", models.FriendRequestSortNewest)
+	input.Status = app.queryString(r.URL.Query(), "status", "All")
 
-	errmap := validator.Schema().GetAllFromFriendRequest.Validate(&dto)
+	errmap := validator.Schema().GetAllFromFriendRequest.Validate(&input)
 	if errmap != nil {
 		app.errFailedValidation(w, r, validator.Sanitize(errmap))
 		return
@@ -243,40 +599,49 @@ func (app *application) getAllFromFriendRequest(w http.ResponseWriter, r *http.R
 	currentUser := app.contextGetUser(r)
 	friendRequests, metadata, err := app.models.FriendRequest.GetAllFromFriendRequest(models.GetAllFromFriendRequestParam{
 		CurrentUserId: currentUser.ID,
-		Status:        dto.Status,
-		Page:          int64(dto.Page),
-		PageSize:      int64(dto.PageSize),
-		SearchSender:  dto.SearchSender,
+		Status:        input.Status,
+		Page:          int64(input.Page),
+		PageSize:      int64(input.PageSize),
+		Cursor:        input.Cursor,
+		SearchSender:  input.SearchSender,
+		Sort:          input.Sort,
 	})
 	if err != nil {
-		app.errInternalServer(w, r, err)
+		switch {
+		case errors.Is(err, models.ErrInvalidCursor):
+			app.errFailedValidation(w, r, map[string][]string{"cursor": {"Invalid cursor"}})
+		default:
+			app.errInternalServer(w, r, err)
+		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"friend_requests": friendRequests, "metadata": metadata}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"friend_requests": dto.NewReceivedFriendRequestsResponse(friendRequests, currentUser), "metadata": metadata}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
 }
 
 func (app *application) getAllSendFriendRequest(w http.ResponseWriter, r *http.Request) {
-	var dto dto.GetAllSendFriendRequestDTO
+	var input dto.GetAllSendFriendRequestDTO
 	var err error
 
-	dto.Page, err = app.queryInt(r.URL.Query(), "page", 1)
+	input.Page, err = app.queryInt(r.URL.Query(), "page", 1)
 	if err != nil {
 		app.errBadRequest(w, r, fmt.Errorf("page, %v", err))
 		return
 	}
-	dto.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 10)
+	input.PageSize, err = app.queryInt(r.URL.Query(), "page_size", 10)
 	if err != nil {
 		app.errBadRequest(w, r, fmt.Errorf("page_size, %v", err))
 		return
 	}
-	dto.SearchRecipient = app.queryString(r.URL.Query(), "search_recipient", "")
-	dto.Status = app.queryString(r.URL.Query(), "status", "All")
+	input.Cursor = app.queryString(r.URL.Query(), "cursor", "")
+	input.SearchRecipient = app.queryString(r.URL.Query(), "search_recipient", "")
+	input.Sort = app.queryString(r.URL.Query(), "sort", models.FriendRequestSortNewest)
+	input.Status = app.queryString(r.URL.Query(), "status", "All")
 
-	errmap := validator.Schema().GetAllSendFriendRequest.Validate(&dto)
+	errmap := validator.Schema().GetAllSendFriendRequest.Validate(&input)
 	if errmap != nil {
 		app.errFailedValidation(w, r, validator.Sanitize(errmap))
 		return
@@ -285,18 +650,205 @@ func (app *application) getAllSendFriendRequest(w http.ResponseWriter, r *http.R
 	currentUser := app.contextGetUser(r)
 	friendRequests, metadata, err := app.models.FriendRequest.GetAllSendFriendRequest(models.GetAllSendFriendRequestParam{
 		CurrentUserId:   currentUser.ID,
-		Status:          dto.Status,
-		Page:            int64(dto.Page),
-		PageSize:        int64(dto.PageSize),
-		SearchRecipient: dto.SearchRecipient,
+		Status:          input.Status,
+		Page:            int64(input.Page),
+		PageSize:        int64(input.PageSize),
+		Cursor:          input.Cursor,
+		SearchRecipient: input.SearchRecipient,
+		Sort:            input.Sort,
 	})
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidCursor):
+			app.errFailedValidation(w, r, map[string][]string{"cursor": {"Invalid cursor"}})
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"friend_requests": dto.NewSentFriendRequestsResponse(friendRequests, currentUser), "metadata": metadata}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) getPrivacySettings(w http.ResponseWriter, r *http.Request) {
+	currentUser := app.contextGetUser(r)
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"privacy": dto.NewPrivacyResponse(currentUser.Privacy)}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) updatePrivacySettings(w http.ResponseWriter, r *http.Request) {
+	var input dto.UpdatePrivacyDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"friend_requests": friendRequests, "metadata": metadata}, nil)
+	errmap := validator.Schema().UpdatePrivacy.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	user := app.contextGetUser(r)
+	user.Privacy = models.PrivacySettings{
+		Discoverable:         input.Discoverable,
+		FriendRequests:       input.FriendRequests,
+		EmailVisibility:      input.EmailVisibility,
+		LocationVisibility:   input.LocationVisibility,
+		FriendListVisibility: input.FriendListVisibility,
+		InviteAction:         input.InviteAction,
+		PresenceVisibility:   input.PresenceVisibility,
+	}
+
+	user, err = app.models.User.UpdatePrivacy(user)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"privacy": dto.NewPrivacyResponse(user.Privacy)}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) checkUsernameAvailability(w http.ResponseWriter, r *http.Request) {
+	var input dto.CheckUsernameDTO
+	input.Username = app.queryString(r.URL.Query(), "username", "")
+
+	errmap := validator.Schema().CheckUsername.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	reason, err := app.usernameAvailability(app.contextGetUser(r), input.Username)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"username": dto.UsernameAvailabilityResponse{
+		Username:  input.Username,
+		Available: reason == usernameAvailable,
+		Reason:    reason,
+	}}, nil)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+	}
+}
+
+func (app *application) updateUsername(w http.ResponseWriter, r *http.Request) {
+	var input dto.UpdateUsernameDTO
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errBadRequest(w, r, err)
+		return
+	}
+
+	errmap := validator.Schema().UpdateUsername.Validate(&input)
+	if errmap != nil {
+		app.errFailedValidation(w, r, validator.Sanitize(errmap))
+		return
+	}
+
+	user := app.contextGetUser(r)
+	oldUsername := user.Username
+	renamed := models.UsernameKey(input.Username) != user.UsernameKey
+
+	// Changing the case of the current username is not a change
+	if renamed && oldUsername != "" && time.Now().Before(user.UsernameChangeAllowedAt()) {
+		app.errFailedValidation(w, r, map[string][]string{
+			"username": {fmt.Sprintf("Username can be changed again after %s", user.UsernameChangeAllowedAt().Format(time.RFC3339))},
+		})
+		return
+	}
+
+	reason, err := app.usernameAvailability(user, input.Username)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+	switch reason {
+	case usernameReserved:
+		app.errFailedValidation(w, r, map[string][]string{"username": {"This username is reserved"}})
+		return
+	case usernameTaken:
+		app.errFailedValidation(w, r, map[string][]string{"username": {"This username is already taken"}})
+		return
+	}
+
+	// Taking back one of our own old usernames drops its redirect
+	err = app.models.UsernameRedirect.Delete(input.Username)
+	if err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	user, err = app.models.User.SetUsername(user, input.Username)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrDuplicateUsername):
+			app.errFailedValidation(w, r, map[string][]string{"username": {"This username is already taken"}})
+		default:
+			app.errInternalServer(w, r, err)
+		}
+		return
+	}
+
+	if renamed && oldUsername != "" {
+		err = app.models.UsernameRedirect.Upsert(oldUsername, user.ID)
+		if err != nil {
+			app.errInternalServer(w, r, err)
+			return
+		}
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user": dto.NewSelfUserResponse(user)}, nil)
 	if err != nil {
 		app.errInternalServer(w, r, err)
 	}
 }
+
+const (
+	usernameAvailable = "available"
+	usernameReserved  = "reserved"
+	usernameTaken     = "taken"
+)
+
+// usernameAvailability tells whether user can claim username. Usernames held
+// by a redirect of another user are taken until the grace period ends.
+func (app *application) usernameAvailability(user *models.User, username string) (string, error) {
+	if models.IsReservedUsername(username) {
+		return usernameReserved, nil
+	}
+
+	owner, err := app.models.User.GetByUsername(username)
+	switch {
+	case err == nil:
+		if owner.ID != user.ID {
+			return usernameTaken, nil
+		}
+	case !errors.Is(err, models.ErrRecordNotFound):
+		return "", err
+	}
+
+	redirect, err := app.models.UsernameRedirect.GetByUsername(username)
+	switch {
+	case err == nil:
+		if redirect.UserID != user.ID {
+			return usernameTaken, nil
+		}
+	case !errors.Is(err, models.ErrRecordNotFound):
+		return "", err
+	}
+
+	return usernameAvailable, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// serveWebSocket upgrades the request to a WebSocket speaking the gateway
+// protocol, see realtime.Message.
+func (app *application) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	// The hijacked connection keeps the server timeouts otherwise
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		app.errInternalServer(w, r, err)
+		return
+	}
+
+	// The session cookie comes along cross site too, only the web app pages
+	// may open the connection
+	ws, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: app.webAppHosts(),
+	})
+	if err != nil {
+		// Accept already responded
+		app.logError(r, err)
+		return
+	}
+
+	app.gateway.Serve(r.Context(), ws, app.contextGetUser(r))
+}
+
+// webAppHosts returns the hosts serving the web app, along with the CORS
+// origins.
+func (app *application) webAppHosts() []string {
+	hosts := []string{}
+	for _, origin := range append([]string{app.config.App.URL}, app.config.Cors.Origins...) {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		hosts = append(hosts, u.Host)
+	}
+	return hosts
+}
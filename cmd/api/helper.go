@@ -178,6 +178,11 @@ func (app *application) DecodeJwtToken(inputToken string, claim *JWTClaim, secre
 	if !token.Valid {
 		return err
 	}
+	// Sessions have no audience, the tokens signed for other purposes with
+	// the same secret, like the chat's, do
+	if len(claim.Audience) > 0 {
+		return errors.New("token is not a session token")
+	}
 	return nil
 }
 
@@ -213,3 +218,35 @@ func (app *application) queryInt(qs url.Values, key string, defaultValue int) (i
 	}
 	return i, nil
 }
+
+func (app *application) queryBool(qs url.Values, key string, defaultValue bool) (bool, error) {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return defaultValue, err
+	}
+	return b, nil
+}
+
+/* ---------------------------------------------------------------- */
+/*                         Background tasks                         */
+/* ---------------------------------------------------------------- */
+
+// background runs fn in a goroutine awaited on shutdown, recovering its panics.
+func (app *application) background(fn func()) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Error().Any("panic", err).Msg("Background task panicked")
+			}
+		}()
+
+		fn()
+	}()
+}
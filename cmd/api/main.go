@@ -4,20 +4,38 @@ import (
 	"context"
 	"sync"
 
-	stream "github.com/GetStream/stream-chat-go/v5"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/ucok-man/streamify/internal/chat"
 	"github.com/ucok-man/streamify/internal/config"
+	"github.com/ucok-man/streamify/internal/email"
+	"github.com/ucok-man/streamify/internal/geo"
 	"github.com/ucok-man/streamify/internal/logger"
 	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/notify"
+	"github.com/ucok-man/streamify/internal/realtime"
+	"github.com/ucok-man/streamify/internal/webpush"
 )
 
 type application struct {
-	config config.Config
-	logger *zerolog.Logger
-	models models.Models
-	stream *stream.Client
-	wg     sync.WaitGroup
+	config    config.Config
+	logger    *zerolog.Logger
+	models    models.Models
+	chat      chat.Provider
+	messenger *chat.Messenger // nil unless the chat provider is native
+	geocoder  geo.Geocoder
+	notifier  *notify.Service
+	events    realtime.PubSub
+	presence  *realtime.PresenceTracker
+	gateway   *realtime.Gateway
+	push      *webpush.Client // nil when web push isn't configured
+
+	mailQueue    *email.Queue
+	reminder     *email.Reminder
+	digester     *email.Digester
+	unsubscribes *email.UnsubscribeTokens
+
+	wg sync.WaitGroup
 }
 
 func main() {
@@ -33,17 +51,152 @@ func main() {
 	}
 	defer dbclient.Disconnect(context.Background())
 
-	// Initialize stream chat client
-	streamChatClient, err := stream.NewClient(cfg.GetStreamIO.ApiKey, cfg.GetStreamIO.ApiSecret)
+	db := dbclient.Database(cfg.DB.DatabaseName)
+	searchBackend, err := models.NewSearchBackend(
+		cfg.DB.SearchBackend,
+		db.Collection("users"),
+		applog.With().Str("context", "search_backend").Logger(),
+	)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed initialize search backend")
+	}
+
+	events, err := realtime.NewPubSub(
+		cfg.Events.Backend,
+		db.Collection("events"),
+		realtime.Options{
+			ReplaySize:       cfg.Events.ReplaySize,
+			ReplayTTL:        cfg.Events.ReplayTTL,
+			SubscriberBuffer: realtime.DefaultOptions.SubscriberBuffer,
+		},
+		applog.With().Str("context", "events_pubsub").Logger(),
+	)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed initialize events pubsub")
+	}
+
+	appmodels := models.NewModels(db, searchBackend, models.NewCursorCodec(cfg.JWT.AuthSecret), cfg.Notify.Retention, applog)
+
+	chatProvider, err := chat.NewProvider(cfg.Chat.Provider, chat.Options{
+		GetStreamAPIKey:    cfg.GetStreamIO.ApiKey,
+		GetStreamAPISecret: cfg.GetStreamIO.ApiSecret,
+		TokenSecret:        cfg.JWT.AuthSecret,
+		Conversations:      appmodels.Conversation,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed initialize chat provider")
+	}
+
+	var messenger *chat.Messenger
+	if _, ok := chatProvider.(*chat.NativeProvider); ok {
+		messenger = chat.NewMessenger(
+			appmodels.Conversation,
+			appmodels.Message,
+			events,
+			applog.With().Str("context", "chat_messenger").Logger(),
+		)
+	}
+
+	presence := realtime.NewPresenceTracker(
+		events,
+		appmodels.User,
+		applog.With().Str("context", "presence_tracker").Logger(),
+	)
+
+	gateway := realtime.NewGateway(
+		events,
+		appmodels.User,
+		presence,
+		realtime.GatewayOptions{
+			SendBuffer:     cfg.WebSocket.SendBuffer,
+			RateLimit:      cfg.WebSocket.RateLimit,
+			RateBurst:      cfg.WebSocket.RateBurst,
+			PingInterval:   cfg.Events.Heartbeat,
+			WriteTimeout:   realtime.DefaultGatewayOptions.WriteTimeout,
+			MaxMessageSize: realtime.DefaultGatewayOptions.MaxMessageSize,
+		},
+		applog.With().Str("context", "websocket_gateway").Logger(),
+	)
+
+	mailSender, err := email.NewSender(cfg.Mail.Sender, email.SenderOptions{
+		SMTPHost:     cfg.Mail.SMTPHost,
+		SMTPPort:     cfg.Mail.SMTPPort,
+		SMTPUsername: cfg.Mail.SMTPUsername,
+		SMTPPassword: cfg.Mail.SMTPPassword,
+		FileDir:      cfg.Mail.FileDir,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed initialize email sender")
+	}
+	mailRenderer, err := email.NewRenderer()
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed initialize stream chat client")
+		log.Fatal().Err(err).Msg("Failed parsing email templates")
+	}
+
+	queueOpts := email.DefaultQueueOptions
+	queueOpts.From = cfg.Mail.From
+	queueOpts.MaxAttempts = cfg.Mail.MaxAttempts
+	mailQueue := email.NewQueue(appmodels.EmailJob, mailSender, queueOpts, applog.With().Str("context", "email_queue").Logger())
+
+	unsubscribes := email.NewUnsubscribeTokens(cfg.JWT.AuthSecret)
+	mailer := email.NewMailer(mailRenderer, mailQueue, unsubscribes, cfg.App.URL)
+	reminder := email.NewReminder(
+		mailer,
+		appmodels.User,
+		appmodels.FriendRequest,
+		cfg.Mail.InactivityAfter,
+		applog.With().Str("context", "email_reminder").Logger(),
+	)
+	digester := email.NewDigester(
+		mailer,
+		appmodels.User,
+		appmodels.FriendRequest,
+		appmodels.Notification,
+		cfg.Mail.DigestHour,
+		applog.With().Str("context", "email_digester").Logger(),
+	)
+
+	var pushClient *webpush.Client
+	if cfg.WebPush.VAPIDPublicKey != "" || cfg.WebPush.VAPIDPrivateKey != "" {
+		vapid, err := webpush.NewVAPID(cfg.WebPush.VAPIDPublicKey, cfg.WebPush.VAPIDPrivateKey, cfg.WebPush.Subject)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed loading web push VAPID keys")
+		}
+		pushClient = webpush.NewClient(vapid)
+	} else {
+		applog.Info().Msg("Web push is disabled, generate VAPID keys with streamify-cli push vapid")
 	}
 
 	app := &application{
-		config: cfg,
-		logger: applog,
-		stream: streamChatClient,
-		models: models.NewModels(dbclient.Database(cfg.DB.DatabaseName), applog),
+		config:    cfg,
+		logger:    applog,
+		chat:      chatProvider,
+		messenger: messenger,
+		geocoder:  geo.NewOfflineGeocoder(),
+		models:    appmodels,
+		notifier:  notify.New(appmodels.Notification, applog.With().Str("context", "notify_service").Logger()),
+		events:    events,
+		presence:  presence,
+		gateway:   gateway,
+		push:      pushClient,
+
+		mailQueue:    mailQueue,
+		reminder:     reminder,
+		digester:     digester,
+		unsubscribes: unsubscribes,
+	}
+	app.notifier.Register(realtime.NewNotificationSender(events))
+	app.notifier.Register(email.NewNotificationSender(mailer, appmodels.User))
+	if pushClient != nil {
+		app.notifier.Register(webpush.NewNotificationSender(
+			pushClient,
+			appmodels.PushSubscription,
+			appmodels.User,
+			cfg.App.URL,
+			webpush.Options{TTL: cfg.WebPush.TTL, Urgency: cfg.WebPush.Urgency},
+			app.background,
+			applog.With().Str("context", "webpush_sender").Logger(),
+		))
 	}
 
 	if err := app.serve(); err != nil {
@@ -3,10 +3,12 @@ package main
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/realtime"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
@@ -57,6 +59,19 @@ func (app *application) withAuthentication(next http.Handler) http.Handler {
 			}
 		}
 
+		if user.SuspendedAt != nil {
+			app.errAccountSuspended(w, r)
+			return
+		}
+
+		// Throttled in the model, a failure only delays the presence update
+		previousActiveAt := user.LastActiveAt
+		if err := app.models.User.TouchLastActive(user); err != nil {
+			app.logError(r, err)
+		} else if realtime.CameOnline(previousActiveAt, time.Now()) {
+			app.publishPresence(user, models.PresenceOnline)
+		}
+
 		r = app.contextSetUser(r, user)
 		next.ServeHTTP(w, r)
 	})
@@ -23,13 +23,30 @@ func (app *application) routes() http.Handler {
 			r.With(app.withAuthentication).Post("/onboarding", app.onboarding)
 			r.With(app.withAuthentication).Get("/me", app.whoami)
 		})
+		r.Get("/languages", app.listLanguages)
 		r.Route("/users", func(r chi.Router) {
 			r.Use(app.withAuthentication)
 
+			r.Get("/me/privacy", app.getPrivacySettings)
+			r.Put("/me/privacy", app.updatePrivacySettings)
+			r.Put("/me/username", app.updateUsername)
+			r.Put("/me/password", app.changePassword)
+			r.Get("/me/referrals", app.getReferralStats)
+			r.Get("/me/email-preferences", app.getEmailPreferences)
+			r.Put("/me/email-preferences", app.updateEmailPreferences)
+
+			r.Get("/username-availability", app.checkUsernameAvailability)
+			r.Get("/by-username/{username}", app.getUserByUsername)
+
 			r.Get("/{userId}", app.getUserById)
+			r.Post("/{userId}/block", app.blockUser)
+			r.Delete("/{userId}/block", app.unblockUser)
 
+			r.Get("/search", app.searchUsers)
 			r.Get("/recommended", app.recommended)
+			r.Get("/people-you-may-know", app.peopleYouMayKnow)
 			r.Get("/friends-with-me", app.myfriend)
+			r.Delete("/friends/{friendId}", app.unfriend)
 
 			r.Route("/friends-request", func(r chi.Router) {
 				r.Post("/create/{recipientId}", app.requestFriend)
@@ -38,9 +55,50 @@ func (app *application) routes() http.Handler {
 				r.Get("/send", app.getAllSendFriendRequest)
 			})
 		})
+		r.Route("/invites", func(r chi.Router) {
+			r.Use(app.withAuthentication)
+
+			r.Get("/", app.listInvites)
+			r.Post("/", app.createInvite)
+			r.Put("/{inviteId}", app.updateInvite)
+			r.Delete("/{inviteId}", app.revokeInvite)
+			r.Post("/{inviteId}/rotate", app.rotateInvite)
+			r.Get("/{inviteId}/qr", app.getInviteQRCode)
+
+			r.Get("/redeem/{token}", app.previewInvite)
+			r.Post("/redeem/{token}", app.redeemInvite)
+		})
+		r.Route("/email", func(r chi.Router) {
+			r.Post("/unsubscribe", app.unsubscribeEmail)
+		})
+		r.Route("/notifications", func(r chi.Router) {
+			r.Use(app.withAuthentication)
+
+			r.Get("/", app.listNotifications)
+			r.Get("/unread-count", app.countUnreadNotifications)
+			r.Post("/read-all", app.markAllNotificationsRead)
+			r.Post("/{notificationId}/read", app.markNotificationRead)
+		})
+		r.Route("/push", func(r chi.Router) {
+			r.Get("/public-key", app.getPushPublicKey)
+			r.With(app.withAuthentication).Post("/subscriptions", app.subscribePush)
+			r.With(app.withAuthentication).Delete("/subscriptions", app.unsubscribePush)
+		})
+		r.With(app.withAuthentication).Get("/events", app.streamEvents)
+		r.With(app.withAuthentication).Get("/ws", app.serveWebSocket)
 		r.Route("/chat", func(r chi.Router) {
 			r.Use(app.withAuthentication)
 			r.Get("/token", app.getStreamToken)
+			r.Post("/token/refresh", app.refreshStreamToken)
+			r.Get("/channels/{friendId}", app.getChatChannel)
+
+			r.Get("/conversations", app.listConversations)
+			r.Post("/conversations", app.openConversation)
+			r.Get("/conversations/{channelId}/messages", app.listMessages)
+			r.Post("/conversations/{channelId}/messages", app.sendMessage)
+			r.Patch("/conversations/{channelId}/messages/{messageId}", app.editMessage)
+			r.Delete("/conversations/{channelId}/messages/{messageId}", app.deleteMessage)
+			r.Post("/conversations/{channelId}/read", app.markChannelRead)
 		})
 	})
 
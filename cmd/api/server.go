@@ -24,6 +24,17 @@ func (app *application) serve() error {
 		WriteTimeout: 30 * time.Second,
 	}
 
+	// Streaming connections never go idle, end them so Shutdown can return
+	srv.RegisterOnShutdown(app.events.Close)
+
+	// Background jobs stop with the server, Shutdown waits for them on app.wg
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	srv.RegisterOnShutdown(stopJobs)
+	app.background(func() { app.mailQueue.Run(jobsCtx) })
+	app.background(func() { app.reminder.Run(jobsCtx) })
+	app.background(func() { app.digester.Run(jobsCtx) })
+	app.background(func() { app.presence.Run(jobsCtx) })
+
 	shutdownError := make(chan error)
 	go func() {
 		// Create a quit channel which carries os.Signal values. Use buffered
@@ -43,7 +54,13 @@ func (app *application) serve() error {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		err := srv.Shutdown(ctx)
+		// Hijacked WebSocket connections aren't tracked by Shutdown, drain them first
+		err := app.gateway.Shutdown(ctx)
+		if err != nil {
+			app.logger.Error().Err(err).Msg("Failed draining websocket connections")
+		}
+
+		err = srv.Shutdown(ctx)
 		if err != nil {
 			shutdownError <- err
 		}
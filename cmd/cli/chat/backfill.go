@@ -0,0 +1,133 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	chatprovider "github.com/ucok-man/streamify/internal/chat"
+	"github.com/ucok-man/streamify/internal/config"
+	"github.com/ucok-man/streamify/internal/logger"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var backfillFlags struct {
+	dryRun bool
+	batch  int64
+}
+
+func init() {
+	backfillCmd.Flags().BoolVar(&backfillFlags.dryRun, "dry-run", false, "print the channels instead of creating them")
+	backfillCmd.Flags().Int64Var(&backfillFlags.batch, "batch", 100, "number of users read at once")
+}
+
+var backfillCmd = &cobra.Command{
+	Use:     "backfill",
+	Short:   "Create the chat channel of every friendship made before the server created them",
+	Example: "- streamify-cli chat backfill --dry-run\n- streamify-cli chat backfill",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if backfillFlags.batch < 1 || backfillFlags.batch > 1000 {
+			return fmt.Errorf("--batch must be between 1 and 1000")
+		}
+
+		cfg := config.New()
+		logger, err := logger.New(cfg.Log.Level, cfg.Env)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize logger")
+		}
+
+		conn, err := cfg.OpenDB()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize db connection")
+		}
+		defer conn.Disconnect(context.Background())
+
+		db := conn.Database(cfg.DB.DatabaseName)
+		searchBackend, err := models.NewSearchBackend(
+			cfg.DB.SearchBackend,
+			db.Collection("users"),
+			logger.With().Str("context", "search_backend").Logger(),
+		)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize search backend")
+		}
+		cursors := models.NewCursorCodec(cfg.JWT.AuthSecret)
+		userModel := models.NewUserModel(
+			db.Collection("users"),
+			searchBackend,
+			cursors,
+			logger.With().Str("context", "user_model_service").Logger(),
+		)
+
+		provider, err := chatprovider.NewProvider(cfg.Chat.Provider, chatprovider.Options{
+			GetStreamAPIKey:    cfg.GetStreamIO.ApiKey,
+			GetStreamAPISecret: cfg.GetStreamIO.ApiSecret,
+			TokenSecret:        cfg.JWT.AuthSecret,
+			Conversations: models.NewConversationModel(
+				db.Collection("conversations"),
+				cursors,
+				logger.With().Str("context", "conversation_model_service").Logger(),
+			),
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize chat provider")
+		}
+
+		// Both friends list each other, every channel comes up twice
+		seen := make(map[string]struct{})
+		var created, failed int
+		afterID := bson.ObjectID{}
+		for {
+			users, err := userModel.WithFriends(afterID, backfillFlags.batch)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error reading friendships")
+			}
+			if len(users) == 0 {
+				break
+			}
+			afterID = users[len(users)-1].ID
+
+			for _, user := range users {
+				for _, friendID := range user.FriendIDs {
+					channelID := chatprovider.DirectChannelID(user.ID.Hex(), friendID.Hex())
+					if _, ok := seen[channelID]; ok {
+						continue
+					}
+					seen[channelID] = struct{}{}
+
+					members := []string{user.ID.Hex(), friendID.Hex()}
+					slices.Sort(members)
+					if backfillFlags.dryRun {
+						fmt.Printf("%s\tcreated by %s\n", channelID, members[0])
+						created++
+						continue
+					}
+
+					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+					err := provider.CreateChannel(ctx, channelID, members[0], members)
+					cancel()
+					if err != nil {
+						logger.Error().Err(err).Str("channel_id", channelID).Msg("Failed creating chat channel")
+						failed++
+						continue
+					}
+					created++
+				}
+			}
+		}
+
+		switch {
+		case backfillFlags.dryRun:
+			fmt.Printf("\n%d channels would be created on %s.\n", created, provider.Name())
+		default:
+			fmt.Printf("%d channels created on %s, %d failed.\n", created, provider.Name(), failed)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d channels failed, run the backfill again", failed)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,14 @@
+package chat
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	ChatCmd.AddCommand(backfillCmd)
+}
+
+var ChatCmd = &cobra.Command{
+	Use:   "chat",
+	Short: "Manage the chat channels",
+}
@@ -3,11 +3,12 @@ package db
 import (
 	"github.com/spf13/cobra"
 	"github.com/ucok-man/streamify/cmd/cli/db/drop"
+	"github.com/ucok-man/streamify/cmd/cli/db/migrate"
 	"github.com/ucok-man/streamify/cmd/cli/db/seed"
 )
 
 func init() {
-	DBCmd.AddCommand(seed.SeedCmd, drop.DropCmd)
+	DBCmd.AddCommand(seed.SeedCmd, drop.DropCmd, migrate.MigrateCmd)
 }
 
 var DBCmd = &cobra.Command{
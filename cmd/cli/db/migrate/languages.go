@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"context"
+	"slices"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/ucok-man/streamify/internal/config"
+	"github.com/ucok-man/streamify/internal/languages"
+	"github.com/ucok-man/streamify/internal/logger"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var languagesCmd = &cobra.Command{
+	Use:   "languages",
+	Short: "Convert native_lng and learning_lng into the languages list and normalize language codes",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.New()
+		logger, err := logger.New(cfg.Log.Level, cfg.Env)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize logger")
+		}
+
+		conn, err := cfg.OpenDB()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize db connection")
+		}
+		defer conn.Disconnect(context.Background())
+
+		userColl := conn.Database(cfg.DB.DatabaseName).Collection("users")
+
+		filter := bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "native_lng", Value: bson.D{{Key: "$exists", Value: true}}}},
+			bson.D{{Key: "learning_lng", Value: bson.D{{Key: "$exists", Value: true}}}},
+			bson.D{{Key: "languages", Value: bson.D{{Key: "$exists", Value: true}}}},
+		}}}
+
+		cursor, err := userColl.Find(context.Background(), filter)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Error finding users")
+		}
+		defer cursor.Close(context.Background())
+
+		logger.Info().Msg("Begin migrating user languages...")
+		var migrated int
+		for cursor.Next(context.Background()) {
+			var doc struct {
+				ID          bson.ObjectID         `bson:"_id"`
+				NativeLng   *string               `bson:"native_lng"`
+				LearningLng *string               `bson:"learning_lng"`
+				Languages   []models.UserLanguage `bson:"languages"`
+			}
+			if err := cursor.Decode(&doc); err != nil {
+				logger.Fatal().Err(err).Msg("Error decoding user")
+			}
+
+			userlog := logger.With().Str("user_id", doc.ID.Hex()).Logger()
+			entries := slices.Clone(doc.Languages)
+			if doc.NativeLng != nil && *doc.NativeLng != "" {
+				entries = append(entries, models.UserLanguage{
+					Code:  *doc.NativeLng,
+					Level: models.LanguageLevelNative,
+				})
+			}
+			if doc.LearningLng != nil && *doc.LearningLng != "" {
+				// The legacy model has no proficiency, assume a beginner.
+				entries = append(entries, models.UserLanguage{
+					Code:     *doc.LearningLng,
+					Level:    models.LanguageLevelA1,
+					Learning: true,
+				})
+			}
+
+			normalized := normalizeLanguages(entries, &userlog)
+			legacy := doc.NativeLng != nil || doc.LearningLng != nil
+			if !legacy && slices.Equal(normalized, doc.Languages) {
+				continue
+			}
+
+			_, err := userColl.UpdateByID(context.Background(), doc.ID, bson.D{
+				{Key: "$set", Value: bson.D{{Key: "languages", Value: normalized}}},
+				{Key: "$unset", Value: bson.D{
+					{Key: "native_lng", Value: ""},
+					{Key: "learning_lng", Value: ""},
+				}},
+			})
+			if err != nil {
+				userlog.Fatal().Err(err).Msg("Error updating user languages")
+			}
+			migrated++
+		}
+		if err := cursor.Err(); err != nil {
+			logger.Fatal().Err(err).Msg("Error iterating users")
+		}
+
+		logger.Info().Msgf("Success migrating %v users", migrated)
+	},
+}
+
+// normalizeLanguages maps every entry to its canonical code, dropping unknown
+// languages and keeping the first entry of duplicated codes.
+func normalizeLanguages(entries []models.UserLanguage, logger *zerolog.Logger) []models.UserLanguage {
+	normalized := []models.UserLanguage{}
+	for _, entry := range entries {
+		code, ok := languages.Normalize(entry.Code)
+		if !ok {
+			logger.Warn().Str("language", entry.Code).Msg("Unknown language, dropping")
+			continue
+		}
+
+		duplicate := slices.ContainsFunc(normalized, func(lng models.UserLanguage) bool {
+			return lng.Code == code
+		})
+		if duplicate {
+			continue
+		}
+
+		entry.Code = code
+		normalized = append(normalized, entry)
+	}
+	return normalized
+}
@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/ucok-man/streamify/internal/config"
+	"github.com/ucok-man/streamify/internal/geo"
+	"github.com/ucok-man/streamify/internal/logger"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var locationCmd = &cobra.Command{
+	Use:   "location",
+	Short: "Convert free-text locations into structured locations",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.New()
+		logger, err := logger.New(cfg.Log.Level, cfg.Env)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize logger")
+		}
+
+		conn, err := cfg.OpenDB()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize db connection")
+		}
+		defer conn.Disconnect(context.Background())
+
+		userColl := conn.Database(cfg.DB.DatabaseName).Collection("users")
+		geocoder := geo.NewOfflineGeocoder()
+
+		filter := bson.D{{Key: "location", Value: bson.D{{Key: "$type", Value: "string"}}}}
+		cursor, err := userColl.Find(context.Background(), filter)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Error finding users")
+		}
+		defer cursor.Close(context.Background())
+
+		logger.Info().Msg("Begin migrating user locations...")
+		var migrated, unresolved int
+		for cursor.Next(context.Background()) {
+			var doc struct {
+				ID       bson.ObjectID `bson:"_id"`
+				Location string        `bson:"location"`
+			}
+			if err := cursor.Decode(&doc); err != nil {
+				logger.Fatal().Err(err).Msg("Error decoding user")
+			}
+
+			var location models.Location
+			place, err := geocoder.Geocode(context.Background(), doc.Location)
+			switch {
+			case err == nil:
+				location = models.NewLocation(place)
+			case errors.Is(err, geo.ErrPlaceNotFound):
+				// Keep the text so the user can fix it, without coordinates.
+				logger.Warn().Str("user_id", doc.ID.Hex()).Str("location", doc.Location).Msg("Unknown location, keeping city only")
+				location = models.Location{City: doc.Location}
+				unresolved++
+			default:
+				logger.Fatal().Err(err).Msg("Error geocoding location")
+			}
+
+			_, err = userColl.UpdateByID(context.Background(), doc.ID, bson.D{
+				{Key: "$set", Value: bson.D{{Key: "location", Value: location}}},
+			})
+			if err != nil {
+				logger.Fatal().Err(err).Str("user_id", doc.ID.Hex()).Msg("Error updating user location")
+			}
+			migrated++
+		}
+		if err := cursor.Err(); err != nil {
+			logger.Fatal().Err(err).Msg("Error iterating users")
+		}
+
+		logger.Info().Int("unresolved", unresolved).Msgf("Success migrating %v users", migrated)
+	},
+}
@@ -0,0 +1,14 @@
+package migrate
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	MigrateCmd.AddCommand(languagesCmd, locationCmd, privacyCmd)
+}
+
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Run data migration on existing documents",
+}
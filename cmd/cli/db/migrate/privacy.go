@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/ucok-man/streamify/internal/config"
+	"github.com/ucok-man/streamify/internal/logger"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var privacyCmd = &cobra.Command{
+	Use:   "privacy",
+	Short: "Set the default privacy settings on users without any",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.New()
+		logger, err := logger.New(cfg.Log.Level, cfg.Env)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize logger")
+		}
+
+		conn, err := cfg.OpenDB()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize db connection")
+		}
+		defer conn.Disconnect(context.Background())
+
+		userColl := conn.Database(cfg.DB.DatabaseName).Collection("users")
+
+		logger.Info().Msg("Begin migrating user privacy settings...")
+		result, err := userColl.UpdateMany(context.Background(),
+			bson.D{{Key: "privacy", Value: bson.D{{Key: "$exists", Value: false}}}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "privacy", Value: models.DefaultPrivacySettings}}}},
+		)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Error updating user privacy settings")
+		}
+
+		logger.Info().Msgf("Success migrating %v users", result.ModifiedCount)
+	},
+}
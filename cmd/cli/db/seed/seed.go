@@ -7,9 +7,11 @@ import (
 	"time"
 
 	"github.com/0x6flab/namegenerator"
-	stream "github.com/GetStream/stream-chat-go/v5"
 	"github.com/spf13/cobra"
+	"github.com/ucok-man/streamify/internal/chat"
 	"github.com/ucok-man/streamify/internal/config"
+	"github.com/ucok-man/streamify/internal/geo"
+	"github.com/ucok-man/streamify/internal/languages"
 	"github.com/ucok-man/streamify/internal/logger"
 	"github.com/ucok-man/streamify/internal/models"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -49,26 +51,38 @@ var SeedCmd = &cobra.Command{
 		logger.Info().Msg("Begin seeding users...")
 		mc := &models.User{}
 
-		streamclient, err := stream.NewClient(cfg.GetStreamIO.ApiKey, cfg.GetStreamIO.ApiSecret)
+		chatProvider, err := chat.NewProvider(cfg.Chat.Provider, chat.Options{
+			GetStreamAPIKey:    cfg.GetStreamIO.ApiKey,
+			GetStreamAPISecret: cfg.GetStreamIO.ApiSecret,
+			TokenSecret:        cfg.JWT.AuthSecret,
+			Conversations: models.NewConversationModel(
+				db.Collection("conversations"),
+				models.NewCursorCodec(cfg.JWT.AuthSecret),
+				logger.With().Str("context", "conversation_model_service").Logger(),
+			),
+		})
 		if err != nil {
-			logger.Fatal().Err(err).Msg("Failed initialize stream chat client")
+			logger.Fatal().Err(err).Msg("Failed initialize chat provider")
 		}
 
 		for i := 0; i < 100; i++ {
 			rndname := ng.Generate()
 			name := strings.Join(strings.Split(rndname, "-"), " ")
+			username := strings.ToLower(strings.ReplaceAll(rndname, "-", "_"))
 			user := &models.User{
 				FullName:    name,
+				Username:    username,
+				UsernameKey: models.UsernameKey(username),
 				Email:       fmt.Sprintf("%s@dummy.com", strings.ToLower(rndname)),
 				Bio:         fmt.Sprintf("Hello, I'am %v", name),
 				ProfilePic:  getRandomPicturePlaceholder(),
-				NativeLng:   getRandomLng(),
-				LearningLng: getRandomLng(),
-				Location:    "Some City, Country",
+				Languages:   getRandomLanguages(),
+				Location:    getRandomLocation(),
 				IsOnboarded: true,
 				CreatedAt:   time.Now(),
 				UpdatedAt:   time.Now(),
 				FriendIDs:   []bson.ObjectID{},
+				Privacy:     models.DefaultPrivacySettings,
 			}
 
 			result, err := userColl.InsertOne(context.Background(), user)
@@ -96,14 +110,14 @@ var SeedCmd = &cobra.Command{
 				logger.Fatal().Err(fmt.Errorf("inserted ID is not ObjectID")).Msg("Error insert user result")
 			}
 
-			// Create user in getstream.io
-			_, err = streamclient.UpsertUser(context.Background(), &stream.User{
+			// Create user in the chat service
+			err = chatProvider.UpsertUser(context.Background(), chat.User{
 				ID:    userID.Hex(),
 				Name:  user.FullName,
 				Image: user.ProfilePic,
 			})
 			if err != nil {
-				logger.Fatal().Err(err).Msg("Error creating stream user")
+				logger.Fatal().Err(err).Msg("Error creating chat user")
 			}
 
 			if i == 0 {
@@ -172,24 +186,27 @@ func getRandomPicturePlaceholder() string {
 	return url
 }
 
-func getRandomLng() string {
-	idx := rand.Intn(len(LANGUAGES))
-	return LANGUAGES[idx]
+func getRandomLocation() models.Location {
+	places := geo.NewOfflineGeocoder().Places()
+	place := places[rand.Intn(len(places))]
+	return models.NewLocation(&place)
 }
 
-var LANGUAGES = []string{
-	"English",
-	"Spanish",
-	"French",
-	"German",
-	"Mandarin",
-	"Japanese",
-	"Korean",
-	"Hindi",
-	"Russian",
-	"Portuguese",
-	"Arabic",
-	"Italian",
-	"Turkish",
-	"Dutch",
+func getRandomLanguages() []models.UserLanguage {
+	catalogue := languages.All()
+	idx := rand.Perm(len(catalogue))
+	learningLevels := models.LanguageLevels[:len(models.LanguageLevels)-1] // without native
+
+	userLanguages := []models.UserLanguage{{
+		Code:  catalogue[idx[0]].Code,
+		Level: models.LanguageLevelNative,
+	}}
+	for _, i := range idx[1 : 2+rand.Intn(2)] {
+		userLanguages = append(userLanguages, models.UserLanguage{
+			Code:     catalogue[i].Code,
+			Level:    learningLevels[rand.Intn(len(learningLevels))],
+			Learning: true,
+		})
+	}
+	return userLanguages
 }
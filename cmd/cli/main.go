@@ -5,18 +5,23 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/ucok-man/streamify/cmd/cli/chat"
 	"github.com/ucok-man/streamify/cmd/cli/db"
+	"github.com/ucok-man/streamify/cmd/cli/notify"
+	"github.com/ucok-man/streamify/cmd/cli/push"
+	"github.com/ucok-man/streamify/cmd/cli/referrals"
+	"github.com/ucok-man/streamify/cmd/cli/users"
 )
 
 func init() {
-	rootCmd.AddCommand(db.DBCmd)
+	rootCmd.AddCommand(db.DBCmd, referrals.ReferralsCmd, notify.NotifyCmd, push.PushCmd, chat.ChatCmd, users.UsersCmd)
 }
 
 var rootCmd = &cobra.Command{
 	Version: "1.0.0",
 	Use:     "streamify-cli",
 	Short:   "streamify-cli - Tools for manage streamify api",
-	Example: "- streamify-cli db seed\n- streamify-cli db restart",
+	Example: "- streamify-cli db seed\n- streamify-cli db restart\n- streamify-cli db migrate languages\n- streamify-cli db migrate privacy\n- streamify-cli referrals report --from 2025-01-01 --to 2025-01-31\n- streamify-cli notify digest --dry-run\n- streamify-cli push vapid\n- streamify-cli chat backfill --dry-run\n- streamify-cli users suspend --email jane@example.com",
 }
 
 func main() {
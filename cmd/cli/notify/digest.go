@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ucok-man/streamify/internal/config"
+	"github.com/ucok-man/streamify/internal/email"
+	"github.com/ucok-man/streamify/internal/logger"
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+var digestFlags struct {
+	dryRun  bool
+	html    bool
+	user    string
+	cadence string
+}
+
+func init() {
+	digestCmd.Flags().BoolVar(&digestFlags.dryRun, "dry-run", false, "print the digests instead of queuing them")
+	digestCmd.Flags().BoolVar(&digestFlags.html, "html", false, "print the HTML version in dry run, rather than the text one")
+	digestCmd.Flags().StringVar(&digestFlags.user, "user", "", "email of the only user to send the digest to, whether it is due or not")
+	digestCmd.Flags().StringVar(&digestFlags.cadence, "cadence", "", "daily or weekly, overrides the cadence chosen by --user")
+}
+
+var digestCmd = &cobra.Command{
+	Use:     "digest",
+	Short:   "Send the digest emails due now, the API sends them every hour too",
+	Example: "- streamify-cli notify digest --dry-run\n- streamify-cli notify digest --user jane@example.com --cadence weekly --dry-run --html\n- streamify-cli notify digest",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if digestFlags.cadence != "" && digestFlags.user == "" {
+			return fmt.Errorf("--cadence requires --user")
+		}
+		if digestFlags.cadence != "" && !slices.Contains([]string{models.DigestDaily, models.DigestWeekly}, digestFlags.cadence) {
+			return fmt.Errorf("--cadence must be daily or weekly")
+		}
+		if digestFlags.html && !digestFlags.dryRun {
+			return fmt.Errorf("--html requires --dry-run")
+		}
+
+		cfg := config.New()
+		logger, err := logger.New(cfg.Log.Level, cfg.Env)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize logger")
+		}
+
+		conn, err := cfg.OpenDB()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize db connection")
+		}
+		defer conn.Disconnect(context.Background())
+
+		db := conn.Database(cfg.DB.DatabaseName)
+		searchBackend, err := models.NewSearchBackend(
+			cfg.DB.SearchBackend,
+			db.Collection("users"),
+			logger.With().Str("context", "search_backend").Logger(),
+		)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize search backend")
+		}
+		appmodels := models.NewModels(db, searchBackend, models.NewCursorCodec(cfg.JWT.AuthSecret), cfg.Notify.Retention, logger)
+
+		renderer, err := email.NewRenderer()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed parsing email templates")
+		}
+		// The queue is only filled here, the worker of the API delivers it
+		queue := email.NewQueue(appmodels.EmailJob, nil, email.DefaultQueueOptions, logger.With().Str("context", "email_queue").Logger())
+
+		mailer := email.NewMailer(renderer, queue, email.NewUnsubscribeTokens(cfg.JWT.AuthSecret), cfg.App.URL)
+		digester := email.NewDigester(
+			mailer,
+			appmodels.User,
+			appmodels.FriendRequest,
+			appmodels.Notification,
+			cfg.Mail.DigestHour,
+			logger.With().Str("context", "email_digester").Logger(),
+		)
+
+		now := time.Now()
+		count := 0
+		process := func(user *models.User, cadence models.DigestCadence) error {
+			if !digestFlags.dryRun {
+				queued, err := digester.Send(user, cadence, now)
+				if queued {
+					count++
+				}
+				return err
+			}
+
+			data, err := digester.Build(user, cadence, now)
+			if err != nil {
+				return err
+			}
+			if data.Empty() {
+				fmt.Printf("# %s: nothing to tell, skipped\n\n", user.Email)
+				return nil
+			}
+			msg, err := digester.Render(user, data)
+			if err != nil {
+				return err
+			}
+
+			body := msg.Text
+			if digestFlags.html {
+				body = msg.HTML
+			}
+			fmt.Printf("To: %s\nSubject: %s\n\n%s\n\n", msg.To, msg.Subject, body)
+			count++
+			return nil
+		}
+
+		if digestFlags.user != "" {
+			user, err := appmodels.User.GetByEmail(digestFlags.user)
+			if err != nil {
+				logger.Fatal().Err(err).Str("email", digestFlags.user).Msg("Error finding user")
+			}
+
+			cadence := user.EmailPreferences.Cadence()
+			if digestFlags.cadence != "" {
+				cadence = digestFlags.cadence
+			}
+			if cadence == models.DigestOff {
+				return fmt.Errorf("%s has the digest off, choose one with --cadence", user.Email)
+			}
+			if err := process(user, cadence); err != nil {
+				logger.Fatal().Err(err).Msg("Error sending digest")
+			}
+		} else {
+			err := digester.EachDue(cmd.Context(), now, func(user *models.User) error {
+				return process(user, user.EmailPreferences.Cadence())
+			})
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error sending digests")
+			}
+		}
+
+		if digestFlags.dryRun {
+			logger.Info().Msgf("Dry run, %d digests rendered, none queued", count)
+		} else {
+			logger.Info().Msgf("Success queuing %d digests", count)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,14 @@
+package notify
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	NotifyCmd.AddCommand(digestCmd)
+}
+
+var NotifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Send notification emails",
+}
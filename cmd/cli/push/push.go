@@ -0,0 +1,14 @@
+package push
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	PushCmd.AddCommand(vapidCmd)
+}
+
+var PushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Manage web push notifications",
+}
@@ -0,0 +1,27 @@
+package push
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ucok-man/streamify/internal/webpush"
+)
+
+var vapidCmd = &cobra.Command{
+	Use:   "vapid",
+	Short: "Generate the VAPID key pair identifying the server to the push services",
+	Long: "Generate the VAPID key pair identifying the server to the push services.\n\n" +
+		"Add the output to the .env of the API. Changing the keys invalidates every\n" +
+		"browser subscription, the users have to subscribe again.",
+	Example: "- streamify-cli push vapid >> .env",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		publicKey, privateKey, err := webpush.GenerateVAPIDKeys()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("API_WEBPUSH_VAPID_PUBLIC_KEY=%s\n", publicKey)
+		fmt.Printf("API_WEBPUSH_VAPID_PRIVATE_KEY=%s\n", privateKey)
+		return nil
+	},
+}
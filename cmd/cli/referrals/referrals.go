@@ -0,0 +1,14 @@
+package referrals
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	ReferralsCmd.AddCommand(reportCmd)
+}
+
+var ReferralsCmd = &cobra.Command{
+	Use:   "referrals",
+	Short: "Inspect referral attribution",
+}
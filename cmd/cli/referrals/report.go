@@ -0,0 +1,112 @@
+package referrals
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ucok-man/streamify/internal/config"
+	"github.com/ucok-man/streamify/internal/logger"
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+var reportFlags struct {
+	from  string
+	to    string
+	limit int64
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&reportFlags.from, "from", "", "first signup day included, YYYY-MM-DD (default 30 days ago)")
+	reportCmd.Flags().StringVar(&reportFlags.to, "to", "", "last signup day included, YYYY-MM-DD (default today)")
+	reportCmd.Flags().Int64Var(&reportFlags.limit, "limit", 10, "number of referrers listed")
+}
+
+var reportCmd = &cobra.Command{
+	Use:     "report",
+	Short:   "List the top referrers by signups over a date range, in UTC",
+	Example: "- streamify-cli referrals report\n- streamify-cli referrals report --from 2025-01-01 --to 2025-01-31 --limit 20",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+
+		from := today.AddDate(0, 0, -30)
+		if reportFlags.from != "" {
+			day, err := time.Parse(dateLayout, reportFlags.from)
+			if err != nil {
+				return fmt.Errorf("invalid --from value, expected YYYY-MM-DD")
+			}
+			from = day
+		}
+		to := today
+		if reportFlags.to != "" {
+			day, err := time.Parse(dateLayout, reportFlags.to)
+			if err != nil {
+				return fmt.Errorf("invalid --to value, expected YYYY-MM-DD")
+			}
+			to = day
+		}
+		if to.Before(from) {
+			return fmt.Errorf("--to must not be before --from")
+		}
+		if reportFlags.limit < 1 || reportFlags.limit > 1000 {
+			return fmt.Errorf("--limit must be between 1 and 1000")
+		}
+
+		cfg := config.New()
+		logger, err := logger.New(cfg.Log.Level, cfg.Env)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize logger")
+		}
+
+		conn, err := cfg.OpenDB()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed initialize db connection")
+		}
+		defer conn.Disconnect(context.Background())
+
+		referralModel := models.NewReferralModel(
+			conn.Database(cfg.DB.DatabaseName).Collection("referrals"),
+			logger.With().Str("context", "referral_model_service").Logger(),
+		)
+
+		// The range is inclusive of the whole --to day
+		referrers, err := referralModel.TopReferrers(from, to.AddDate(0, 0, 1), reportFlags.limit)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Error computing top referrers")
+		}
+
+		fmt.Printf("Top referrers from %s to %s\n\n", from.Format(dateLayout), to.Format(dateLayout))
+		if len(referrers) == 0 {
+			fmt.Println("No referred signup in this range.")
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "RANK\tUSER ID\tNAME\tUSERNAME\tEMAIL\tSIGNUPS\tONBOARDED")
+		for i, referrer := range referrers {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%d\t%d\n",
+				i+1,
+				referrer.ReferrerID.Hex(),
+				valueOrDash(referrer.FullName),
+				valueOrDash(referrer.Username),
+				valueOrDash(referrer.Email),
+				referrer.Total,
+				referrer.Onboarded,
+			)
+		}
+		return tw.Flush()
+	},
+}
+
+// valueOrDash keeps the columns aligned for deleted referrers.
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ucok-man/streamify/internal/chat"
+	"github.com/ucok-man/streamify/internal/config"
+	"github.com/ucok-man/streamify/internal/logger"
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+var suspendFlags struct {
+	email string
+}
+
+func init() {
+	suspendCmd.Flags().StringVar(&suspendFlags.email, "email", "", "email of the user to suspend")
+}
+
+var suspendCmd = &cobra.Command{
+	Use:     "suspend",
+	Short:   "Suspend a user: they can't sign in nor use their session, their chat tokens are revoked",
+	Example: "- streamify-cli users suspend --email jane@example.com",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if suspendFlags.email == "" {
+			return fmt.Errorf("--email is required")
+		}
+		return setSuspended(suspendFlags.email, true)
+	},
+}
+
+// setSuspended suspends the user of email or lifts their suspension.
+func setSuspended(email string, suspended bool) error {
+	cfg := config.New()
+	logger, err := logger.New(cfg.Log.Level, cfg.Env)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed initialize logger")
+	}
+
+	conn, err := cfg.OpenDB()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed initialize db connection")
+	}
+	defer conn.Disconnect(context.Background())
+
+	db := conn.Database(cfg.DB.DatabaseName)
+	searchBackend, err := models.NewSearchBackend(
+		cfg.DB.SearchBackend,
+		db.Collection("users"),
+		logger.With().Str("context", "search_backend").Logger(),
+	)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed initialize search backend")
+	}
+	cursors := models.NewCursorCodec(cfg.JWT.AuthSecret)
+	userModel := models.NewUserModel(
+		db.Collection("users"),
+		searchBackend,
+		cursors,
+		logger.With().Str("context", "user_model_service").Logger(),
+	)
+
+	user, err := userModel.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			return fmt.Errorf("no user with email %s", email)
+		}
+		logger.Fatal().Err(err).Msg("Error reading user")
+	}
+
+	user, err = userModel.SetSuspended(user, suspended)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error updating user")
+	}
+	if !suspended {
+		fmt.Printf("User %s (%s) is not suspended anymore.\n", user.Email, user.ID.Hex())
+		return nil
+	}
+
+	chatProvider, err := chat.NewProvider(cfg.Chat.Provider, chat.Options{
+		GetStreamAPIKey:    cfg.GetStreamIO.ApiKey,
+		GetStreamAPISecret: cfg.GetStreamIO.ApiSecret,
+		TokenSecret:        cfg.JWT.AuthSecret,
+		Conversations: models.NewConversationModel(
+			db.Collection("conversations"),
+			cursors,
+			logger.With().Str("context", "conversation_model_service").Logger(),
+		),
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed initialize chat provider")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := chatProvider.RevokeTokens(ctx, user.ID.Hex(), *user.SuspendedAt); err != nil {
+		return fmt.Errorf("user suspended but revoking their chat tokens failed, run the command again: %w", err)
+	}
+
+	fmt.Printf("User %s (%s) suspended, chat tokens revoked.\n", user.Email, user.ID.Hex())
+	return nil
+}
@@ -0,0 +1,27 @@
+package users
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var unsuspendFlags struct {
+	email string
+}
+
+func init() {
+	unsuspendCmd.Flags().StringVar(&unsuspendFlags.email, "email", "", "email of the user to unsuspend")
+}
+
+var unsuspendCmd = &cobra.Command{
+	Use:     "unsuspend",
+	Short:   "Lift the suspension of a user",
+	Example: "- streamify-cli users unsuspend --email jane@example.com",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if unsuspendFlags.email == "" {
+			return fmt.Errorf("--email is required")
+		}
+		return setSuspended(unsuspendFlags.email, false)
+	},
+}
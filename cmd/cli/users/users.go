@@ -0,0 +1,14 @@
+package users
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	UsersCmd.AddCommand(suspendCmd, unsuspendCmd)
+}
+
+var UsersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manage user accounts",
+}
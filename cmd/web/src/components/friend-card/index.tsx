@@ -1,8 +1,8 @@
 import { Link } from "@tanstack/react-router";
 import { MapPinIcon } from "lucide-react";
-import { capitialize } from "../../lib/utils";
+import { formatLocation } from "../../lib/utils";
 import type { UserResponse } from "../../types/user-response.type";
-import LanguageFlag from "../language-flag";
+import LanguageBadges from "../language-badges";
 
 type Props = {
   friend: UserResponse;
@@ -54,22 +54,13 @@ export default function FriendCard({ friend }: Props) {
             {friend.location && (
               <div className="mt-1 flex items-center text-xs opacity-70">
                 <MapPinIcon className="mr-1 size-3" />
-                {friend.location}
+                {formatLocation(friend.location)}
               </div>
             )}
           </div>
         </div>
         {/* Languages with flags */}
-        <div className="flex flex-wrap gap-1.5 space-y-1">
-          <span className="badge badge-secondary">
-            <LanguageFlag language={friend.native_lng} />
-            Native: {capitialize(friend.native_lng)}
-          </span>
-          <span className="badge-outline badge">
-            <LanguageFlag language={friend.learning_lng} />
-            Learning: {capitialize(friend.learning_lng)}
-          </span>
-        </div>
+        <LanguageBadges languages={friend.languages} />
         <p className="line-clamp-2 text-sm opacity-70">{friend.bio}</p>
         {/* Action button */}
         <Link
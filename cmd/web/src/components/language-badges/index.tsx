@@ -0,0 +1,48 @@
+import { cn } from "../../lib/utils";
+import type { UserLanguageResponse } from "../../types/user-language-response.type";
+import LanguageFlag from "../language-flag";
+
+type Props = {
+  languages: UserLanguageResponse[];
+  small?: boolean;
+};
+
+// Native and learning languages of a user, the other ones they speak are
+// left out.
+export default function LanguageBadges({ languages, small }: Props) {
+  return (
+    <div
+      className={cn(
+        "flex flex-wrap gap-1.5",
+        small ? "mt-1" : "space-y-1"
+      )}
+    >
+      {languages.map((lang) => {
+        const name = lang.name || lang.code;
+        if (lang.learning) {
+          return (
+            <span
+              key={lang.code}
+              className={cn("badge-outline badge", small && "badge-sm")}
+            >
+              {!small && <LanguageFlag language={name} />}
+              Learning: {name} ({lang.level})
+            </span>
+          );
+        }
+        if (lang.level === "native") {
+          return (
+            <span
+              key={lang.code}
+              className={cn("badge badge-secondary", small && "badge-sm")}
+            >
+              {!small && <LanguageFlag language={name} />}
+              Native: {name}
+            </span>
+          );
+        }
+        return null;
+      })}
+    </div>
+  );
+}
@@ -3,17 +3,16 @@ import { CheckCircleIcon, MapPinIcon, UserPlusIcon } from "lucide-react";
 import toast from "react-hot-toast";
 import { apiclient } from "../../lib/apiclient";
 import { refetchQuery } from "../../lib/query-client";
-import { capitialize, cn } from "../../lib/utils";
+import { cn, formatLocation } from "../../lib/utils";
 import type { UserWithFriendRequestResponse } from "../../types/user-with-friend-request-response.type";
-import LanguageFlag from "../language-flag";
+import LanguageBadges from "../language-badges";
 
 type Props = {
   user: UserWithFriendRequestResponse;
 };
 
 export default function RecommendCard({ user }: Props) {
-  const isHasSent = user.sent_friend_request.length > 0;
-  const isHasFrom = user.from_friend_request.length > 0;
+  const hasRequest = user.friend_request !== null;
 
   const sentRequest = useMutation({
     mutationFn: async (receipentId: string) => {
@@ -45,23 +44,14 @@ export default function RecommendCard({ user }: Props) {
             {user.location && (
               <div className="mt-1 flex items-center text-xs opacity-70">
                 <MapPinIcon className="mr-1 size-3" />
-                {user.location}
+                {formatLocation(user.location)}
               </div>
             )}
           </div>
         </div>
 
         {/* Languages with flags */}
-        <div className="flex flex-wrap gap-1.5 space-y-1">
-          <span className="badge badge-secondary">
-            <LanguageFlag language={user.native_lng} />
-            Native: {capitialize(user.native_lng)}
-          </span>
-          <span className="badge-outline badge">
-            <LanguageFlag language={user.learning_lng} />
-            Learning: {capitialize(user.learning_lng)}
-          </span>
-        </div>
+        <LanguageBadges languages={user.languages} />
 
         <p className="line-clamp-2 text-sm opacity-70">{user.bio}</p>
 
@@ -69,12 +59,12 @@ export default function RecommendCard({ user }: Props) {
         <button
           className={cn(
             `btn mt-2 w-full`,
-            isHasSent || isHasFrom ? "btn-disabled" : "btn-primary"
+            hasRequest ? "btn-disabled" : "btn-primary"
           )}
           onClick={() => sentRequest.mutate(user.id)}
-          disabled={isHasSent || isHasFrom || sentRequest.isPending}
+          disabled={hasRequest || sentRequest.isPending}
         >
-          {isHasSent || isHasFrom ? (
+          {hasRequest ? (
             <>
               <CheckCircleIcon className="mr-2 size-4" />
               See Notification
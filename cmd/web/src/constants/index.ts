@@ -179,12 +179,16 @@ export const LANGUAGES = [
   "Dutch",
 ];
 
+// CEFR levels of a learning language, native ones are "native"
+export const LANGUAGE_LEVELS = ["A1", "A2", "B1", "B2", "C1", "C2"] as const;
+
 export const LANGUAGE_TO_FLAG = {
   english: "gb",
   spanish: "es",
   french: "fr",
   german: "de",
   mandarin: "cn",
+  chinese: "cn",
   japanese: "jp",
   korean: "kr",
   hindi: "in",
@@ -1,6 +1,7 @@
 import { clsx, type ClassValue } from "clsx";
 import type { useForm } from "react-hook-form";
 import { twMerge } from "tailwind-merge";
+import type { LocationResponse } from "../types/location-response.type";
 
 export function cn(...inputs: ClassValue[]) {
   return twMerge(clsx(inputs));
@@ -32,3 +33,6 @@ export function generateAvatar() {
 
 export const capitialize = (str: string) =>
   str.charAt(0).toUpperCase() + str.slice(1);
+
+export const formatLocation = (location: LocationResponse) =>
+  [location.city, location.country].filter(Boolean).join(", ");
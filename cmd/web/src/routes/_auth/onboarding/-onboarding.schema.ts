@@ -1,4 +1,5 @@
 import { z } from "zod";
+import { LANGUAGE_LEVELS } from "../../../constants";
 
 export const onboardingSchema = z.object({
   fullname: z
@@ -20,6 +21,10 @@ export const onboardingSchema = z.object({
     message: "Learning language must be selected",
   }),
 
+  learning_level: z.enum(LANGUAGE_LEVELS, {
+    message: "Learning level must be selected",
+  }),
+
   location: z
     .string()
     .trim()
@@ -32,3 +37,20 @@ export const onboardingSchema = z.object({
 });
 
 export type OnboardingData = z.infer<typeof onboardingSchema>;
+
+// toOnboardingPayload turns the form into the request body, the API takes a
+// list of languages with their level.
+export function toOnboardingPayload({
+  native_lng,
+  learning_lng,
+  learning_level,
+  ...profile
+}: OnboardingData) {
+  return {
+    ...profile,
+    languages: [
+      { code: native_lng, level: "native", learning: false },
+      { code: learning_lng, level: learning_level, learning: true },
+    ],
+  };
+}
@@ -7,12 +7,16 @@ import { useTransition } from "react";
 import { useForm } from "react-hook-form";
 import toast from "react-hot-toast";
 import SpinnerBtn from "../../../components/spinner-btn";
-import { LANGUAGES } from "../../../constants";
+import { LANGUAGE_LEVELS, LANGUAGES } from "../../../constants";
 import { apiclient } from "../../../lib/apiclient";
 import { refetchQuery } from "../../../lib/query-client";
 import { generateAvatar, parseApiError } from "../../../lib/utils";
 import type { UserResponse } from "../../../types/user-response.type";
-import { onboardingSchema, type OnboardingData } from "./-onboarding.schema";
+import {
+  onboardingSchema,
+  toOnboardingPayload,
+  type OnboardingData,
+} from "./-onboarding.schema";
 
 export const Route = createFileRoute("/_auth/onboarding")({
   beforeLoad: ({ context }) => {
@@ -45,6 +49,7 @@ function OnboardingPage() {
       fullname: session.data?.full_name || "",
       bio: "",
       learning_lng: "",
+      learning_level: "A1",
       native_lng: "",
       location: "",
       profile_pic: session.data?.profile_pic || "",
@@ -55,7 +60,7 @@ function OnboardingPage() {
     mutationFn: async (payload: OnboardingData) => {
       const { data } = await apiclient.post<{ user: UserResponse }>(
         "/auth/onboarding",
-        payload
+        toOnboardingPayload(payload)
       );
       return data;
     },
@@ -76,6 +81,19 @@ function OnboardingPage() {
     onError: (err: AxiosError) => {
       if (err.status === 422) {
         parseApiError(err.response?.data, form);
+        // The form has no languages field, show their errors on the learning one
+        const errors = (
+          err.response?.data as { error?: Record<string, string[]> }
+        )?.error;
+        const languagesKey = Object.keys(errors ?? {}).find((key) =>
+          key.startsWith("languages")
+        );
+        if (errors && languagesKey) {
+          form.setError("learning_lng", {
+            message: errors[languagesKey][0],
+            type: "onChange",
+          });
+        }
         return;
       }
       toast.error(
@@ -219,6 +237,27 @@ function OnboardingPage() {
                   {formerror.learning_lng?.message}
                 </div>
               </div>
+
+              {/* LEARNING LEVEL */}
+              <div className="form-control w-full space-y-2 md:col-start-2">
+                <label htmlFor="learning_level" className="label">
+                  <span className="label-text px-1">Learning Level</span>
+                </label>
+                <select
+                  id="learning_level"
+                  className="select-bordered select w-full"
+                  {...form.register("learning_level")}
+                >
+                  {LANGUAGE_LEVELS.map((level) => (
+                    <option key={`level-${level}`} value={level}>
+                      {level}
+                    </option>
+                  ))}
+                </select>
+                <div className="px-1 text-xs text-red-500">
+                  {formerror.learning_level?.message}
+                </div>
+              </div>
             </div>
 
             {/* LOCATION */}
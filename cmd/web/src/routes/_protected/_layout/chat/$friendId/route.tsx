@@ -47,19 +47,41 @@ export const Route = createFileRoute("/_protected/_layout/chat/$friendId")({
     const { data: user } = context.session;
     if (!user) throw notFound();
 
+    // The token expires, the client asks a new one when it does
+    let initialToken: string | null = token;
+    const tokenProvider = async () => {
+      if (initialToken) {
+        const current = initialToken;
+        initialToken = null;
+        return current;
+      }
+      return apiclient
+        .post<{ token: string }>(`/chat/token/refresh`)
+        .then((res) => res.data.token);
+    };
+
     await streamclient.connectUser(
       {
         id: user!.id,
         name: user!.full_name,
         image: user!.profile_pic,
       },
-      token
+      tokenProvider
     );
 
-    const chanId = [user.id, friend.id].sort().join("-");
-    const channel = streamclient.channel("messaging", chanId, {
-      members: [user.id, friend.id],
-    });
+    // The server creates the channel when the friendship is made
+    const chanId = await apiclient
+      .get<{ channel_id: string }>(`/chat/channels/${friend.id}`)
+      .then((res) => res.data.channel_id)
+      .catch((error) => {
+        if (error instanceof AxiosError && error.response?.status === 404)
+          return null;
+        throw error;
+      });
+
+    if (!chanId) throw notFound();
+
+    const channel = streamclient.channel("messaging", chanId);
 
     return { token, channel, streamclient };
   },
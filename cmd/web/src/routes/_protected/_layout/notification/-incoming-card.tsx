@@ -1,5 +1,6 @@
 import { useMutation } from "@tanstack/react-query";
 import toast from "react-hot-toast";
+import LanguageBadges from "../../../../components/language-badges";
 import { apiclient } from "../../../../lib/apiclient";
 import { refetchQuery } from "../../../../lib/query-client";
 import type { FriendRequestWithSenderResponse } from "../../../../types/friend-request-with-sender-response.type";
@@ -33,14 +34,7 @@ export default function IncomingCard({ item }: Props) {
             </div>
             <div>
               <h3 className="font-semibold">{item.sender.full_name}</h3>
-              <div className="mt-1 flex flex-wrap gap-1.5">
-                <span className="badge badge-sm badge-secondary">
-                  Native: {item.sender.native_lng}
-                </span>
-                <span className="badge-outline badge badge-sm">
-                  Learning: {item.sender.learning_lng}
-                </span>
-              </div>
+              <LanguageBadges languages={item.sender.languages} small />
             </div>
           </div>
 
@@ -1,3 +1,4 @@
+import LanguageBadges from "../../../../components/language-badges";
 import type { FriendRequestWithRecipientResponse } from "../../../../types/friend-request-with-recipient-response.type";
 
 type Props = {
@@ -18,14 +19,7 @@ export default function OutgoingCard({ item }: Props) {
             </div>
             <div>
               <h3 className="font-semibold">{item.recipient.full_name}</h3>
-              <div className="mt-1 flex flex-wrap gap-1.5">
-                <span className="badge badge-sm badge-secondary">
-                  Native: {item.recipient.native_lng}
-                </span>
-                <span className="badge-outline badge badge-sm">
-                  Learning: {item.recipient.learning_lng}
-                </span>
-              </div>
+              <LanguageBadges languages={item.recipient.languages} small />
             </div>
           </div>
 
@@ -33,6 +33,11 @@ export const Route = createFileRoute("/_protected/call/$channelId")({
         image: user.profile_pic,
       },
       token: token,
+      // The token expires, the client asks a new one when it does
+      tokenProvider: () =>
+        apiclient
+          .post<{ token: string }>(`/chat/token/refresh`)
+          .then((res) => res.data.token),
     });
 
     const callInstance = streamclient.call("default", params.channelId);
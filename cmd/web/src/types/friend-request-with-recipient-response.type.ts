@@ -1,3 +1,5 @@
+import type { PublicUserResponse } from "./public-user-response.type";
+
 export type FriendRequestWithRecipientResponse = {
   id: string;
   sender_id: string;
@@ -5,18 +7,5 @@ export type FriendRequestWithRecipientResponse = {
   status: "Pending" | "Accepted";
   created_at: string; // ISO date string (time.Time in Go)
   updated_at: string;
-  recipient: {
-    id: string;
-    full_name: string;
-    email: string;
-    bio: string;
-    profile_pic: string;
-    native_lng: string;
-    learning_lng: string;
-    location: string;
-    is_onboarded: boolean;
-    friend_ids: string[];
-    created_at: string;
-    updated_at: string;
-  };
+  recipient: PublicUserResponse;
 };
@@ -0,0 +1,8 @@
+export type LocationResponse = {
+  city: string;
+  country_code: string;
+  country: string;
+  timezone: string;
+  latitude?: number;
+  longitude?: number;
+};
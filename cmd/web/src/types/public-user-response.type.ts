@@ -0,0 +1,17 @@
+import type { LocationResponse } from "./location-response.type";
+import type { UserLanguageResponse } from "./user-language-response.type";
+
+// Email, location and friend_ids are left out when the privacy settings of
+// the user hide them from the viewer.
+export type PublicUserResponse = {
+  id: string;
+  full_name: string;
+  username?: string;
+  email?: string;
+  bio: string;
+  profile_pic: string;
+  languages: UserLanguageResponse[];
+  location?: LocationResponse;
+  friend_ids?: string[];
+  created_at: string;
+};
@@ -0,0 +1,8 @@
+export type LanguageLevel = "A1" | "A2" | "B1" | "B2" | "C1" | "C2" | "native";
+
+export type UserLanguageResponse = {
+  code: string;
+  name: string;
+  level: LanguageLevel;
+  learning: boolean;
+};
@@ -1,14 +1,19 @@
+import type { LocationResponse } from "./location-response.type";
+import type { UserLanguageResponse } from "./user-language-response.type";
+
 export type UserResponse = {
   id: string;
   full_name: string;
-  email: string;
+  username?: string;
+  email?: string;
   bio: string;
   profile_pic: string;
-  native_lng: string;
-  learning_lng: string;
-  location: string;
-  is_onboarded: true;
-  friend_ids: string[];
+  languages: UserLanguageResponse[];
+  location?: LocationResponse | null;
+  is_onboarded?: boolean;
+  friend_ids?: string[];
+  presence?: "online" | "away" | "offline";
+  last_active_at?: string;
   created_at: string;
-  updated_at: string;
+  updated_at?: string;
 };
@@ -1,18 +1,23 @@
-import type { FriendRequestResponse } from "./friend-request-response.type";
+import type { PublicUserResponse } from "./public-user-response.type";
 
-export type UserWithFriendRequestResponse = {
+export type FriendRequestStateResponse = {
+  id: string;
+  direction: "sent" | "received";
+  status: "Accepted" | "Pending";
+};
+
+export type MutualFriendResponse = {
   id: string;
   full_name: string;
-  email: string;
-  bio: string;
   profile_pic: string;
-  native_lng: string;
-  learning_lng: string;
-  location: string;
-  is_onboarded: true;
-  friend_ids: string[];
-  created_at: string;
-  updated_at: string;
-  sent_friend_request: FriendRequestResponse[];
-  from_friend_request: FriendRequestResponse[];
+};
+
+// The mutual friends are left out when the user hides their friend list.
+export type UserWithFriendRequestResponse = PublicUserResponse & {
+  friend_request: FriendRequestStateResponse | null;
+  has_friend_request: boolean;
+  mutual_friends_count?: number;
+  mutual_friends?: MutualFriendResponse[];
+  match_score: number;
+  match_reason: string;
 };
@@ -0,0 +1,86 @@
+// Package chat keeps the users and channels of the chat service in sync with
+// Streamify and issues the tokens its clients connect with.
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+// ChannelType is the type of the 1:1 channels between friends.
+const ChannelType = "messaging"
+
+var ErrChannelNotFound = errors.New("chat channel not found")
+
+// DirectChannelID returns the id of the 1:1 channel between two users, the
+// same whichever of them asks.
+func DirectChannelID(userID, otherID string) string {
+	ids := []string{userID, otherID}
+	slices.Sort(ids)
+	return strings.Join(ids, "-")
+}
+
+// User is the chat profile of a Streamify user.
+type User struct {
+	ID    string
+	Name  string
+	Image string
+}
+
+// Provider is a chat service. The operations are idempotent, upserting or
+// creating twice is not an error.
+type Provider interface {
+	Name() string
+	UpsertUser(ctx context.Context, user User) error
+	DeleteUser(ctx context.Context, userID string) error
+	// CreateToken returns the token userID connects with until expiresAt,
+	// the zero expiresAt never expires. issuedAt is the iat claim
+	// RevokeTokens compares to.
+	CreateToken(userID string, expiresAt, issuedAt time.Time) (string, error)
+	// RevokeTokens invalidates the tokens of userID issued before, so a
+	// leaked token stops working.
+	RevokeTokens(ctx context.Context, userID string, before time.Time) error
+	// CreateChannel creates channelID with members, a frozen channel is
+	// unfrozen so the members can write again.
+	CreateChannel(ctx context.Context, channelID, createdBy string, members []string) error
+	// FreezeChannel keeps the history of channelID readable but stops new
+	// messages, it returns ErrChannelNotFound when it doesn't exist.
+	FreezeChannel(ctx context.Context, channelID string) error
+}
+
+type Options struct {
+	GetStreamAPIKey    string
+	GetStreamAPISecret string
+	// Signs the tokens of the memory and native providers, through a key
+	// derived for the chat alone
+	TokenSecret string
+	// Stores the channels of the native provider
+	Conversations *models.ConversationModel
+}
+
+// NewProvider returns the Provider named by backend, getstream, native or
+// memory.
+func NewProvider(backend string, opts Options) (Provider, error) {
+	switch backend {
+	case "getstream":
+		if opts.GetStreamAPIKey == "" || opts.GetStreamAPISecret == "" {
+			return nil, fmt.Errorf("getstream chat provider requires an api key and secret")
+		}
+		return NewGetStreamProvider(opts.GetStreamAPIKey, opts.GetStreamAPISecret)
+	case "native":
+		if opts.Conversations == nil {
+			return nil, fmt.Errorf("native chat provider requires the conversation model")
+		}
+		return NewNativeProvider(opts.Conversations, opts.TokenSecret), nil
+	case "memory":
+		return NewMemoryProvider(opts.TokenSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown chat provider %q", backend)
+	}
+}
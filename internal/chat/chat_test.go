@@ -0,0 +1,236 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/signer"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const testSecret = "test-secret"
+
+// The native provider and the messenger run against a real MongoDB
+// deployment, in a throwaway database, when STREAMIFY_TEST_MONGO_URI is set.
+const testMongoURIEnv = "STREAMIFY_TEST_MONGO_URI"
+
+// testProvider is a Provider under test, with a look at the channels it
+// keeps.
+type testProvider struct {
+	Provider
+	// frozen reports whether channelID is frozen, and whether it exists.
+	frozen func(t *testing.T, channelID string) (frozen, found bool)
+}
+
+func newTestMemoryProvider(t *testing.T) testProvider {
+	p := NewMemoryProvider(testSecret)
+	return testProvider{
+		Provider: p,
+		frozen: func(t *testing.T, channelID string) (bool, bool) {
+			channel, found := p.Channel(channelID)
+			return channel.Frozen, found
+		},
+	}
+}
+
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv(testMongoURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", testMongoURIEnv)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	db := client.Database("streamify_test_" + bson.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db
+}
+
+func newTestNativeProvider(t *testing.T) testProvider {
+	t.Helper()
+
+	conversations := models.NewConversationModel(testDatabase(t).Collection("conversations"), models.NewCursorCodec("test"), zerolog.Nop())
+	return testProvider{
+		Provider: NewNativeProvider(conversations, testSecret),
+		frozen: func(t *testing.T, channelID string) (bool, bool) {
+			t.Helper()
+
+			conversation, err := conversations.GetByChannelID(channelID)
+			if errors.Is(err, models.ErrRecordNotFound) {
+				return false, false
+			}
+			if err != nil {
+				t.Fatalf("getting conversation: %v", err)
+			}
+			return conversation.Frozen, true
+		},
+	}
+}
+
+// TestProviderContract checks the providers keep the contract of Provider
+// the handlers rely on. GetStream needs an account and isn't tested here.
+func TestProviderContract(t *testing.T) {
+	providers := []struct {
+		name string
+		new  func(t *testing.T) testProvider
+	}{
+		{"memory", newTestMemoryProvider},
+		{"native", newTestNativeProvider},
+	}
+
+	for _, provider := range providers {
+		t.Run(provider.name, func(t *testing.T) {
+			p := provider.new(t)
+			if p.Name() != provider.name {
+				t.Errorf("got name %q, want %q", p.Name(), provider.name)
+			}
+
+			t.Run("users", func(t *testing.T) {
+				testProviderUsers(t, p)
+			})
+			t.Run("tokens", func(t *testing.T) {
+				testProviderTokens(t, p)
+			})
+			t.Run("channels", func(t *testing.T) {
+				testProviderChannels(t, p)
+			})
+		})
+	}
+}
+
+func testProviderUsers(t *testing.T, p testProvider) {
+	ctx := context.Background()
+	user := User{ID: bson.NewObjectID().Hex(), Name: "Olivia Owner", Image: "https://example.com/olivia.png"}
+
+	for i := range 2 {
+		if err := p.UpsertUser(ctx, user); err != nil {
+			t.Fatalf("upserting user, time %d: %v", i+1, err)
+		}
+	}
+	for i := range 2 {
+		if err := p.DeleteUser(ctx, user.ID); err != nil {
+			t.Fatalf("deleting user, time %d: %v", i+1, err)
+		}
+	}
+}
+
+func testProviderTokens(t *testing.T, p testProvider) {
+	userID := bson.NewObjectID().Hex()
+	issuedAt := time.Now().Truncate(time.Second)
+	expiresAt := issuedAt.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		issuedAt  time.Time
+		want      jwt.MapClaims
+	}{
+		{
+			name:      "expiring",
+			expiresAt: expiresAt,
+			issuedAt:  issuedAt,
+			want:      jwt.MapClaims{"user_id": userID, "aud": TokenAudience, "exp": float64(expiresAt.Unix()), "iat": float64(issuedAt.Unix())},
+		},
+		{
+			name: "never expiring",
+			want: jwt.MapClaims{"user_id": userID, "aud": TokenAudience},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := p.CreateToken(userID, tt.expiresAt, tt.issuedAt)
+			if err != nil {
+				t.Fatalf("creating token: %v", err)
+			}
+
+			claims := jwt.MapClaims{}
+			_, err = jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (any, error) {
+				return signer.DeriveKey(testSecret, TokenAudience), nil
+			}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(TokenAudience))
+			if err != nil {
+				t.Fatalf("parsing token: %v", err)
+			}
+			// The sessions are signed with the secret itself
+			_, err = jwt.Parse(signed, func(token *jwt.Token) (any, error) {
+				return []byte(testSecret), nil
+			})
+			if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+				t.Errorf("verifying with the secret: got %v, want %v", err, jwt.ErrTokenSignatureInvalid)
+			}
+			if len(claims) != len(tt.want) {
+				t.Errorf("got claims %v, want %v", claims, tt.want)
+			}
+			for key, want := range tt.want {
+				if claims[key] != want {
+					t.Errorf("claim %s: got %v, want %v", key, claims[key], want)
+				}
+			}
+		})
+	}
+
+	if err := p.RevokeTokens(context.Background(), userID, issuedAt); err != nil {
+		t.Errorf("revoking tokens: %v", err)
+	}
+}
+
+func testProviderChannels(t *testing.T, p testProvider) {
+	ctx := context.Background()
+	userID, friendID := bson.NewObjectID().Hex(), bson.NewObjectID().Hex()
+	channelID := DirectChannelID(userID, friendID)
+	members := []string{userID, friendID}
+
+	if got := DirectChannelID(friendID, userID); got != channelID {
+		t.Errorf("channel id of the friend: got %q, want %q", got, channelID)
+	}
+
+	if err := p.FreezeChannel(ctx, channelID); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("freezing a missing channel: got %v, want %v", err, ErrChannelNotFound)
+	}
+	if _, found := p.frozen(t, channelID); found {
+		t.Fatal("freezing a missing channel created it")
+	}
+
+	steps := []struct {
+		name       string
+		do         func() error
+		wantFrozen bool
+	}{
+		{"create", func() error { return p.CreateChannel(ctx, channelID, userID, members) }, false},
+		{"create again", func() error { return p.CreateChannel(ctx, channelID, friendID, members) }, false},
+		{"freeze", func() error { return p.FreezeChannel(ctx, channelID) }, true},
+		{"freeze again", func() error { return p.FreezeChannel(ctx, channelID) }, true},
+		{"create frozen", func() error { return p.CreateChannel(ctx, channelID, userID, members) }, false},
+	}
+
+	for _, step := range steps {
+		if err := step.do(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		frozen, found := p.frozen(t, channelID)
+		if !found {
+			t.Fatalf("%s: channel not found", step.name)
+		}
+		if frozen != step.wantFrozen {
+			t.Errorf("%s: got frozen %t, want %t", step.name, frozen, step.wantFrozen)
+		}
+	}
+}
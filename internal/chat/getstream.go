@@ -0,0 +1,78 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v5"
+)
+
+// GetStreamProvider is the getstream.io chat service.
+type GetStreamProvider struct {
+	client *stream.Client
+}
+
+func NewGetStreamProvider(apiKey, apiSecret string) (*GetStreamProvider, error) {
+	client, err := stream.NewClient(apiKey, apiSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &GetStreamProvider{client: client}, nil
+}
+
+func (p *GetStreamProvider) Name() string {
+	return "getstream"
+}
+
+func (p *GetStreamProvider) UpsertUser(ctx context.Context, user User) error {
+	_, err := p.client.UpsertUser(ctx, &stream.User{
+		ID:    user.ID,
+		Name:  user.Name,
+		Image: user.Image,
+	})
+	return err
+}
+
+func (p *GetStreamProvider) DeleteUser(ctx context.Context, userID string) error {
+	_, err := p.client.DeleteUser(ctx, userID)
+	return err
+}
+
+func (p *GetStreamProvider) CreateToken(userID string, expiresAt, issuedAt time.Time) (string, error) {
+	return p.client.CreateToken(userID, expiresAt, issuedAt)
+}
+
+func (p *GetStreamProvider) RevokeTokens(ctx context.Context, userID string, before time.Time) error {
+	_, err := p.client.RevokeUserToken(ctx, userID, &before)
+	return err
+}
+
+func (p *GetStreamProvider) CreateChannel(ctx context.Context, channelID, createdBy string, members []string) error {
+	res, err := p.client.CreateChannel(ctx, ChannelType, channelID, createdBy, &stream.ChannelRequest{
+		Members: members,
+	})
+	if err != nil {
+		return err
+	}
+	if !res.Channel.Frozen {
+		return nil
+	}
+	_, err = res.Channel.PartialUpdate(ctx, stream.PartialUpdate{
+		Set: map[string]interface{}{"frozen": false},
+	})
+	return err
+}
+
+func (p *GetStreamProvider) FreezeChannel(ctx context.Context, channelID string) error {
+	_, err := p.client.Channel(ChannelType, channelID).PartialUpdate(ctx, stream.PartialUpdate{
+		Set: map[string]interface{}{"frozen": true},
+	})
+
+	var apiErr stream.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return ErrChannelNotFound
+	}
+	return err
+}
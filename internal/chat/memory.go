@@ -0,0 +1,143 @@
+package chat
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ucok-man/streamify/internal/signer"
+)
+
+// TokenAudience is the audience of the tokens of the memory and native
+// providers, and the purpose their key is derived from the secret for. The
+// sessions of the API share the secret and reject them.
+const TokenAudience = "streamify-chat"
+
+// Channel is a channel of the MemoryProvider.
+type Channel struct {
+	ID        string
+	CreatedBy string
+	Members   []string
+	Frozen    bool
+}
+
+// MemoryProvider keeps the users and channels within the process, for tests
+// and offline development. Its tokens are signed like GetStream's, but no
+// chat client can connect with them.
+type MemoryProvider struct {
+	secret []byte
+
+	mu       sync.RWMutex
+	users    map[string]User
+	channels map[string]*Channel
+	revoked  map[string]time.Time
+}
+
+func NewMemoryProvider(secret string) *MemoryProvider {
+	return &MemoryProvider{
+		secret:   signer.DeriveKey(secret, TokenAudience),
+		users:    make(map[string]User),
+		channels: make(map[string]*Channel),
+		revoked:  make(map[string]time.Time),
+	}
+}
+
+func (p *MemoryProvider) Name() string {
+	return "memory"
+}
+
+func (p *MemoryProvider) UpsertUser(ctx context.Context, user User) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.users[user.ID] = user
+	return nil
+}
+
+func (p *MemoryProvider) DeleteUser(ctx context.Context, userID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.users, userID)
+	return nil
+}
+
+func (p *MemoryProvider) CreateToken(userID string, expiresAt, issuedAt time.Time) (string, error) {
+	return signToken(p.secret, userID, expiresAt, issuedAt)
+}
+
+func (p *MemoryProvider) RevokeTokens(ctx context.Context, userID string, before time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.revoked[userID] = before
+	return nil
+}
+
+func (p *MemoryProvider) CreateChannel(ctx context.Context, channelID, createdBy string, members []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if channel, ok := p.channels[channelID]; ok {
+		channel.Frozen = false
+		return nil
+	}
+	p.channels[channelID] = &Channel{
+		ID:        channelID,
+		CreatedBy: createdBy,
+		Members:   slices.Clone(members),
+	}
+	return nil
+}
+
+func (p *MemoryProvider) FreezeChannel(ctx context.Context, channelID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	channel, ok := p.channels[channelID]
+	if !ok {
+		return ErrChannelNotFound
+	}
+	channel.Frozen = true
+	return nil
+}
+
+// signToken returns a token shaped like GetStream's, a HS256 JWT of userID,
+// for TokenAudience.
+func signToken(key []byte, userID string, expiresAt, issuedAt time.Time) (string, error) {
+	claims := jwt.MapClaims{"user_id": userID, "aud": TokenAudience}
+	if !expiresAt.IsZero() {
+		claims["exp"] = expiresAt.Unix()
+	}
+	if !issuedAt.IsZero() {
+		claims["iat"] = issuedAt.Unix()
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+// User returns the user userID, and whether it exists.
+func (p *MemoryProvider) User(userID string) (User, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	user, ok := p.users[userID]
+	return user, ok
+}
+
+// RevokedBefore returns the time the tokens of userID issued before are
+// revoked, and whether they were.
+func (p *MemoryProvider) RevokedBefore(userID string) (time.Time, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	before, ok := p.revoked[userID]
+	return before, ok
+}
+
+// Channel returns a copy of the channel channelID, and whether it exists.
+func (p *MemoryProvider) Channel(channelID string) (Channel, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	channel, ok := p.channels[channelID]
+	if !ok {
+		return Channel{}, false
+	}
+	copied := *channel
+	copied.Members = slices.Clone(channel.Members)
+	return copied, true
+}
@@ -0,0 +1,264 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/realtime"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// publishTimeout bounds the delivery of the events of a change, which was
+// already saved.
+const publishTimeout = 5 * time.Second
+
+var (
+	ErrChannelFrozen   = errors.New("chat channel is frozen")
+	ErrNotFriends      = errors.New("chat members are not friends")
+	ErrMessageNotFound = errors.New("chat message not found")
+	ErrNotSender       = errors.New("chat message sent by another user")
+)
+
+// Messenger is the native chat: the conversations and messages of the
+// NativeProvider, delivered to the members through the realtime events. Only
+// friends can write to each other, the history stays readable.
+type Messenger struct {
+	conversations *models.ConversationModel
+	messages      *models.MessageModel
+	events        realtime.PubSub
+	logger        zerolog.Logger
+}
+
+func NewMessenger(conversations *models.ConversationModel, messages *models.MessageModel, events realtime.PubSub, logger zerolog.Logger) *Messenger {
+	return &Messenger{
+		conversations: conversations,
+		messages:      messages,
+		events:        events,
+		logger:        logger,
+	}
+}
+
+// Open returns the conversation of user with friendID, creating it the first
+// time. It returns ErrNotFriends when they aren't.
+func (m *Messenger) Open(user *models.User, friendID bson.ObjectID) (*models.Conversation, error) {
+	if !slices.Contains(user.FriendIDs, friendID) {
+		return nil, ErrNotFriends
+	}
+	channelID := DirectChannelID(user.ID.Hex(), friendID.Hex())
+	return m.conversations.Upsert(channelID, user.ID, []bson.ObjectID{user.ID, friendID})
+}
+
+// Conversation returns the conversation channelID of user, it returns
+// ErrChannelNotFound when user isn't a member.
+func (m *Messenger) Conversation(user *models.User, channelID string) (*models.Conversation, error) {
+	conversation, err := m.conversations.GetByChannelID(channelID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			return nil, ErrChannelNotFound
+		default:
+			return nil, err
+		}
+	}
+	if !conversation.HasMember(user.ID) {
+		return nil, ErrChannelNotFound
+	}
+	return conversation, nil
+}
+
+// History returns the messages of the conversation param.ChannelID of user,
+// newest first.
+func (m *Messenger) History(user *models.User, param models.MessageListParam) ([]*models.Message, models.Metadata, error) {
+	if _, err := m.Conversation(user, param.ChannelID); err != nil {
+		return []*models.Message{}, models.Metadata{}, err
+	}
+	return m.messages.GetAll(param)
+}
+
+// Send posts text from user to channelID.
+func (m *Messenger) Send(ctx context.Context, user *models.User, channelID, text string) (*models.Message, error) {
+	conversation, err := m.Conversation(user, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.writable(user, conversation); err != nil {
+		return nil, err
+	}
+
+	message, err := m.messages.Insert(&models.Message{
+		ChannelID: channelID,
+		SenderID:  user.ID,
+		Text:      text,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := m.conversations.Touch(channelID, message.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	m.publish(ctx, conversation, realtime.EventMessageCreated, map[string]any{
+		"channel_id": channelID,
+		"message":    message,
+	})
+	return message, nil
+}
+
+// Edit replaces the text of a message user sent.
+func (m *Messenger) Edit(ctx context.Context, user *models.User, channelID string, messageID bson.ObjectID, text string) (*models.Message, error) {
+	conversation, message, err := m.ownMessage(user, channelID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.writable(user, conversation); err != nil {
+		return nil, err
+	}
+
+	message, err = m.messages.UpdateText(message, text)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			return nil, ErrMessageNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	m.publish(ctx, conversation, realtime.EventMessageUpdated, map[string]any{
+		"channel_id": channelID,
+		"message":    message,
+	})
+	return message, nil
+}
+
+// Delete removes the text of a message user sent. Unlike writing it is
+// allowed in a frozen conversation, so anyone can take back what they said.
+func (m *Messenger) Delete(ctx context.Context, user *models.User, channelID string, messageID bson.ObjectID) (*models.Message, error) {
+	conversation, message, err := m.ownMessage(user, channelID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err = m.messages.Delete(message)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			return nil, ErrMessageNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	m.publish(ctx, conversation, realtime.EventMessageDeleted, map[string]any{
+		"channel_id": channelID,
+		"message":    message,
+	})
+	return message, nil
+}
+
+// MarkRead records that user read channelID up to messageID and returns the
+// read receipt of user, which doesn't move back to an earlier message.
+func (m *Messenger) MarkRead(ctx context.Context, user *models.User, channelID string, messageID bson.ObjectID) (*models.ConversationRead, error) {
+	conversation, err := m.Conversation(user, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.messages.GetByID(channelID, messageID); err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			return nil, ErrMessageNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	current := time.Now()
+	moved, err := m.conversations.MarkRead(channelID, user.ID, messageID, current)
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		conversation, err = m.conversations.GetByChannelID(channelID)
+		if err != nil {
+			return nil, err
+		}
+		for _, read := range conversation.Reads {
+			if read.UserID == user.ID {
+				return &read, nil
+			}
+		}
+		return nil, ErrChannelNotFound
+	}
+
+	read := &models.ConversationRead{UserID: user.ID, MessageID: &messageID, ReadAt: &current}
+	m.publish(ctx, conversation, realtime.EventMessageRead, map[string]any{
+		"channel_id": channelID,
+		"read":       read,
+	})
+	return read, nil
+}
+
+// ownMessage returns the conversation channelID of user and the message
+// messageID user sent to it.
+func (m *Messenger) ownMessage(user *models.User, channelID string, messageID bson.ObjectID) (*models.Conversation, *models.Message, error) {
+	conversation, err := m.Conversation(user, channelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	message, err := m.messages.GetByID(channelID, messageID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			return nil, nil, ErrMessageNotFound
+		default:
+			return nil, nil, err
+		}
+	}
+	if message.DeletedAt != nil {
+		return nil, nil, ErrMessageNotFound
+	}
+	if message.SenderID != user.ID {
+		return nil, nil, ErrNotSender
+	}
+	return conversation, message, nil
+}
+
+// writable checks user can write to conversation: it isn't frozen and the
+// other members are still friends of user.
+func (m *Messenger) writable(user *models.User, conversation *models.Conversation) error {
+	if conversation.Frozen {
+		return ErrChannelFrozen
+	}
+	for _, memberID := range conversation.MemberIDs {
+		if memberID != user.ID && !slices.Contains(user.FriendIDs, memberID) {
+			return ErrNotFriends
+		}
+	}
+	return nil
+}
+
+// publish sends an event to every member of conversation, the other
+// connections of the author included. The change is saved already, a failed
+// delivery is only logged: the clients catch up with the history.
+func (m *Messenger) publish(ctx context.Context, conversation *models.Conversation, eventType realtime.EventType, data any) {
+	events := make([]realtime.Event, 0, len(conversation.MemberIDs))
+	for _, memberID := range conversation.MemberIDs {
+		event, err := realtime.NewEvent(memberID, eventType, data)
+		if err != nil {
+			m.logger.Error().Err(err).Str("channel_id", conversation.ChannelID).Msg("Failed building chat event")
+			return
+		}
+		events = append(events, event)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+	defer cancel()
+
+	if err := m.events.Publish(ctx, events...); err != nil {
+		m.logger.Error().Err(err).Str("channel_id", conversation.ChannelID).Str("type", eventType).Msg("Failed publishing chat event")
+	}
+}
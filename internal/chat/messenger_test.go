@@ -0,0 +1,251 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/realtime"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// testMessenger is a Messenger between two friends, and a stranger.
+type testMessenger struct {
+	*Messenger
+	conversations          *models.ConversationModel
+	user, friend, stranger *models.User
+	channelID              string
+	// The events of user and friend
+	userEvents, friendEvents *realtime.Subscription
+}
+
+func newTestMessenger(t *testing.T) testMessenger {
+	t.Helper()
+
+	db := testDatabase(t)
+	conversations := models.NewConversationModel(db.Collection("conversations"), models.NewCursorCodec("test"), zerolog.Nop())
+	messages := models.NewMessageModel(db.Collection("messages"), models.NewCursorCodec("test"), zerolog.Nop())
+	pubsub := realtime.NewMemoryPubSub(realtime.DefaultOptions)
+	t.Cleanup(pubsub.Close)
+
+	userID, friendID := bson.NewObjectID(), bson.NewObjectID()
+	m := testMessenger{
+		Messenger:     NewMessenger(conversations, messages, pubsub, zerolog.Nop()),
+		conversations: conversations,
+		user:          &models.User{ID: userID, FriendIDs: []bson.ObjectID{friendID}},
+		friend:        &models.User{ID: friendID, FriendIDs: []bson.ObjectID{userID}},
+		stranger:      &models.User{ID: bson.NewObjectID()},
+		channelID:     DirectChannelID(userID.Hex(), friendID.Hex()),
+	}
+
+	var err error
+	if m.userEvents, err = pubsub.Subscribe(userID); err != nil {
+		t.Fatalf("subscribing user: %v", err)
+	}
+	if m.friendEvents, err = pubsub.Subscribe(friendID); err != nil {
+		t.Fatalf("subscribing friend: %v", err)
+	}
+	return m
+}
+
+// nextEvent returns the next event of sub, failing when none comes.
+func nextEvent(t *testing.T, sub *realtime.Subscription) realtime.Event {
+	t.Helper()
+
+	select {
+	case event := <-sub.C:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("got no event")
+		return realtime.Event{}
+	}
+}
+
+func TestMessengerConversation(t *testing.T) {
+	m := newTestMessenger(t)
+
+	if _, err := m.Open(m.user, m.stranger.ID); !errors.Is(err, ErrNotFriends) {
+		t.Errorf("opening with a stranger: got %v, want %v", err, ErrNotFriends)
+	}
+	if _, err := m.Conversation(m.user, m.channelID); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("getting before opening: got %v, want %v", err, ErrChannelNotFound)
+	}
+
+	opened, err := m.Open(m.user, m.friend.ID)
+	if err != nil {
+		t.Fatalf("opening: %v", err)
+	}
+	// The friend finds the same conversation from their side
+	again, err := m.Open(m.friend, m.user.ID)
+	if err != nil {
+		t.Fatalf("opening from the friend: %v", err)
+	}
+	if again.ID != opened.ID || opened.ChannelID != m.channelID {
+		t.Errorf("got conversations %s and %s, want the same of channel %s", opened.ID.Hex(), again.ID.Hex(), m.channelID)
+	}
+
+	if _, err := m.Conversation(m.stranger, m.channelID); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("getting as a stranger: got %v, want %v", err, ErrChannelNotFound)
+	}
+	param := models.MessageListParam{ChannelID: m.channelID, Page: 1, PageSize: 10}
+	if _, _, err := m.History(m.stranger, param); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("reading as a stranger: got %v, want %v", err, ErrChannelNotFound)
+	}
+}
+
+func TestMessengerSend(t *testing.T) {
+	m := newTestMessenger(t)
+	ctx := context.Background()
+
+	if _, err := m.Open(m.user, m.friend.ID); err != nil {
+		t.Fatalf("opening: %v", err)
+	}
+	message, err := m.Send(ctx, m.user, m.channelID, "hello")
+	if err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+
+	// Both members get it, the other connections of the sender included
+	for _, sub := range []*realtime.Subscription{m.userEvents, m.friendEvents} {
+		if event := nextEvent(t, sub); event.Type != realtime.EventMessageCreated {
+			t.Errorf("got %s, want %s", event.Type, realtime.EventMessageCreated)
+		}
+	}
+
+	history, _, err := m.History(m.friend, models.MessageListParam{ChannelID: m.channelID, Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("reading: %v", err)
+	}
+	if len(history) != 1 || history[0].ID != message.ID || history[0].Text != "hello" {
+		t.Errorf("got history %+v, want the message sent", history)
+	}
+	conversation, err := m.Conversation(m.friend, m.channelID)
+	if err != nil {
+		t.Fatalf("getting conversation: %v", err)
+	}
+	if conversation.LastMessageAt == nil || !conversation.LastMessageAt.Equal(message.CreatedAt.Truncate(time.Millisecond)) {
+		t.Errorf("got last message at %v, want %v", conversation.LastMessageAt, message.CreatedAt)
+	}
+
+	if _, err := m.Send(ctx, m.stranger, m.channelID, "hi"); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("sending as a stranger: got %v, want %v", err, ErrChannelNotFound)
+	}
+
+	// Unfriended, the history stays but nobody writes anymore
+	unfriended := &models.User{ID: m.user.ID}
+	if _, err := m.Send(ctx, unfriended, m.channelID, "still there?"); !errors.Is(err, ErrNotFriends) {
+		t.Errorf("sending unfriended: got %v, want %v", err, ErrNotFriends)
+	}
+	if err := m.conversations.Freeze(m.channelID); err != nil {
+		t.Fatalf("freezing: %v", err)
+	}
+	if _, err := m.Send(ctx, m.user, m.channelID, "frozen"); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("sending frozen: got %v, want %v", err, ErrChannelFrozen)
+	}
+}
+
+func TestMessengerEditAndDelete(t *testing.T) {
+	m := newTestMessenger(t)
+	ctx := context.Background()
+
+	if _, err := m.Open(m.user, m.friend.ID); err != nil {
+		t.Fatalf("opening: %v", err)
+	}
+	message, err := m.Send(ctx, m.user, m.channelID, "helo")
+	if err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+	nextEvent(t, m.friendEvents)
+
+	if _, err := m.Edit(ctx, m.friend, m.channelID, message.ID, "hijacked"); !errors.Is(err, ErrNotSender) {
+		t.Errorf("editing as the friend: got %v, want %v", err, ErrNotSender)
+	}
+	if _, err := m.Edit(ctx, m.user, m.channelID, bson.NewObjectID(), "hello"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("editing a missing message: got %v, want %v", err, ErrMessageNotFound)
+	}
+
+	edited, err := m.Edit(ctx, m.user, m.channelID, message.ID, "hello")
+	if err != nil {
+		t.Fatalf("editing: %v", err)
+	}
+	if edited.Text != "hello" || edited.EditedAt == nil {
+		t.Errorf("got %q edited at %v, want %q edited", edited.Text, edited.EditedAt, "hello")
+	}
+	if event := nextEvent(t, m.friendEvents); event.Type != realtime.EventMessageUpdated {
+		t.Errorf("got %s, want %s", event.Type, realtime.EventMessageUpdated)
+	}
+
+	// Frozen, what was said can still be taken back
+	if err := m.conversations.Freeze(m.channelID); err != nil {
+		t.Fatalf("freezing: %v", err)
+	}
+	if _, err := m.Edit(ctx, m.user, m.channelID, message.ID, "edited frozen"); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("editing frozen: got %v, want %v", err, ErrChannelFrozen)
+	}
+	deleted, err := m.Delete(ctx, m.user, m.channelID, message.ID)
+	if err != nil {
+		t.Fatalf("deleting frozen: %v", err)
+	}
+	if deleted.Text != "" || deleted.DeletedAt == nil {
+		t.Errorf("got %q deleted at %v, want no text and deleted", deleted.Text, deleted.DeletedAt)
+	}
+	if event := nextEvent(t, m.friendEvents); event.Type != realtime.EventMessageDeleted {
+		t.Errorf("got %s, want %s", event.Type, realtime.EventMessageDeleted)
+	}
+
+	if _, err := m.Delete(ctx, m.user, m.channelID, message.ID); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("deleting again: got %v, want %v", err, ErrMessageNotFound)
+	}
+}
+
+func TestMessengerMarkRead(t *testing.T) {
+	m := newTestMessenger(t)
+	ctx := context.Background()
+
+	if _, err := m.Open(m.user, m.friend.ID); err != nil {
+		t.Fatalf("opening: %v", err)
+	}
+	first, err := m.Send(ctx, m.friend, m.channelID, "first")
+	if err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+	second, err := m.Send(ctx, m.friend, m.channelID, "second")
+	if err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+	for range 2 {
+		nextEvent(t, m.friendEvents)
+	}
+
+	read, err := m.MarkRead(ctx, m.user, m.channelID, second.ID)
+	if err != nil {
+		t.Fatalf("marking read: %v", err)
+	}
+	if read.MessageID == nil || *read.MessageID != second.ID {
+		t.Errorf("got read up to %v, want %s", read.MessageID, second.ID.Hex())
+	}
+	if event := nextEvent(t, m.friendEvents); event.Type != realtime.EventMessageRead {
+		t.Errorf("got %s, want %s", event.Type, realtime.EventMessageRead)
+	}
+
+	// The receipt doesn't move back, and nobody is told
+	read, err = m.MarkRead(ctx, m.user, m.channelID, first.ID)
+	if err != nil {
+		t.Fatalf("marking an earlier message read: %v", err)
+	}
+	if read.MessageID == nil || *read.MessageID != second.ID {
+		t.Errorf("got read up to %v, want still %s", read.MessageID, second.ID.Hex())
+	}
+	select {
+	case event := <-m.friendEvents.C:
+		t.Errorf("got %s, want no event", event.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if _, err := m.MarkRead(ctx, m.user, m.channelID, bson.NewObjectID()); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("marking a missing message read: got %v, want %v", err, ErrMessageNotFound)
+	}
+}
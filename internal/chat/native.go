@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/signer"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// NativeProvider keeps the channels in MongoDB, for the deployments without
+// a chat service. The users are Streamify's own and the clients talk to the
+// API, see Messenger, its tokens only keep the contract of the other
+// providers.
+type NativeProvider struct {
+	conversations *models.ConversationModel
+	secret        []byte
+}
+
+func NewNativeProvider(conversations *models.ConversationModel, secret string) *NativeProvider {
+	return &NativeProvider{
+		conversations: conversations,
+		secret:        signer.DeriveKey(secret, TokenAudience),
+	}
+}
+
+func (p *NativeProvider) Name() string {
+	return "native"
+}
+
+// UpsertUser does nothing, the profiles are read from the users collection.
+func (p *NativeProvider) UpsertUser(ctx context.Context, user User) error {
+	return nil
+}
+
+// DeleteUser does nothing, the history of a deleted user stays with the
+// other member.
+func (p *NativeProvider) DeleteUser(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (p *NativeProvider) CreateToken(userID string, expiresAt, issuedAt time.Time) (string, error) {
+	return signToken(p.secret, userID, expiresAt, issuedAt)
+}
+
+// RevokeTokens does nothing, the native chat authenticates with the session
+// of the API rather than its tokens.
+func (p *NativeProvider) RevokeTokens(ctx context.Context, userID string, before time.Time) error {
+	return nil
+}
+
+func (p *NativeProvider) CreateChannel(ctx context.Context, channelID, createdBy string, members []string) error {
+	creatorID, err := bson.ObjectIDFromHex(createdBy)
+	if err != nil {
+		return err
+	}
+	memberIDs := make([]bson.ObjectID, 0, len(members))
+	for _, member := range members {
+		memberID, err := bson.ObjectIDFromHex(member)
+		if err != nil {
+			return err
+		}
+		memberIDs = append(memberIDs, memberID)
+	}
+
+	conversation, err := p.conversations.Upsert(channelID, creatorID, memberIDs)
+	if err != nil {
+		return err
+	}
+	if conversation.Frozen {
+		return p.conversations.Unfreeze(channelID)
+	}
+	return nil
+}
+
+func (p *NativeProvider) FreezeChannel(ctx context.Context, channelID string) error {
+	err := p.conversations.Freeze(channelID)
+	if errors.Is(err, models.ErrRecordNotFound) {
+		return ErrChannelNotFound
+	}
+	return err
+}
@@ -22,7 +22,11 @@ func init() {
 type Config struct {
 	Port int    `mapstructure:"PORT"`
 	Env  string `mapstructure:"API_ENV"`
-	Log  struct {
+	App  struct {
+		// Base url of the web app, invite links point at it
+		URL string `mapstructure:"API_APP_URL"`
+	} `mapstructure:",squash"`
+	Log struct {
 		Level string `mapstructure:"API_LOG_LEVEL"`
 	} `mapstructure:",squash"`
 	DB struct {
@@ -31,10 +35,62 @@ type Config struct {
 		MaxConnecting uint64        `mapstructure:"API_DB_MAX_CONNECTING"`
 		MaxPoolSize   uint64        `mapstructure:"API_DB_MAX_POOL_SIZE"`
 		MaxIdleTime   time.Duration `mapstructure:"API_DB_MAX_IDLE_TIME"`
+		SearchBackend string        `mapstructure:"API_DB_SEARCH_BACKEND"` // auto, atlas or regex
 	} `mapstructure:",squash"`
 	Cors struct {
 		Origins []string `mapstructure:"API_CORS_ORIGINS"`
 	} `mapstructure:",squash"`
+	Search struct {
+		// Weight of every field in the user search score
+		BoostName      float64 `mapstructure:"API_SEARCH_BOOST_NAME"`
+		BoostBio       float64 `mapstructure:"API_SEARCH_BOOST_BIO"`
+		BoostLanguages float64 `mapstructure:"API_SEARCH_BOOST_LANGUAGES"`
+		BoostLocation  float64 `mapstructure:"API_SEARCH_BOOST_LOCATION"`
+	} `mapstructure:",squash"`
+	Notify struct {
+		// How long notifications are kept, read or not
+		Retention time.Duration `mapstructure:"API_NOTIFY_RETENTION"`
+	} `mapstructure:",squash"`
+	Events struct {
+		Backend    string        `mapstructure:"API_EVENTS_BACKEND"` // memory or mongo
+		Heartbeat  time.Duration `mapstructure:"API_EVENTS_HEARTBEAT"`
+		ReplaySize int           `mapstructure:"API_EVENTS_REPLAY_SIZE"`
+		ReplayTTL  time.Duration `mapstructure:"API_EVENTS_REPLAY_TTL"`
+	} `mapstructure:",squash"`
+	WebSocket struct {
+		RateLimit  float64 `mapstructure:"API_WS_RATE_LIMIT"` // messages per second
+		RateBurst  int     `mapstructure:"API_WS_RATE_BURST"`
+		SendBuffer int     `mapstructure:"API_WS_SEND_BUFFER"`
+	} `mapstructure:",squash"`
+	Mail struct {
+		Sender       string `mapstructure:"API_MAIL_SENDER"` // smtp or file
+		From         string `mapstructure:"API_MAIL_FROM"`
+		SMTPHost     string `mapstructure:"API_MAIL_SMTP_HOST"`
+		SMTPPort     int    `mapstructure:"API_MAIL_SMTP_PORT"`
+		SMTPUsername string `mapstructure:"API_MAIL_SMTP_USERNAME"`
+		SMTPPassword string `mapstructure:"API_MAIL_SMTP_PASSWORD"`
+		// Directory the file sender drops the emails in
+		FileDir     string `mapstructure:"API_MAIL_FILE_DIR"`
+		MaxAttempts int    `mapstructure:"API_MAIL_MAX_ATTEMPTS"`
+		// How long before an inactive user is reminded, by email
+		InactivityAfter time.Duration `mapstructure:"API_MAIL_INACTIVITY_AFTER"`
+		// Local hour of the recipient the digests are sent at
+		DigestHour int `mapstructure:"API_MAIL_DIGEST_HOUR"`
+	} `mapstructure:",squash"`
+	WebPush struct {
+		// Keys of streamify-cli push vapid, Web Push is off without them
+		VAPIDPublicKey  string `mapstructure:"API_WEBPUSH_VAPID_PUBLIC_KEY"`
+		VAPIDPrivateKey string `mapstructure:"API_WEBPUSH_VAPID_PRIVATE_KEY"`
+		// Contact of the operator for the push services, mailto: or https:
+		Subject string        `mapstructure:"API_WEBPUSH_SUBJECT"`
+		TTL     time.Duration `mapstructure:"API_WEBPUSH_TTL"`
+		Urgency string        `mapstructure:"API_WEBPUSH_URGENCY"`
+	} `mapstructure:",squash"`
+	Chat struct {
+		Provider string `mapstructure:"API_CHAT_PROVIDER"` // getstream, native or memory
+		// Lifetime of the chat tokens, clients refresh them before
+		TokenTTL time.Duration `mapstructure:"API_CHAT_TOKEN_TTL"`
+	} `mapstructure:",squash"`
 	GetStreamIO struct {
 		ApiKey    string `mapstructure:"API_GETSTREAMIO_API_KEY"`
 		ApiSecret string `mapstructure:"API_GETSTREAMIO_API_SECRET"`
@@ -50,6 +106,41 @@ func New() Config {
 	viper.AddConfigPath(".")    // Look for the config file in the current directory
 	viper.AutomaticEnv()
 
+	// Optional config
+	viper.SetDefault("API_APP_URL", "http://localhost:5173")
+	viper.SetDefault("API_DB_SEARCH_BACKEND", "auto")
+	viper.SetDefault("API_SEARCH_BOOST_NAME", 4)
+	viper.SetDefault("API_SEARCH_BOOST_BIO", 1)
+	viper.SetDefault("API_SEARCH_BOOST_LANGUAGES", 2)
+	viper.SetDefault("API_SEARCH_BOOST_LOCATION", 2)
+	viper.SetDefault("API_NOTIFY_RETENTION", "2160h") // 90 days
+	viper.SetDefault("API_EVENTS_BACKEND", "memory")
+	viper.SetDefault("API_EVENTS_HEARTBEAT", "25s")
+	viper.SetDefault("API_EVENTS_REPLAY_SIZE", 100)
+	viper.SetDefault("API_EVENTS_REPLAY_TTL", "10m")
+	viper.SetDefault("API_WS_RATE_LIMIT", 10)
+	viper.SetDefault("API_WS_RATE_BURST", 20)
+	viper.SetDefault("API_WS_SEND_BUFFER", 16)
+	viper.SetDefault("API_MAIL_SENDER", "file")
+	viper.SetDefault("API_MAIL_FROM", "Streamify <no-reply@streamify.local>")
+	viper.SetDefault("API_MAIL_SMTP_HOST", "")
+	viper.SetDefault("API_MAIL_SMTP_PORT", 587)
+	viper.SetDefault("API_MAIL_SMTP_USERNAME", "")
+	viper.SetDefault("API_MAIL_SMTP_PASSWORD", "")
+	viper.SetDefault("API_MAIL_FILE_DIR", "./tmp/mail")
+	viper.SetDefault("API_MAIL_MAX_ATTEMPTS", 5)
+	viper.SetDefault("API_MAIL_INACTIVITY_AFTER", "336h") // 14 days
+	viper.SetDefault("API_MAIL_DIGEST_HOUR", 8)
+	viper.SetDefault("API_WEBPUSH_VAPID_PUBLIC_KEY", "")
+	viper.SetDefault("API_WEBPUSH_VAPID_PRIVATE_KEY", "")
+	viper.SetDefault("API_WEBPUSH_SUBJECT", "mailto:admin@streamify.local")
+	viper.SetDefault("API_WEBPUSH_TTL", "24h")
+	viper.SetDefault("API_WEBPUSH_URGENCY", "normal")
+	viper.SetDefault("API_CHAT_PROVIDER", "getstream")
+	viper.SetDefault("API_CHAT_TOKEN_TTL", "1h")
+	viper.SetDefault("API_GETSTREAMIO_API_KEY", "") // required by the getstream chat provider
+	viper.SetDefault("API_GETSTREAMIO_API_SECRET", "")
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatal().Err(err).Msg("Error reading config file")
 	}
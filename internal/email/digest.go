@@ -0,0 +1,233 @@
+package email
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	digestInterval  = time.Hour
+	digestBatchSize = 100
+	// Most names listed per section, the counts tell the rest
+	digestListSize = 5
+	// Weekly digests go out on the first day of the week
+	digestWeekday = time.Monday
+)
+
+// DigestData is the data of the digest email.
+type DigestData struct {
+	Cadence         models.DigestCadence
+	PendingRequests int64
+	PendingSenders  []string
+	NewFriends      []string
+	NewPartners     []string
+	RequestsURL     string
+	FriendsURL      string
+	PartnersURL     string
+}
+
+// Empty reports whether nothing happened worth a digest.
+func (d DigestData) Empty() bool {
+	return d.PendingRequests == 0 && len(d.NewFriends) == 0 && len(d.NewPartners) == 0
+}
+
+// Digester sends the users who opted in a daily or weekly summary of their
+// pending friend requests, new friends and new partners, at a set hour of
+// their timezone.
+type Digester struct {
+	mailer         *Mailer
+	users          *models.UserModel
+	friendRequests *models.FriendRequestModel
+	notifications  *models.NotificationModel
+	hour           int
+	logger         zerolog.Logger
+}
+
+// NewDigester sends the digests at hour, local time of the recipients.
+func NewDigester(mailer *Mailer, users *models.UserModel, friendRequests *models.FriendRequestModel, notifications *models.NotificationModel, hour int, logger zerolog.Logger) *Digester {
+	return &Digester{
+		mailer:         mailer,
+		users:          users,
+		friendRequests: friendRequests,
+		notifications:  notifications,
+		hour:           hour,
+		logger:         logger,
+	}
+}
+
+// Run sends the digests due every hour until ctx is done.
+func (d *Digester) Run(ctx context.Context) {
+	ticker := time.NewTicker(digestInterval)
+	defer ticker.Stop()
+
+	for {
+		err := d.EachDue(ctx, time.Now(), func(user *models.User) error {
+			_, err := d.Send(user, user.EmailPreferences.Cadence(), time.Now())
+			return err
+		})
+		if err != nil {
+			d.logger.Error().Err(err).Msg("Failed sending digests")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EachDue calls fn with every user whose digest is due at now: their local
+// time is past the digest hour of the day, or of the first day of the week,
+// and they didn't get it yet. Errors of fn are logged, the user is tried
+// again on the next round.
+func (d *Digester) EachDue(ctx context.Context, now time.Time, fn func(user *models.User) error) error {
+	for _, cadence := range []models.DigestCadence{models.DigestDaily, models.DigestWeekly} {
+		timezones, err := d.users.DigestTimezones(cadence)
+		if err != nil {
+			return err
+		}
+
+		for _, timezone := range timezones {
+			start, err := d.periodStart(cadence, timezone, now)
+			if err != nil {
+				d.logger.Warn().Err(err).Str("timezone", timezone).Msg("Unknown timezone of digest recipients")
+				continue
+			}
+			if now.Before(start.Add(time.Duration(d.hour) * time.Hour)) {
+				continue
+			}
+
+			if err := d.eachRecipient(ctx, cadence, timezone, start, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Digester) eachRecipient(ctx context.Context, cadence models.DigestCadence, timezone string, sentBefore time.Time, fn func(user *models.User) error) error {
+	after := bson.NilObjectID
+	for ctx.Err() == nil {
+		users, err := d.users.DigestRecipients(cadence, timezone, sentBefore, after, digestBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			if err := fn(user); err != nil {
+				d.logger.Error().Err(err).Str("user_id", user.ID.Hex()).Msg("Failed sending digest")
+			}
+			after = user.ID
+		}
+		if len(users) < digestBatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// periodStart returns the local midnight the current period of cadence began
+// at in timezone, UTC when empty.
+func (d *Digester) periodStart(cadence models.DigestCadence, timezone string, now time.Time) (time.Time, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := now.In(location)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	if cadence == models.DigestWeekly {
+		days := (int(local.Weekday()) - int(digestWeekday) + 7) % 7
+		start = start.AddDate(0, 0, -days)
+	}
+	return start, nil
+}
+
+// Build gathers the digest of user at now. It covers what happened since
+// their last digest, at most one period back.
+func (d *Digester) Build(user *models.User, cadence models.DigestCadence, now time.Time) (DigestData, error) {
+	since := now.AddDate(0, 0, -1)
+	if cadence == models.DigestWeekly {
+		since = now.AddDate(0, 0, -7)
+	}
+	if user.DigestSentAt != nil && user.DigestSentAt.After(since) {
+		since = *user.DigestSentAt
+	}
+
+	data := DigestData{
+		Cadence:     cadence,
+		RequestsURL: d.mailer.AppURL("/notifications"),
+		FriendsURL:  d.mailer.AppURL("/friends"),
+		PartnersURL: d.mailer.AppURL("/"),
+	}
+
+	pending, err := d.friendRequests.CountPending(user.ID)
+	if err != nil {
+		return DigestData{}, err
+	}
+	data.PendingRequests = pending
+
+	senders, err := d.friendRequests.PendingSenders(user.ID, digestListSize)
+	if err != nil {
+		return DigestData{}, err
+	}
+	data.PendingSenders = names(senders)
+
+	friends, err := d.notifications.ActorsSince(user.ID, []models.NotificationType{
+		models.NotificationFriendRequestAccepted,
+		models.NotificationInviteRedeemed,
+	}, since, digestListSize)
+	if err != nil {
+		return DigestData{}, err
+	}
+	data.NewFriends = names(friends)
+
+	partners, err := d.users.NewPartners(user, since, digestListSize)
+	if err != nil {
+		return DigestData{}, err
+	}
+	data.NewPartners = names(partners)
+
+	return data, nil
+}
+
+// Render returns the digest email of data for user without queuing it.
+func (d *Digester) Render(user *models.User, data DigestData) (*Message, error) {
+	return d.mailer.Render(user, models.EmailDigest, TemplateDigest, data)
+}
+
+// Send queues the digest of user at now and records it was sent, it reports
+// whether it was queued. An empty digest isn't sent, it is recorded though
+// so the next one doesn't repeat the period.
+func (d *Digester) Send(user *models.User, cadence models.DigestCadence, now time.Time) (bool, error) {
+	if cadence == models.DigestOff {
+		return false, nil
+	}
+
+	data, err := d.Build(user, cadence, now)
+	if err != nil {
+		return false, err
+	}
+
+	queued := false
+	if !data.Empty() {
+		queued, err = d.mailer.Send(user, models.EmailDigest, TemplateDigest, data)
+		if err != nil {
+			return false, err
+		}
+	}
+	return queued, d.users.MarkDigestSent(user)
+}
+
+func names(users []*models.User) []string {
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.FullName)
+	}
+	return names
+}
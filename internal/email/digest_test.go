@@ -0,0 +1,233 @@
+package email
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestDigestPeriodStart(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("loading timezone: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("loading timezone: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		cadence  models.DigestCadence
+		timezone string
+		now      time.Time
+		want     time.Time
+	}{
+		{
+			name:    "daily without timezone",
+			cadence: models.DigestDaily,
+			now:     time.Date(2026, 10, 21, 10, 0, 0, 0, time.UTC),
+			want:    time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily ahead of UTC",
+			cadence:  models.DigestDaily,
+			timezone: "Asia/Jakarta",
+			now:      time.Date(2026, 10, 21, 20, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 10, 22, 0, 0, 0, 0, jakarta),
+		},
+		{
+			name:     "weekly on the first day",
+			cadence:  models.DigestWeekly,
+			timezone: "Asia/Jakarta",
+			now:      time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 10, 19, 0, 0, 0, 0, jakarta),
+		},
+		{
+			name:     "weekly on the last day",
+			cadence:  models.DigestWeekly,
+			timezone: "Asia/Jakarta",
+			now:      time.Date(2026, 10, 25, 12, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 10, 19, 0, 0, 0, 0, jakarta),
+		},
+		{
+			// Monday in UTC, still Sunday in New York
+			name:     "weekly behind UTC",
+			cadence:  models.DigestWeekly,
+			timezone: "America/New_York",
+			now:      time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 10, 12, 0, 0, 0, 0, newYork),
+		},
+	}
+
+	d := &Digester{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.periodStart(tt.cadence, tt.timezone, tt.now)
+			if err != nil {
+				t.Fatalf("getting period start: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := d.periodStart(models.DigestDaily, "Mars/Olympus_Mons", time.Now()); err == nil {
+		t.Error("got no error for an unknown timezone")
+	}
+}
+
+func TestDigestDataEmpty(t *testing.T) {
+	tests := []struct {
+		name string
+		data DigestData
+		want bool
+	}{
+		{"nothing", DigestData{Cadence: models.DigestDaily}, true},
+		{"pending requests", DigestData{PendingRequests: 1}, false},
+		{"new friends", DigestData{NewFriends: []string{"Fay"}}, false},
+		{"new partners", DigestData{NewPartners: []string{"Pat"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.data.Empty(); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestEachDue(t *testing.T) {
+	db := testDatabase(t)
+	users := models.NewUserModel(db.Collection("users"), &models.RegexSearchBackend{}, models.NewCursorCodec("test"), zerolog.Nop())
+
+	// A Monday, 17:00 in Jakarta and 06:00 in New York, digests go out at 9:00
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	sentAt := func(ago time.Duration) *time.Time {
+		at := now.Add(-ago)
+		return &at
+	}
+
+	recipients := []struct {
+		name     string
+		user     models.User
+		wantSent bool
+	}{
+		{
+			name:     "daily",
+			user:     models.User{EmailPreferences: models.EmailPreferences{Digest: models.DigestDaily}},
+			wantSent: true,
+		},
+		{
+			name: "daily ahead of UTC",
+			user: models.User{
+				Location:         models.Location{Timezone: "Asia/Jakarta"},
+				EmailPreferences: models.EmailPreferences{Digest: models.DigestDaily},
+			},
+			wantSent: true,
+		},
+		{
+			name: "daily before the hour",
+			user: models.User{
+				Location:         models.Location{Timezone: "America/New_York"},
+				EmailPreferences: models.EmailPreferences{Digest: models.DigestDaily},
+			},
+		},
+		{
+			name: "daily sent yesterday",
+			user: models.User{
+				Location:         models.Location{Timezone: "Asia/Jakarta"},
+				EmailPreferences: models.EmailPreferences{Digest: models.DigestDaily},
+				DigestSentAt:     sentAt(24 * time.Hour),
+			},
+			wantSent: true,
+		},
+		{
+			name: "daily sent today",
+			user: models.User{
+				Location:         models.Location{Timezone: "Asia/Jakarta"},
+				EmailPreferences: models.EmailPreferences{Digest: models.DigestDaily},
+				DigestSentAt:     sentAt(time.Hour),
+			},
+		},
+		{
+			name: "weekly sent last week",
+			user: models.User{
+				EmailPreferences: models.EmailPreferences{Digest: models.DigestWeekly},
+				DigestSentAt:     sentAt(7 * 24 * time.Hour),
+			},
+			wantSent: true,
+		},
+		{
+			name: "weekly sent this week",
+			user: models.User{
+				EmailPreferences: models.EmailPreferences{Digest: models.DigestWeekly},
+				DigestSentAt:     sentAt(time.Hour),
+			},
+		},
+		{
+			name: "off",
+			user: models.User{EmailPreferences: models.EmailPreferences{Digest: models.DigestOff}},
+		},
+		{
+			name: "digest disabled",
+			user: models.User{EmailPreferences: models.EmailPreferences{
+				Digest:   models.DigestDaily,
+				Disabled: []models.EmailEvent{models.EmailDigest},
+			}},
+		},
+		{
+			name: "unknown timezone",
+			user: models.User{
+				Location:         models.Location{Timezone: "Mars/Olympus_Mons"},
+				EmailPreferences: models.EmailPreferences{Digest: models.DigestDaily},
+			},
+		},
+	}
+
+	want := []string{}
+	for _, r := range recipients {
+		user := r.user
+		user.FullName = r.name
+		user.Email = bson.NewObjectID().Hex() + "@example.com"
+		user.IsOnboarded = true
+		if _, err := users.Insert(&user); err != nil {
+			t.Fatalf("inserting user %s: %v", r.name, err)
+		}
+		if r.wantSent {
+			want = append(want, r.name)
+		}
+	}
+	// Not onboarded yet, whatever the preferences
+	_, err := users.Insert(&models.User{
+		FullName:         "not onboarded",
+		Email:            bson.NewObjectID().Hex() + "@example.com",
+		EmailPreferences: models.EmailPreferences{Digest: models.DigestDaily},
+	})
+	if err != nil {
+		t.Fatalf("inserting user not onboarded: %v", err)
+	}
+
+	d := NewDigester(nil, users, nil, nil, 9, zerolog.Nop())
+	got := []string{}
+	err = d.EachDue(context.Background(), now, func(user *models.User) error {
+		got = append(got, user.FullName)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("selecting due digests: %v", err)
+	}
+
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+// Package email renders the transactional emails and delivers them through a
+// queue, so sending never holds a request.
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Message is an email ready to be sent, with both an HTML and a plain text
+// version.
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+	Headers map[string]string
+}
+
+// Sender delivers messages, see SMTPSender and FileSender.
+type Sender interface {
+	Send(ctx context.Context, from string, msg *Message) error
+}
+
+type SenderOptions struct {
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	FileDir      string
+}
+
+// NewSender returns the Sender named by backend, smtp or file.
+func NewSender(backend string, opts SenderOptions) (Sender, error) {
+	switch backend {
+	case "smtp":
+		if opts.SMTPHost == "" {
+			return nil, fmt.Errorf("smtp sender requires a host")
+		}
+		return NewSMTPSender(opts.SMTPHost, opts.SMTPPort, opts.SMTPUsername, opts.SMTPPassword), nil
+	case "file":
+		return NewFileSender(opts.FileDir)
+	default:
+		return nil, fmt.Errorf("unknown email sender %q", backend)
+	}
+}
+
+// compose encodes msg as a multipart/alternative MIME message.
+func compose(from string, msg *Message) ([]byte, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		// The last part is the preferred one
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	messageID, err := newMessageID(sender.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&out, "%s: %s\r\n", key, value)
+	}
+	header("From", sender.String())
+	header("To", recipient.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	header("MIME-Version", "1.0")
+
+	keys := make([]string, 0, len(msg.Headers))
+	for key := range msg.Headers {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		header(key, msg.Headers[key])
+	}
+
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	out.WriteString("\r\n")
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+func newMessageID(address string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	domain := "localhost"
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		domain = address[i+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">", nil
+}
@@ -0,0 +1,38 @@
+package email
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSender drops every message as an .eml file in a directory instead of
+// sending it, for development.
+type FileSender struct {
+	dir string
+}
+
+func NewFileSender(dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+func (s *FileSender) Send(ctx context.Context, from string, msg *Message) error {
+	raw, err := compose(from, msg)
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml"
+
+	return os.WriteFile(filepath.Join(s.dir, name), raw, 0o644)
+}
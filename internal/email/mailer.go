@@ -0,0 +1,75 @@
+package email
+
+import (
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+// Mailer renders the emails of the users and queues them, leaving out those
+// they opted out of.
+type Mailer struct {
+	renderer *Renderer
+	queue    *Queue
+	tokens   *UnsubscribeTokens
+	appURL   string
+}
+
+// NewMailer links the emails to the web app at appURL, which serves the API
+// under /api/v1 too.
+func NewMailer(renderer *Renderer, queue *Queue, tokens *UnsubscribeTokens, appURL string) *Mailer {
+	return &Mailer{
+		renderer: renderer,
+		queue:    queue,
+		tokens:   tokens,
+		appURL:   strings.TrimRight(appURL, "/"),
+	}
+}
+
+// Send queues the email of template for user, unless they opted out of
+// event. It reports whether the email was queued.
+func (m *Mailer) Send(user *models.User, event models.EmailEvent, template string, data any) (bool, error) {
+	if !user.EmailPreferences.Enabled(event) {
+		return false, nil
+	}
+
+	msg, err := m.Render(user, event, template, data)
+	if err != nil {
+		return false, err
+	}
+	if err := m.queue.Enqueue(user.ID, event, msg); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Render returns the email of template for user without queuing it.
+func (m *Mailer) Render(user *models.User, event models.EmailEvent, template string, data any) (*Message, error) {
+	token := url.Values{"token": {m.tokens.Sign(user.ID, event)}}.Encode()
+
+	msg, err := m.renderer.Render(template, TemplateData{
+		Locale:         LocaleOf(user),
+		Recipient:      user,
+		UnsubscribeURL: m.AppURL("/unsubscribe?" + token),
+		PreferencesURL: m.AppURL("/settings/notifications"),
+		Data:           data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg.To = (&mail.Address{Name: user.FullName, Address: user.Email}).String()
+	// One-click unsubscribe from the mailbox, RFC 8058
+	msg.Headers = map[string]string{
+		"List-Unsubscribe":      "<" + m.AppURL("/api/v1/email/unsubscribe?"+token) + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	return msg, nil
+}
+
+// AppURL returns the link to path of the web app.
+func (m *Mailer) AppURL(path string) string {
+	return m.appURL + path
+}
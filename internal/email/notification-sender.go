@@ -0,0 +1,62 @@
+package email
+
+import (
+	"context"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+// ActorData is the data of the emails telling what another user did.
+type ActorData struct {
+	ActorName string
+	ActionURL string
+}
+
+// NotificationSender emails the notifications their users want by email. It
+// implements notify.Sender, the emails are queued and sent in the background.
+type NotificationSender struct {
+	mailer *Mailer
+	users  *models.UserModel
+}
+
+func NewNotificationSender(mailer *Mailer, users *models.UserModel) *NotificationSender {
+	return &NotificationSender{mailer: mailer, users: users}
+}
+
+func (s *NotificationSender) Name() string {
+	return "email"
+}
+
+func (s *NotificationSender) Send(ctx context.Context, notification *models.Notification) error {
+	var event models.EmailEvent
+	var template, actionPath string
+	switch notification.Type {
+	case models.NotificationFriendRequestReceived:
+		event, template, actionPath = models.EmailFriendRequestReceived, TemplateFriendRequestReceived, "/notifications"
+	case models.NotificationFriendRequestAccepted:
+		event, template, actionPath = models.EmailFriendRequestAccepted, TemplateFriendRequestAccepted, "/chat/"+notification.ActorID.Hex()
+	case models.NotificationInviteRedeemed:
+		event, template, actionPath = models.EmailInviteRedeemed, TemplateInviteRedeemed, "/chat/"+notification.ActorID.Hex()
+	default:
+		return nil
+	}
+
+	recipient, err := s.users.GetById(notification.UserID)
+	if err != nil {
+		return err
+	}
+	if !recipient.EmailPreferences.Enabled(event) {
+		return nil
+	}
+
+	actor, err := s.users.GetById(notification.ActorID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.mailer.Send(recipient, event, template, ActorData{
+		ActorName: actor.FullName,
+		ActionURL: s.mailer.AppURL(actionPath),
+	})
+	return err
+}
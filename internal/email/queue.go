@@ -0,0 +1,146 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type QueueOptions struct {
+	// From is the sender address of every email.
+	From string
+	// MaxAttempts is the number of sends tried before giving up on an email.
+	MaxAttempts int
+	// PollInterval is how often the queue is checked for due emails.
+	PollInterval time.Duration
+	// Lease is how long a worker owns an email it is sending, another worker
+	// retries it afterwards.
+	Lease time.Duration
+	// SendTimeout bounds a single send.
+	SendTimeout time.Duration
+	// The delay before a retry doubles from MinBackoff up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+var DefaultQueueOptions = QueueOptions{
+	MaxAttempts:  5,
+	PollInterval: 5 * time.Second,
+	Lease:        2 * time.Minute,
+	SendTimeout:  30 * time.Second,
+	MinBackoff:   time.Minute,
+	MaxBackoff:   time.Hour,
+}
+
+// Queue stores the emails to send and delivers them in the background,
+// retrying the failed ones. It is backed by the database so every replica
+// can work on it and nothing is lost on restart.
+type Queue struct {
+	jobs   *models.EmailJobModel
+	sender Sender
+	opts   QueueOptions
+	logger zerolog.Logger
+}
+
+func NewQueue(jobs *models.EmailJobModel, sender Sender, opts QueueOptions, logger zerolog.Logger) *Queue {
+	return &Queue{
+		jobs:   jobs,
+		sender: sender,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Enqueue stores msg to be sent as soon as possible.
+func (q *Queue) Enqueue(userID bson.ObjectID, event models.EmailEvent, msg *Message) error {
+	_, err := q.jobs.Enqueue(&models.EmailJob{
+		UserID:  userID,
+		Event:   event,
+		To:      msg.To,
+		Subject: msg.Subject,
+		HTML:    msg.HTML,
+		Text:    msg.Text,
+		Headers: msg.Headers,
+	})
+	return err
+}
+
+// Run sends the due emails until ctx is done.
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain sends the due emails one after the other until none is left.
+func (q *Queue) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := q.jobs.Claim(q.opts.Lease)
+		if err != nil {
+			if !errors.Is(err, models.ErrRecordNotFound) {
+				q.logger.Error().Err(err).Msg("Failed claiming email job")
+			}
+			return
+		}
+		q.deliver(ctx, job)
+	}
+}
+
+func (q *Queue) deliver(ctx context.Context, job *models.EmailJob) {
+	// A send in flight finishes on shutdown rather than being sent twice
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), q.opts.SendTimeout)
+	defer cancel()
+
+	sendErr := q.sender.Send(ctx, q.opts.From, &Message{
+		To:      job.To,
+		Subject: job.Subject,
+		HTML:    job.HTML,
+		Text:    job.Text,
+		Headers: job.Headers,
+	})
+
+	if sendErr == nil {
+		if err := q.jobs.MarkSent(job); err != nil {
+			q.logger.Error().Err(err).Str("job_id", job.ID.Hex()).Msg("Failed marking email job sent")
+		}
+		return
+	}
+
+	var nextAttemptAt *time.Time
+	if job.Attempts < q.opts.MaxAttempts {
+		next := time.Now().Add(q.backoff(job.Attempts))
+		nextAttemptAt = &next
+	}
+
+	q.logger.Warn().Err(sendErr).
+		Str("job_id", job.ID.Hex()).
+		Int("attempts", job.Attempts).
+		Bool("gave_up", nextAttemptAt == nil).
+		Msg("Failed sending email")
+
+	if err := q.jobs.Retry(job, sendErr, nextAttemptAt); err != nil {
+		q.logger.Error().Err(err).Str("job_id", job.ID.Hex()).Msg("Failed rescheduling email job")
+	}
+}
+
+// backoff returns the delay before retrying after attempts sends.
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.opts.MinBackoff
+	for i := 1; i < attempts && delay < q.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, q.opts.MaxBackoff)
+}
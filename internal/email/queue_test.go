@@ -0,0 +1,162 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// The queue and the digest run against a real MongoDB deployment, in a
+// throwaway database, when STREAMIFY_TEST_MONGO_URI is set.
+const testMongoURIEnv = "STREAMIFY_TEST_MONGO_URI"
+
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv(testMongoURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", testMongoURIEnv)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	db := client.Database("streamify_test_" + bson.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db
+}
+
+func testJobsCollection(t *testing.T) *mongo.Collection {
+	t.Helper()
+	return testDatabase(t).Collection("email_jobs")
+}
+
+// failingSender fails the first failures sends.
+type failingSender struct {
+	failures int
+	sent     []*Message
+	attempts int
+}
+
+func (s *failingSender) Send(ctx context.Context, from string, msg *Message) error {
+	s.attempts++
+	if s.attempts <= s.failures {
+		return errors.New("mailbox unavailable")
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestQueueBackoff(t *testing.T) {
+	q := &Queue{opts: QueueOptions{MinBackoff: time.Minute, MaxBackoff: time.Hour}}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := q.backoff(tt.attempts); got != tt.want {
+			t.Errorf("after %d attempts: got %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestQueueRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		wantStatus   models.EmailJobStatus
+		wantAttempts int
+		wantSent     int
+	}{
+		{"sent", 0, models.EmailJobSent, 1, 1},
+		{"sent on retry", 2, models.EmailJobSent, 3, 1},
+		{"given up", 5, models.EmailJobFailed, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coll := testJobsCollection(t)
+			jobs := models.NewEmailJobModel(coll, zerolog.Nop())
+			sender := &failingSender{failures: tt.failures}
+
+			// Retried right away so a drain goes through every attempt
+			opts := DefaultQueueOptions
+			opts.MaxAttempts = 3
+			opts.MinBackoff = time.Nanosecond
+			opts.MaxBackoff = time.Nanosecond
+			q := NewQueue(jobs, sender, opts, zerolog.Nop())
+
+			msg := &Message{To: "olivia@example.com", Subject: "Hello", Text: "Hello"}
+			if err := q.Enqueue(bson.NewObjectID(), models.EmailDigest, msg); err != nil {
+				t.Fatalf("enqueuing: %v", err)
+			}
+			q.drain(context.Background())
+
+			var job models.EmailJob
+			if err := coll.FindOne(context.Background(), bson.D{}).Decode(&job); err != nil {
+				t.Fatalf("getting job: %v", err)
+			}
+			if job.Status != tt.wantStatus || job.Attempts != tt.wantAttempts {
+				t.Errorf("got %s after %d attempts, want %s after %d", job.Status, job.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if len(sender.sent) != tt.wantSent {
+				t.Errorf("got %d sent, want %d", len(sender.sent), tt.wantSent)
+			}
+			if tt.failures > 0 && job.LastError == "" {
+				t.Error("got no last error")
+			}
+			if job.FinishedAt == nil {
+				t.Error("got a finished job without finished_at")
+			}
+		})
+	}
+}
+
+func TestQueueSchedulesRetryWithBackoff(t *testing.T) {
+	coll := testJobsCollection(t)
+	jobs := models.NewEmailJobModel(coll, zerolog.Nop())
+	q := NewQueue(jobs, &failingSender{failures: 1}, DefaultQueueOptions, zerolog.Nop())
+
+	msg := &Message{To: "olivia@example.com", Subject: "Hello", Text: "Hello"}
+	if err := q.Enqueue(bson.NewObjectID(), models.EmailDigest, msg); err != nil {
+		t.Fatalf("enqueuing: %v", err)
+	}
+	before := time.Now()
+	q.drain(context.Background())
+
+	var job models.EmailJob
+	if err := coll.FindOne(context.Background(), bson.D{}).Decode(&job); err != nil {
+		t.Fatalf("getting job: %v", err)
+	}
+	if job.Status != models.EmailJobPending || job.Attempts != 1 {
+		t.Errorf("got %s after %d attempts, want %s after 1", job.Status, job.Attempts, models.EmailJobPending)
+	}
+	// Not due again before the backoff, the drain stopped
+	if want := before.Add(DefaultQueueOptions.MinBackoff); job.NextAttemptAt.Before(want.Add(-time.Second)) {
+		t.Errorf("got next attempt at %v, want around %v", job.NextAttemptAt, want)
+	}
+}
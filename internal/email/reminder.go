@@ -0,0 +1,96 @@
+package email
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+const (
+	reminderInterval  = time.Hour
+	reminderBatchSize = 100
+)
+
+// ReminderData is the data of the inactivity reminder.
+type ReminderData struct {
+	PendingRequests int64
+	ActionURL       string
+}
+
+// Reminder emails the users who haven't been active for a while, once per
+// absence.
+type Reminder struct {
+	mailer         *Mailer
+	users          *models.UserModel
+	friendRequests *models.FriendRequestModel
+	after          time.Duration
+	logger         zerolog.Logger
+}
+
+// NewReminder reminds the users inactive for after.
+func NewReminder(mailer *Mailer, users *models.UserModel, friendRequests *models.FriendRequestModel, after time.Duration, logger zerolog.Logger) *Reminder {
+	return &Reminder{
+		mailer:         mailer,
+		users:          users,
+		friendRequests: friendRequests,
+		after:          after,
+		logger:         logger,
+	}
+}
+
+// Run looks for inactive users every hour until ctx is done.
+func (r *Reminder) Run(ctx context.Context) {
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+
+	for {
+		r.remind(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reminder) remind(ctx context.Context) {
+	for ctx.Err() == nil {
+		users, err := r.users.InactiveToRemind(time.Now().Add(-r.after), reminderBatchSize)
+		if err != nil {
+			r.logger.Error().Err(err).Msg("Failed listing inactive users")
+			return
+		}
+
+		reminded := 0
+		for _, user := range users {
+			if err := r.remindUser(user); err != nil {
+				r.logger.Error().Err(err).Str("user_id", user.ID.Hex()).Msg("Failed reminding inactive user")
+				continue
+			}
+			reminded++
+		}
+		// The failed users come back first, wait for the next round
+		if len(users) < reminderBatchSize || reminded == 0 {
+			return
+		}
+	}
+}
+
+func (r *Reminder) remindUser(user *models.User) error {
+	pending, err := r.friendRequests.CountPending(user.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.mailer.Send(user, models.EmailInactivityReminder, TemplateInactivityReminder, ReminderData{
+		PendingRequests: pending,
+		ActionURL:       r.mailer.AppURL("/"),
+	})
+	if err != nil {
+		return err
+	}
+	return r.users.MarkInactivityReminded(user)
+}
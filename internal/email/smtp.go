@@ -0,0 +1,49 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPSender relays the messages to an SMTP server, upgrading to TLS when the
+// server supports STARTTLS.
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+}
+
+func NewSMTPSender(host string, port int, username, password string) *SMTPSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPSender{
+		addr: host + ":" + strconv.Itoa(port),
+		auth: auth,
+	}
+}
+
+// Send doesn't honor ctx, net/smtp has no deadline support.
+func (s *SMTPSender) Send(ctx context.Context, from string, msg *Message) error {
+	raw, err := compose(from, msg)
+	if err != nil {
+		return err
+	}
+
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return err
+	}
+	recipient, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(s.addr, s.auth, sender.Address, []string{recipient.Address}, raw); err != nil {
+		return fmt.Errorf("smtp send: %w", err)
+	}
+	return nil
+}
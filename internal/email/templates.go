@@ -0,0 +1,192 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// DefaultLocale is used for users speaking none of the Locales natively.
+const DefaultLocale = "en"
+
+// Locales are the languages the emails are translated to.
+var Locales = []string{"en", "id"}
+
+// Names of the email templates, each has an .html.tmpl and a .txt.tmpl
+// version defining its subject and content.
+const (
+	TemplateFriendRequestReceived = "friend_request_received"
+	TemplateFriendRequestAccepted = "friend_request_accepted"
+	TemplateInviteRedeemed        = "invite_redeemed"
+	TemplateInactivityReminder    = "inactivity_reminder"
+	TemplateDigest                = "digest"
+)
+
+var templateNames = []string{
+	TemplateFriendRequestReceived,
+	TemplateFriendRequestAccepted,
+	TemplateInviteRedeemed,
+	TemplateInactivityReminder,
+	TemplateDigest,
+}
+
+// TemplateData is what every template is executed with, Data holds what is
+// specific to the template.
+type TemplateData struct {
+	Locale         string
+	Recipient      *models.User
+	UnsubscribeURL string
+	PreferencesURL string
+	Data           any
+}
+
+type button struct {
+	Label string
+	URL   string
+}
+
+// Renderer executes the email templates in the language of the recipient.
+type Renderer struct {
+	html     map[string]*htmltemplate.Template
+	text     map[string]*texttemplate.Template
+	catalogs map[string]map[string]string
+}
+
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{
+		html:     make(map[string]*htmltemplate.Template),
+		text:     make(map[string]*texttemplate.Template),
+		catalogs: make(map[string]map[string]string),
+	}
+
+	for _, locale := range Locales {
+		js, err := localeFS.ReadFile(path.Join("locales", locale+".json"))
+		if err != nil {
+			return nil, err
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(js, &catalog); err != nil {
+			return nil, fmt.Errorf("locale %s: %w", locale, err)
+		}
+		r.catalogs[locale] = catalog
+	}
+
+	// Placeholders, the functions of the locale rendered replace them
+	funcs := r.funcs(DefaultLocale)
+	for _, name := range templateNames {
+		html, err := htmltemplate.New(name).Funcs(funcs).ParseFS(templateFS, "templates/layout.html.tmpl", "templates/"+name+".html.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		text, err := texttemplate.New(name).Funcs(funcs).ParseFS(templateFS, "templates/layout.txt.tmpl", "templates/"+name+".txt.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		r.html[name] = html
+		r.text[name] = text
+	}
+	return r, nil
+}
+
+// Render returns the message of template name, without its recipient.
+func (r *Renderer) Render(name string, data TemplateData) (*Message, error) {
+	if _, ok := r.catalogs[data.Locale]; !ok {
+		data.Locale = DefaultLocale
+	}
+	funcs := r.funcs(data.Locale)
+
+	html, ok := r.html[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	html, err := html.Clone()
+	if err != nil {
+		return nil, err
+	}
+	text, err := r.text[name].Clone()
+	if err != nil {
+		return nil, err
+	}
+	html.Funcs(funcs)
+	text.Funcs(funcs)
+
+	var subject, htmlBody, textBody bytes.Buffer
+	// The subject comes from the text version, it mustn't be HTML escaped
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := html.ExecuteTemplate(&htmlBody, "layout", data); err != nil {
+		return nil, err
+	}
+	if err := text.ExecuteTemplate(&textBody, "layout", data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    htmlBody.String(),
+		Text:    textBody.String(),
+	}, nil
+}
+
+func (r *Renderer) funcs(locale string) map[string]any {
+	return map[string]any{
+		"t": func(key string, args ...any) string {
+			return r.translate(locale, key, args...)
+		},
+		// plural picks the .one or .other form of key for n
+		"plural": func(key string, n int64) string {
+			if n == 1 {
+				return r.translate(locale, key+".one", n)
+			}
+			return r.translate(locale, key+".other", n)
+		},
+		"button": func(label, url string) button {
+			return button{Label: label, URL: url}
+		},
+	}
+}
+
+// translate formats the message key of locale with args, falling back on the
+// default locale then on key itself.
+func (r *Renderer) translate(locale, key string, args ...any) string {
+	format, ok := r.catalogs[locale][key]
+	if !ok {
+		format, ok = r.catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// LocaleOf returns the language emails to user are written in: their choice,
+// else the first of their native languages translated, else the default.
+func LocaleOf(user *models.User) string {
+	if slices.Contains(Locales, user.EmailPreferences.Locale) {
+		return user.EmailPreferences.Locale
+	}
+	for _, code := range user.NativeLanguages() {
+		if slices.Contains(Locales, code) {
+			return code
+		}
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,174 @@
+package email
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+func TestLocaleCatalogsAreComplete(t *testing.T) {
+	catalogs := map[string]map[string]string{}
+	for _, locale := range Locales {
+		js, err := localeFS.ReadFile(path.Join("locales", locale+".json"))
+		if err != nil {
+			t.Fatalf("reading locale %s: %v", locale, err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(js, &catalog); err != nil {
+			t.Fatalf("decoding locale %s: %v", locale, err)
+		}
+		catalogs[locale] = catalog
+	}
+
+	for _, locale := range Locales {
+		for key := range catalogs[DefaultLocale] {
+			if _, ok := catalogs[locale][key]; !ok {
+				t.Errorf("locale %s misses %s", locale, key)
+			}
+		}
+		for key := range catalogs[locale] {
+			if _, ok := catalogs[DefaultLocale][key]; !ok {
+				t.Errorf("locale %s has %s unknown to %s", locale, key, DefaultLocale)
+			}
+		}
+	}
+}
+
+func TestRenderLocalized(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("creating renderer: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		locale      string
+		pending     int64
+		wantSubject string
+		wantText    string
+	}{
+		{"english", "en", 1, "Your language partners miss you", "You have 1 friend request waiting for an answer."},
+		{"english plural", "en", 3, "Your language partners miss you", "You have 3 friend requests waiting for an answer."},
+		{"indonesian", "id", 2, "Partner bahasamu merindukanmu", "Ada 2 permintaan pertemanan yang menunggu jawabanmu."},
+		{"unknown locale", "fr", 1, "Your language partners miss you", "You have 1 friend request waiting for an answer."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := r.Render(TemplateInactivityReminder, TemplateData{
+				Locale:    tt.locale,
+				Recipient: &models.User{FullName: "Olivia Owner"},
+				Data:      ReminderData{PendingRequests: tt.pending, ActionURL: "https://example.com/"},
+			})
+			if err != nil {
+				t.Fatalf("rendering: %v", err)
+			}
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("got subject %q, want %q", msg.Subject, tt.wantSubject)
+			}
+			for _, body := range []string{msg.Text, msg.HTML} {
+				if !strings.Contains(body, tt.wantText) {
+					t.Errorf("got body %q, want it to contain %q", body, tt.wantText)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderEveryTemplate(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("creating renderer: %v", err)
+	}
+
+	for _, locale := range Locales {
+		for _, name := range templateNames {
+			t.Run(locale+"/"+name, func(t *testing.T) {
+				msg, err := r.Render(name, TemplateData{
+					Locale:    locale,
+					Recipient: &models.User{FullName: "Olivia Owner"},
+					Data:      templateTestData[name],
+				})
+				if err != nil {
+					t.Fatalf("rendering: %v", err)
+				}
+				// A key left untranslated is rendered as is
+				for _, body := range []string{msg.Subject, msg.Text} {
+					if strings.Contains(body, name+".") || strings.Contains(body, "common.") {
+						t.Errorf("got an untranslated key in %q", body)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestTranslateFallback(t *testing.T) {
+	r := &Renderer{catalogs: map[string]map[string]string{
+		DefaultLocale: {"greeting": "Hi %s", "only_default": "Default"},
+		"id":          {"greeting": "Hai %s"},
+	}}
+
+	tests := []struct {
+		locale string
+		key    string
+		args   []any
+		want   string
+	}{
+		{"id", "greeting", []any{"Olivia"}, "Hai Olivia"},
+		{"id", "only_default", nil, "Default"},
+		{"id", "missing", nil, "missing"},
+		{DefaultLocale, "greeting", []any{"Olivia"}, "Hi Olivia"},
+	}
+
+	for _, tt := range tests {
+		if got := r.translate(tt.locale, tt.key, tt.args...); got != tt.want {
+			t.Errorf("%s %s: got %q, want %q", tt.locale, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleOf(t *testing.T) {
+	native := func(code string) models.UserLanguage {
+		return models.UserLanguage{Code: code, Level: models.LanguageLevelNative}
+	}
+
+	tests := []struct {
+		name string
+		user models.User
+		want string
+	}{
+		{"chosen", models.User{EmailPreferences: models.EmailPreferences{Locale: "id"}, Languages: []models.UserLanguage{native("en")}}, "id"},
+		{"native", models.User{Languages: []models.UserLanguage{native("fr"), native("id")}}, "id"},
+		{"learning", models.User{Languages: []models.UserLanguage{{Code: "id", Level: "B1", Learning: true}}}, DefaultLocale},
+		{"unsupported choice", models.User{EmailPreferences: models.EmailPreferences{Locale: "fr"}}, DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LocaleOf(&tt.user); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+var templateTestData = map[string]any{
+	TemplateFriendRequestReceived: ActorData{ActorName: "Rita Sender", ActionURL: "https://example.com/notifications"},
+	TemplateFriendRequestAccepted: ActorData{ActorName: "Rita Sender", ActionURL: "https://example.com/friends"},
+	TemplateInviteRedeemed:        ActorData{ActorName: "Rita Sender", ActionURL: "https://example.com/friends"},
+	TemplateInactivityReminder:    ReminderData{PendingRequests: 2, ActionURL: "https://example.com/"},
+	TemplateDigest: DigestData{
+		Cadence:         models.DigestWeekly,
+		PendingRequests: 1,
+		PendingSenders:  []string{"Rita Sender"},
+		NewFriends:      []string{"Bob Stone"},
+		NewPartners:     []string{"Carol Jones"},
+		RequestsURL:     "https://example.com/notifications",
+		FriendsURL:      "https://example.com/friends",
+		PartnersURL:     "https://example.com/",
+	},
+}
@@ -0,0 +1,45 @@
+package email
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/signer"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+// UnsubscribeTokens signs the one-click unsubscribe links. A token opts one
+// user out of one kind of email, it doesn't expire since emails are kept.
+type UnsubscribeTokens struct {
+	signer *signer.Signer
+}
+
+func NewUnsubscribeTokens(secret string) *UnsubscribeTokens {
+	return &UnsubscribeTokens{signer: signer.New(secret, "streamify-unsubscribe")}
+}
+
+func (t *UnsubscribeTokens) Sign(userID bson.ObjectID, event models.EmailEvent) string {
+	return t.signer.Sign([]byte(userID.Hex() + ":" + event))
+}
+
+// Verify returns the user and the kind of email token opts out.
+func (t *UnsubscribeTokens) Verify(token string) (bson.ObjectID, models.EmailEvent, error) {
+	payload, err := t.signer.Verify(token)
+	if err != nil {
+		return bson.ObjectID{}, "", ErrInvalidUnsubscribeToken
+	}
+
+	userHex, event, found := strings.Cut(string(payload), ":")
+	if !found || !slices.Contains(models.EmailEvents, event) {
+		return bson.ObjectID{}, "", ErrInvalidUnsubscribeToken
+	}
+	userID, err := bson.ObjectIDFromHex(userHex)
+	if err != nil {
+		return bson.ObjectID{}, "", ErrInvalidUnsubscribeToken
+	}
+	return userID, event, nil
+}
@@ -0,0 +1,50 @@
+package email
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ucok-man/streamify/internal/models"
+	"github.com/ucok-man/streamify/internal/signer"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestUnsubscribeTokens(t *testing.T) {
+	tokens := NewUnsubscribeTokens("secret")
+	userID := bson.NewObjectID()
+
+	gotUserID, gotEvent, err := tokens.Verify(tokens.Sign(userID, models.EmailDigest))
+	if err != nil {
+		t.Fatalf("verifying: %v", err)
+	}
+	if gotUserID != userID || gotEvent != models.EmailDigest {
+		t.Errorf("got %s for %s, want %s for %s", gotEvent, gotUserID.Hex(), models.EmailDigest, userID.Hex())
+	}
+
+	// A cursor is signed with the same secret for another purpose
+	cursor, err := models.NewCursorCodec("secret").Encode(models.Cursor{Listing: "test", Direction: models.CursorDirectionNext})
+	if err != nil {
+		t.Fatalf("encoding cursor: %v", err)
+	}
+
+	// Signed with the right key but not opting out of anything known
+	unsubscribes := signer.New("secret", "streamify-unsubscribe")
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"other secret", NewUnsubscribeTokens("other").Sign(userID, models.EmailDigest)},
+		{"cursor", cursor},
+		{"unknown event", unsubscribes.Sign([]byte(userID.Hex() + ":unknown"))},
+		{"invalid user", unsubscribes.Sign([]byte("user:" + models.EmailDigest))},
+		{"no event", unsubscribes.Sign([]byte(userID.Hex()))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tokens.Verify(tt.token); !errors.Is(err, ErrInvalidUnsubscribeToken) {
+				t.Errorf("got %v, want %v", err, ErrInvalidUnsubscribeToken)
+			}
+		})
+	}
+}
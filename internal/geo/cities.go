@@ -0,0 +1,133 @@
+package geo
+
+// cities bundles the places known to the offline geocoder, roughly ordered by
+// population so ambiguous names resolve to the largest city.
+var cities = []Place{
+	{City: "Tokyo", CountryCode: "JP", Latitude: 35.6762, Longitude: 139.6503, Timezone: "Asia/Tokyo"},
+	{City: "Delhi", CountryCode: "IN", Latitude: 28.7041, Longitude: 77.1025, Timezone: "Asia/Kolkata"},
+	{City: "Shanghai", CountryCode: "CN", Latitude: 31.2304, Longitude: 121.4737, Timezone: "Asia/Shanghai"},
+	{City: "São Paulo", CountryCode: "BR", Latitude: -23.5505, Longitude: -46.6333, Timezone: "America/Sao_Paulo"},
+	{City: "Mexico City", CountryCode: "MX", Latitude: 19.4326, Longitude: -99.1332, Timezone: "America/Mexico_City"},
+	{City: "Cairo", CountryCode: "EG", Latitude: 30.0444, Longitude: 31.2357, Timezone: "Africa/Cairo"},
+	{City: "Mumbai", CountryCode: "IN", Latitude: 19.0760, Longitude: 72.8777, Timezone: "Asia/Kolkata"},
+	{City: "Beijing", CountryCode: "CN", Latitude: 39.9042, Longitude: 116.4074, Timezone: "Asia/Shanghai"},
+	{City: "Dhaka", CountryCode: "BD", Latitude: 23.8103, Longitude: 90.4125, Timezone: "Asia/Dhaka"},
+	{City: "Osaka", CountryCode: "JP", Latitude: 34.6937, Longitude: 135.5023, Timezone: "Asia/Tokyo"},
+	{City: "New York", CountryCode: "US", Latitude: 40.7128, Longitude: -74.0060, Timezone: "America/New_York"},
+	{City: "Karachi", CountryCode: "PK", Latitude: 24.8607, Longitude: 67.0011, Timezone: "Asia/Karachi"},
+	{City: "Buenos Aires", CountryCode: "AR", Latitude: -34.6037, Longitude: -58.3816, Timezone: "America/Argentina/Buenos_Aires"},
+	{City: "Istanbul", CountryCode: "TR", Latitude: 41.0082, Longitude: 28.9784, Timezone: "Europe/Istanbul"},
+	{City: "Kolkata", CountryCode: "IN", Latitude: 22.5726, Longitude: 88.3639, Timezone: "Asia/Kolkata"},
+	{City: "Manila", CountryCode: "PH", Latitude: 14.5995, Longitude: 120.9842, Timezone: "Asia/Manila"},
+	{City: "Lagos", CountryCode: "NG", Latitude: 6.5244, Longitude: 3.3792, Timezone: "Africa/Lagos"},
+	{City: "Rio de Janeiro", CountryCode: "BR", Latitude: -22.9068, Longitude: -43.1729, Timezone: "America/Sao_Paulo"},
+	{City: "Guangzhou", CountryCode: "CN", Latitude: 23.1291, Longitude: 113.2644, Timezone: "Asia/Shanghai"},
+	{City: "Los Angeles", CountryCode: "US", Latitude: 34.0522, Longitude: -118.2437, Timezone: "America/Los_Angeles"},
+	{City: "Moscow", CountryCode: "RU", Latitude: 55.7558, Longitude: 37.6173, Timezone: "Europe/Moscow"},
+	{City: "Shenzhen", CountryCode: "CN", Latitude: 22.5431, Longitude: 114.0579, Timezone: "Asia/Shanghai"},
+	{City: "Lahore", CountryCode: "PK", Latitude: 31.5204, Longitude: 74.3587, Timezone: "Asia/Karachi"},
+	{City: "Bangalore", CountryCode: "IN", Latitude: 12.9716, Longitude: 77.5946, Timezone: "Asia/Kolkata"},
+	{City: "Paris", CountryCode: "FR", Latitude: 48.8566, Longitude: 2.3522, Timezone: "Europe/Paris"},
+	{City: "Bogotá", CountryCode: "CO", Latitude: 4.7110, Longitude: -74.0721, Timezone: "America/Bogota"},
+	{City: "Jakarta", CountryCode: "ID", Latitude: -6.2088, Longitude: 106.8456, Timezone: "Asia/Jakarta"},
+	{City: "Chennai", CountryCode: "IN", Latitude: 13.0827, Longitude: 80.2707, Timezone: "Asia/Kolkata"},
+	{City: "Lima", CountryCode: "PE", Latitude: -12.0464, Longitude: -77.0428, Timezone: "America/Lima"},
+	{City: "Bangkok", CountryCode: "TH", Latitude: 13.7563, Longitude: 100.5018, Timezone: "Asia/Bangkok"},
+	{City: "Seoul", CountryCode: "KR", Latitude: 37.5665, Longitude: 126.9780, Timezone: "Asia/Seoul"},
+	{City: "Nagoya", CountryCode: "JP", Latitude: 35.1815, Longitude: 136.9066, Timezone: "Asia/Tokyo"},
+	{City: "Hyderabad", CountryCode: "IN", Latitude: 17.3850, Longitude: 78.4867, Timezone: "Asia/Kolkata"},
+	{City: "London", CountryCode: "GB", Latitude: 51.5074, Longitude: -0.1278, Timezone: "Europe/London"},
+	{City: "Tehran", CountryCode: "IR", Latitude: 35.6892, Longitude: 51.3890, Timezone: "Asia/Tehran"},
+	{City: "Chicago", CountryCode: "US", Latitude: 41.8781, Longitude: -87.6298, Timezone: "America/Chicago"},
+	{City: "Chengdu", CountryCode: "CN", Latitude: 30.5728, Longitude: 104.0668, Timezone: "Asia/Shanghai"},
+	{City: "Ho Chi Minh City", CountryCode: "VN", Latitude: 10.8231, Longitude: 106.6297, Timezone: "Asia/Ho_Chi_Minh"},
+	{City: "Luanda", CountryCode: "AO", Latitude: -8.8390, Longitude: 13.2894, Timezone: "Africa/Luanda"},
+	{City: "Kuala Lumpur", CountryCode: "MY", Latitude: 3.1390, Longitude: 101.6869, Timezone: "Asia/Kuala_Lumpur"},
+	{City: "Hong Kong", CountryCode: "HK", Latitude: 22.3193, Longitude: 114.1694, Timezone: "Asia/Hong_Kong"},
+	{City: "Riyadh", CountryCode: "SA", Latitude: 24.7136, Longitude: 46.6753, Timezone: "Asia/Riyadh"},
+	{City: "Baghdad", CountryCode: "IQ", Latitude: 33.3152, Longitude: 44.3661, Timezone: "Asia/Baghdad"},
+	{City: "Santiago", CountryCode: "CL", Latitude: -33.4489, Longitude: -70.6693, Timezone: "America/Santiago"},
+	{City: "Madrid", CountryCode: "ES", Latitude: 40.4168, Longitude: -3.7038, Timezone: "Europe/Madrid"},
+	{City: "Toronto", CountryCode: "CA", Latitude: 43.6532, Longitude: -79.3832, Timezone: "America/Toronto"},
+	{City: "Singapore", CountryCode: "SG", Latitude: 1.3521, Longitude: 103.8198, Timezone: "Asia/Singapore"},
+	{City: "Khartoum", CountryCode: "SD", Latitude: 15.5007, Longitude: 32.5599, Timezone: "Africa/Khartoum"},
+	{City: "Saint Petersburg", CountryCode: "RU", Latitude: 59.9311, Longitude: 30.3609, Timezone: "Europe/Moscow"},
+	{City: "Nairobi", CountryCode: "KE", Latitude: -1.2921, Longitude: 36.8219, Timezone: "Africa/Nairobi"},
+	{City: "Surabaya", CountryCode: "ID", Latitude: -7.2575, Longitude: 112.7521, Timezone: "Asia/Jakarta"},
+	{City: "Houston", CountryCode: "US", Latitude: 29.7604, Longitude: -95.3698, Timezone: "America/Chicago"},
+	{City: "Johannesburg", CountryCode: "ZA", Latitude: -26.2041, Longitude: 28.0473, Timezone: "Africa/Johannesburg"},
+	{City: "Addis Ababa", CountryCode: "ET", Latitude: 9.0300, Longitude: 38.7400, Timezone: "Africa/Addis_Ababa"},
+	{City: "Casablanca", CountryCode: "MA", Latitude: 33.5731, Longitude: -7.5898, Timezone: "Africa/Casablanca"},
+	{City: "Sydney", CountryCode: "AU", Latitude: -33.8688, Longitude: 151.2093, Timezone: "Australia/Sydney"},
+	{City: "Melbourne", CountryCode: "AU", Latitude: -37.8136, Longitude: 144.9631, Timezone: "Australia/Melbourne"},
+	{City: "Berlin", CountryCode: "DE", Latitude: 52.5200, Longitude: 13.4050, Timezone: "Europe/Berlin"},
+	{City: "Bandung", CountryCode: "ID", Latitude: -6.9175, Longitude: 107.6191, Timezone: "Asia/Jakarta"},
+	{City: "Taipei", CountryCode: "TW", Latitude: 25.0330, Longitude: 121.5654, Timezone: "Asia/Taipei"},
+	{City: "Hanoi", CountryCode: "VN", Latitude: 21.0278, Longitude: 105.8342, Timezone: "Asia/Bangkok"},
+	{City: "Rome", CountryCode: "IT", Latitude: 41.9028, Longitude: 12.4964, Timezone: "Europe/Rome"},
+	{City: "Kyiv", CountryCode: "UA", Latitude: 50.4501, Longitude: 30.5234, Timezone: "Europe/Kyiv"},
+	{City: "Montreal", CountryCode: "CA", Latitude: 45.5017, Longitude: -73.5673, Timezone: "America/Toronto"},
+	{City: "Dubai", CountryCode: "AE", Latitude: 25.2048, Longitude: 55.2708, Timezone: "Asia/Dubai"},
+	{City: "Medan", CountryCode: "ID", Latitude: 3.5952, Longitude: 98.6722, Timezone: "Asia/Jakarta"},
+	{City: "Accra", CountryCode: "GH", Latitude: 5.6037, Longitude: -0.1870, Timezone: "Africa/Accra"},
+	{City: "Phoenix", CountryCode: "US", Latitude: 33.4484, Longitude: -112.0740, Timezone: "America/Phoenix"},
+	{City: "Philadelphia", CountryCode: "US", Latitude: 39.9526, Longitude: -75.1652, Timezone: "America/New_York"},
+	{City: "Barcelona", CountryCode: "ES", Latitude: 41.3851, Longitude: 2.1734, Timezone: "Europe/Madrid"},
+	{City: "Busan", CountryCode: "KR", Latitude: 35.1796, Longitude: 129.0756, Timezone: "Asia/Seoul"},
+	{City: "Milan", CountryCode: "IT", Latitude: 45.4642, Longitude: 9.1900, Timezone: "Europe/Rome"},
+	{City: "Athens", CountryCode: "GR", Latitude: 37.9838, Longitude: 23.7275, Timezone: "Europe/Athens"},
+	{City: "Lisbon", CountryCode: "PT", Latitude: 38.7223, Longitude: -9.1393, Timezone: "Europe/Lisbon"},
+	{City: "Warsaw", CountryCode: "PL", Latitude: 52.2297, Longitude: 21.0122, Timezone: "Europe/Warsaw"},
+	{City: "Bucharest", CountryCode: "RO", Latitude: 44.4268, Longitude: 26.1025, Timezone: "Europe/Bucharest"},
+	{City: "Hamburg", CountryCode: "DE", Latitude: 53.5511, Longitude: 9.9937, Timezone: "Europe/Berlin"},
+	{City: "Budapest", CountryCode: "HU", Latitude: 47.4979, Longitude: 19.0402, Timezone: "Europe/Budapest"},
+	{City: "Vienna", CountryCode: "AT", Latitude: 48.2082, Longitude: 16.3738, Timezone: "Europe/Vienna"},
+	{City: "Munich", CountryCode: "DE", Latitude: 48.1351, Longitude: 11.5820, Timezone: "Europe/Berlin"},
+	{City: "Prague", CountryCode: "CZ", Latitude: 50.0755, Longitude: 14.4378, Timezone: "Europe/Prague"},
+	{City: "Amsterdam", CountryCode: "NL", Latitude: 52.3676, Longitude: 4.9041, Timezone: "Europe/Amsterdam"},
+	{City: "Stockholm", CountryCode: "SE", Latitude: 59.3293, Longitude: 18.0686, Timezone: "Europe/Stockholm"},
+	{City: "Brussels", CountryCode: "BE", Latitude: 50.8503, Longitude: 4.3517, Timezone: "Europe/Brussels"},
+	{City: "Copenhagen", CountryCode: "DK", Latitude: 55.6761, Longitude: 12.5683, Timezone: "Europe/Copenhagen"},
+	{City: "Dublin", CountryCode: "IE", Latitude: 53.3498, Longitude: -6.2603, Timezone: "Europe/Dublin"},
+	{City: "Helsinki", CountryCode: "FI", Latitude: 60.1699, Longitude: 24.9384, Timezone: "Europe/Helsinki"},
+	{City: "Oslo", CountryCode: "NO", Latitude: 59.9139, Longitude: 10.7522, Timezone: "Europe/Oslo"},
+	{City: "Zurich", CountryCode: "CH", Latitude: 47.3769, Longitude: 8.5417, Timezone: "Europe/Zurich"},
+	{City: "Manchester", CountryCode: "GB", Latitude: 53.4808, Longitude: -2.2426, Timezone: "Europe/London"},
+	{City: "Lyon", CountryCode: "FR", Latitude: 45.7640, Longitude: 4.8357, Timezone: "Europe/Paris"},
+	{City: "San Francisco", CountryCode: "US", Latitude: 37.7749, Longitude: -122.4194, Timezone: "America/Los_Angeles"},
+	{City: "Seattle", CountryCode: "US", Latitude: 47.6062, Longitude: -122.3321, Timezone: "America/Los_Angeles"},
+	{City: "Miami", CountryCode: "US", Latitude: 25.7617, Longitude: -80.1918, Timezone: "America/New_York"},
+	{City: "Boston", CountryCode: "US", Latitude: 42.3601, Longitude: -71.0589, Timezone: "America/New_York"},
+	{City: "Denver", CountryCode: "US", Latitude: 39.7392, Longitude: -104.9903, Timezone: "America/Denver"},
+	{City: "Vancouver", CountryCode: "CA", Latitude: 49.2827, Longitude: -123.1207, Timezone: "America/Vancouver"},
+	{City: "Honolulu", CountryCode: "US", Latitude: 21.3069, Longitude: -157.8583, Timezone: "Pacific/Honolulu"},
+	{City: "Anchorage", CountryCode: "US", Latitude: 61.2181, Longitude: -149.9003, Timezone: "America/Anchorage"},
+	{City: "Auckland", CountryCode: "NZ", Latitude: -36.8485, Longitude: 174.7633, Timezone: "Pacific/Auckland"},
+	{City: "Perth", CountryCode: "AU", Latitude: -31.9505, Longitude: 115.8605, Timezone: "Australia/Perth"},
+	{City: "Brisbane", CountryCode: "AU", Latitude: -27.4698, Longitude: 153.0251, Timezone: "Australia/Brisbane"},
+	{City: "Yogyakarta", CountryCode: "ID", Latitude: -7.7956, Longitude: 110.3695, Timezone: "Asia/Jakarta"},
+	{City: "Denpasar", CountryCode: "ID", Latitude: -8.6705, Longitude: 115.2126, Timezone: "Asia/Makassar"},
+	{City: "Makassar", CountryCode: "ID", Latitude: -5.1477, Longitude: 119.4327, Timezone: "Asia/Makassar"},
+	{City: "Cebu", CountryCode: "PH", Latitude: 10.3157, Longitude: 123.8854, Timezone: "Asia/Manila"},
+	{City: "Kathmandu", CountryCode: "NP", Latitude: 27.7172, Longitude: 85.3240, Timezone: "Asia/Kathmandu"},
+	{City: "Colombo", CountryCode: "LK", Latitude: 6.9271, Longitude: 79.8612, Timezone: "Asia/Colombo"},
+	{City: "Tashkent", CountryCode: "UZ", Latitude: 41.2995, Longitude: 69.2401, Timezone: "Asia/Tashkent"},
+	{City: "Almaty", CountryCode: "KZ", Latitude: 43.2220, Longitude: 76.8512, Timezone: "Asia/Almaty"},
+	{City: "Tel Aviv", CountryCode: "IL", Latitude: 32.0853, Longitude: 34.7818, Timezone: "Asia/Jerusalem"},
+	{City: "Amman", CountryCode: "JO", Latitude: 31.9454, Longitude: 35.9284, Timezone: "Asia/Amman"},
+	{City: "Beirut", CountryCode: "LB", Latitude: 33.8938, Longitude: 35.5018, Timezone: "Asia/Beirut"},
+	{City: "Doha", CountryCode: "QA", Latitude: 25.2854, Longitude: 51.5310, Timezone: "Asia/Qatar"},
+	{City: "Tunis", CountryCode: "TN", Latitude: 36.8065, Longitude: 10.1815, Timezone: "Africa/Tunis"},
+	{City: "Algiers", CountryCode: "DZ", Latitude: 36.7538, Longitude: 3.0588, Timezone: "Africa/Algiers"},
+	{City: "Dakar", CountryCode: "SN", Latitude: 14.7167, Longitude: -17.4677, Timezone: "Africa/Dakar"},
+	{City: "Cape Town", CountryCode: "ZA", Latitude: -33.9249, Longitude: 18.4241, Timezone: "Africa/Johannesburg"},
+	{City: "Kinshasa", CountryCode: "CD", Latitude: -4.4419, Longitude: 15.2663, Timezone: "Africa/Kinshasa"},
+	{City: "Dar es Salaam", CountryCode: "TZ", Latitude: -6.7924, Longitude: 39.2083, Timezone: "Africa/Dar_es_Salaam"},
+	{City: "Kampala", CountryCode: "UG", Latitude: 0.3476, Longitude: 32.5825, Timezone: "Africa/Kampala"},
+	{City: "Caracas", CountryCode: "VE", Latitude: 10.4806, Longitude: -66.9036, Timezone: "America/Caracas"},
+	{City: "Quito", CountryCode: "EC", Latitude: -0.1807, Longitude: -78.4678, Timezone: "America/Guayaquil"},
+	{City: "Montevideo", CountryCode: "UY", Latitude: -34.9011, Longitude: -56.1645, Timezone: "America/Montevideo"},
+	{City: "Guadalajara", CountryCode: "MX", Latitude: 20.6597, Longitude: -103.3496, Timezone: "America/Mexico_City"},
+	{City: "Havana", CountryCode: "CU", Latitude: 23.1136, Longitude: -82.3666, Timezone: "America/Havana"},
+	{City: "Reykjavik", CountryCode: "IS", Latitude: 64.1466, Longitude: -21.9426, Timezone: "Atlantic/Reykjavik"},
+}
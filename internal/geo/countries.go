@@ -0,0 +1,85 @@
+package geo
+
+// countries maps ISO 3166-1 alpha-2 codes of the bundled cities to their
+// English name and common aliases.
+var countries = map[string][]string{
+	"AE": {"United Arab Emirates", "UAE"},
+	"AO": {"Angola"},
+	"AR": {"Argentina"},
+	"AT": {"Austria"},
+	"AU": {"Australia"},
+	"BD": {"Bangladesh"},
+	"BE": {"Belgium"},
+	"BR": {"Brazil", "Brasil"},
+	"CA": {"Canada"},
+	"CD": {"Democratic Republic of the Congo", "DR Congo", "DRC"},
+	"CH": {"Switzerland"},
+	"CL": {"Chile"},
+	"CN": {"China"},
+	"CO": {"Colombia"},
+	"CU": {"Cuba"},
+	"CZ": {"Czechia", "Czech Republic"},
+	"DE": {"Germany", "Deutschland"},
+	"DK": {"Denmark"},
+	"DZ": {"Algeria"},
+	"EC": {"Ecuador"},
+	"EG": {"Egypt"},
+	"ES": {"Spain", "España"},
+	"ET": {"Ethiopia"},
+	"FI": {"Finland"},
+	"FR": {"France"},
+	"GB": {"United Kingdom", "UK", "Great Britain", "England", "Scotland", "Wales"},
+	"GH": {"Ghana"},
+	"GR": {"Greece"},
+	"HK": {"Hong Kong"},
+	"HU": {"Hungary"},
+	"ID": {"Indonesia"},
+	"IE": {"Ireland"},
+	"IL": {"Israel"},
+	"IN": {"India"},
+	"IQ": {"Iraq"},
+	"IR": {"Iran"},
+	"IS": {"Iceland"},
+	"IT": {"Italy", "Italia"},
+	"JO": {"Jordan"},
+	"JP": {"Japan"},
+	"KE": {"Kenya"},
+	"KR": {"South Korea", "Korea", "Republic of Korea"},
+	"KZ": {"Kazakhstan"},
+	"LB": {"Lebanon"},
+	"LK": {"Sri Lanka"},
+	"MA": {"Morocco"},
+	"MX": {"Mexico", "México"},
+	"MY": {"Malaysia"},
+	"NG": {"Nigeria"},
+	"NL": {"Netherlands", "The Netherlands", "Holland"},
+	"NO": {"Norway"},
+	"NP": {"Nepal"},
+	"NZ": {"New Zealand"},
+	"PE": {"Peru"},
+	"PH": {"Philippines"},
+	"PK": {"Pakistan"},
+	"PL": {"Poland"},
+	"PT": {"Portugal"},
+	"QA": {"Qatar"},
+	"RO": {"Romania"},
+	"RU": {"Russia", "Russian Federation"},
+	"SA": {"Saudi Arabia"},
+	"SD": {"Sudan"},
+	"SE": {"Sweden"},
+	"SG": {"Singapore"},
+	"SN": {"Senegal"},
+	"TH": {"Thailand"},
+	"TN": {"Tunisia"},
+	"TR": {"Turkey", "Türkiye"},
+	"TW": {"Taiwan"},
+	"TZ": {"Tanzania"},
+	"UA": {"Ukraine"},
+	"UG": {"Uganda"},
+	"US": {"United States", "USA", "United States of America", "America"},
+	"UY": {"Uruguay"},
+	"UZ": {"Uzbekistan"},
+	"VE": {"Venezuela"},
+	"VN": {"Vietnam", "Viet Nam"},
+	"ZA": {"South Africa"},
+}
@@ -0,0 +1,76 @@
+// Package geo resolves free-text locations into structured places and answers
+// timezone questions about them.
+package geo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	// Embed the IANA database so timezones resolve without system tzdata.
+	_ "time/tzdata"
+)
+
+var ErrPlaceNotFound = errors.New("geo: place not found")
+
+type Place struct {
+	City        string  `json:"city"`
+	CountryCode string  `json:"country_code"` // ISO 3166-1 alpha-2
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Timezone    string  `json:"timezone"` // IANA name
+}
+
+// Geocoder resolves a free-text query such as "Jakarta, Indonesia" into a Place.
+type Geocoder interface {
+	Geocode(ctx context.Context, query string) (*Place, error)
+}
+
+const earthRadiusKm = 6378.1
+
+// KmToRadians converts a distance on the earth surface to radians, as expected
+// by $centerSphere.
+func KmToRadians(km float64) float64 {
+	return km / earthRadiusKm
+}
+
+// Waking hours in local time used to compare timezones.
+const (
+	WakingHourStart = 8
+	WakingHourEnd   = 22
+)
+
+// UTCOffset returns the current offset of the IANA timezone.
+func UTCOffset(timezone string, at time.Time) (time.Duration, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return 0, err
+	}
+	_, offset := at.In(loc).Zone()
+	return time.Duration(offset) * time.Second, nil
+}
+
+// WakingOverlap returns how long the waking hours of two UTC offsets overlap
+// over a day.
+func WakingOverlap(a, b time.Duration) time.Duration {
+	const day = 24 * 60
+
+	// Waking window of each offset, in UTC minutes of the day
+	window := func(offset time.Duration) [day]bool {
+		var minutes [day]bool
+		start := WakingHourStart*60 - int(offset.Minutes())
+		for m := 0; m < (WakingHourEnd-WakingHourStart)*60; m++ {
+			minutes[((start+m)%day+day)%day] = true
+		}
+		return minutes
+	}
+
+	wa, wb := window(a), window(b)
+	var overlap int
+	for m := 0; m < day; m++ {
+		if wa[m] && wb[m] {
+			overlap++
+		}
+	}
+	return time.Duration(overlap) * time.Minute
+}
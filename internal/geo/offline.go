@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// OfflineGeocoder resolves places from the bundled cities list, it never
+// touches the network.
+type OfflineGeocoder struct{}
+
+func NewOfflineGeocoder() *OfflineGeocoder {
+	return &OfflineGeocoder{}
+}
+
+// Geocode accepts "City", "City, Country" or "City, CC" queries.
+func (g *OfflineGeocoder) Geocode(ctx context.Context, query string) (*Place, error) {
+	cityPart, countryPart, _ := strings.Cut(query, ",")
+	city := strings.TrimSpace(cityPart)
+	country := strings.TrimSpace(countryPart)
+	if city == "" {
+		return nil, ErrPlaceNotFound
+	}
+
+	countryCode := ""
+	if country != "" {
+		var ok bool
+		countryCode, ok = lookupCountry(country)
+		if !ok {
+			return nil, ErrPlaceNotFound
+		}
+	}
+
+	for _, place := range cities {
+		if !strings.EqualFold(fold(place.City), fold(city)) {
+			continue
+		}
+		if countryCode != "" && place.CountryCode != countryCode {
+			continue
+		}
+		found := place
+		return &found, nil
+	}
+
+	return nil, ErrPlaceNotFound
+}
+
+// Places returns every bundled place.
+func (g *OfflineGeocoder) Places() []Place {
+	return slices.Clone(cities)
+}
+
+// fold strips diacritics so "Sao Paulo" matches "São Paulo".
+func fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+	return folded
+}
+
+func lookupCountry(s string) (string, bool) {
+	if _, ok := countries[strings.ToUpper(s)]; ok {
+		return strings.ToUpper(s), true
+	}
+	for code, names := range countries {
+		for _, name := range names {
+			if strings.EqualFold(fold(name), fold(s)) {
+				return code, true
+			}
+		}
+	}
+	return "", false
+}
+
+// CountryName returns the English name of an ISO 3166-1 alpha-2 code.
+func CountryName(code string) string {
+	names, ok := countries[strings.ToUpper(code)]
+	if !ok {
+		return ""
+	}
+	return names[0]
+}
+
+// CountryCode returns the ISO 3166-1 alpha-2 code of a country name, alias or
+// code, case and accent insensitively.
+func CountryCode(name string) (string, bool) {
+	return lookupCountry(strings.TrimSpace(name))
+}
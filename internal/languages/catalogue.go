@@ -0,0 +1,99 @@
+package languages
+
+// catalogue is ordered by English name. Code is the ISO 639-1 code when one
+// exists, otherwise the ISO 639-3 code.
+var catalogue = []Language{
+	{Code: "af", Code3: "afr", Name: "Afrikaans", NativeName: "Afrikaans"},
+	{Code: "sq", Code3: "sqi", Name: "Albanian", NativeName: "Shqip", Aliases: []string{"alb"}},
+	{Code: "am", Code3: "amh", Name: "Amharic", NativeName: "አማርኛ"},
+	{Code: "ar", Code3: "ara", Name: "Arabic", NativeName: "العربية"},
+	{Code: "hy", Code3: "hye", Name: "Armenian", NativeName: "Հայերեն", Aliases: []string{"arm"}},
+	{Code: "ase", Code3: "ase", Name: "American Sign Language", NativeName: "American Sign Language", Aliases: []string{"asl"}},
+	{Code: "az", Code3: "aze", Name: "Azerbaijani", NativeName: "Azərbaycan dili", Aliases: []string{"azeri"}},
+	{Code: "eu", Code3: "eus", Name: "Basque", NativeName: "Euskara", Aliases: []string{"baq"}},
+	{Code: "be", Code3: "bel", Name: "Belarusian", NativeName: "Беларуская"},
+	{Code: "bn", Code3: "ben", Name: "Bengali", NativeName: "বাংলা", Aliases: []string{"bangla"}},
+	{Code: "bs", Code3: "bos", Name: "Bosnian", NativeName: "Bosanski"},
+	{Code: "bg", Code3: "bul", Name: "Bulgarian", NativeName: "Български"},
+	{Code: "my", Code3: "mya", Name: "Burmese", NativeName: "မြန်မာဘာသာ", Aliases: []string{"bur", "myanmar"}},
+	{Code: "yue", Code3: "yue", Name: "Cantonese", NativeName: "粵語", Aliases: []string{"yue chinese"}},
+	{Code: "ca", Code3: "cat", Name: "Catalan", NativeName: "Català", Aliases: []string{"valencian"}},
+	{Code: "zh", Code3: "zho", Name: "Chinese", NativeName: "中文", Aliases: []string{"chi", "mandarin", "cmn", "mandarin chinese", "putonghua"}},
+	{Code: "hr", Code3: "hrv", Name: "Croatian", NativeName: "Hrvatski"},
+	{Code: "cs", Code3: "ces", Name: "Czech", NativeName: "Čeština", Aliases: []string{"cze"}},
+	{Code: "da", Code3: "dan", Name: "Danish", NativeName: "Dansk"},
+	{Code: "nl", Code3: "nld", Name: "Dutch", NativeName: "Nederlands", Aliases: []string{"dut", "flemish"}},
+	{Code: "en", Code3: "eng", Name: "English", NativeName: "English"},
+	{Code: "eo", Code3: "epo", Name: "Esperanto", NativeName: "Esperanto"},
+	{Code: "et", Code3: "est", Name: "Estonian", NativeName: "Eesti"},
+	{Code: "fil", Code3: "fil", Name: "Filipino", NativeName: "Filipino", Aliases: []string{"pilipino"}},
+	{Code: "fi", Code3: "fin", Name: "Finnish", NativeName: "Suomi"},
+	{Code: "fr", Code3: "fra", Name: "French", NativeName: "Français", Aliases: []string{"fre"}},
+	{Code: "gl", Code3: "glg", Name: "Galician", NativeName: "Galego"},
+	{Code: "ka", Code3: "kat", Name: "Georgian", NativeName: "ქართული", Aliases: []string{"geo"}},
+	{Code: "de", Code3: "deu", Name: "German", NativeName: "Deutsch", Aliases: []string{"ger"}},
+	{Code: "el", Code3: "ell", Name: "Greek", NativeName: "Ελληνικά", Aliases: []string{"gre", "modern greek"}},
+	{Code: "gu", Code3: "guj", Name: "Gujarati", NativeName: "ગુજરાતી"},
+	{Code: "ht", Code3: "hat", Name: "Haitian Creole", NativeName: "Kreyòl ayisyen", Aliases: []string{"haitian"}},
+	{Code: "ha", Code3: "hau", Name: "Hausa", NativeName: "Hausa"},
+	{Code: "haw", Code3: "haw", Name: "Hawaiian", NativeName: "ʻŌlelo Hawaiʻi"},
+	{Code: "he", Code3: "heb", Name: "Hebrew", NativeName: "עברית", Aliases: []string{"iw"}},
+	{Code: "hi", Code3: "hin", Name: "Hindi", NativeName: "हिन्दी"},
+	{Code: "hu", Code3: "hun", Name: "Hungarian", NativeName: "Magyar"},
+	{Code: "is", Code3: "isl", Name: "Icelandic", NativeName: "Íslenska", Aliases: []string{"ice"}},
+	{Code: "ig", Code3: "ibo", Name: "Igbo", NativeName: "Asụsụ Igbo"},
+	{Code: "id", Code3: "ind", Name: "Indonesian", NativeName: "Bahasa Indonesia", Aliases: []string{"in", "bahasa"}},
+	{Code: "ga", Code3: "gle", Name: "Irish", NativeName: "Gaeilge", Aliases: []string{"irish gaelic"}},
+	{Code: "it", Code3: "ita", Name: "Italian", NativeName: "Italiano"},
+	{Code: "ja", Code3: "jpn", Name: "Japanese", NativeName: "日本語"},
+	{Code: "jv", Code3: "jav", Name: "Javanese", NativeName: "Basa Jawa"},
+	{Code: "kn", Code3: "kan", Name: "Kannada", NativeName: "ಕನ್ನಡ"},
+	{Code: "kk", Code3: "kaz", Name: "Kazakh", NativeName: "Қазақ тілі"},
+	{Code: "km", Code3: "khm", Name: "Khmer", NativeName: "ខ្មែរ", Aliases: []string{"cambodian"}},
+	{Code: "ko", Code3: "kor", Name: "Korean", NativeName: "한국어"},
+	{Code: "ku", Code3: "kur", Name: "Kurdish", NativeName: "Kurdî"},
+	{Code: "ky", Code3: "kir", Name: "Kyrgyz", NativeName: "Кыргызча", Aliases: []string{"kirghiz"}},
+	{Code: "lo", Code3: "lao", Name: "Lao", NativeName: "ລາວ", Aliases: []string{"laotian"}},
+	{Code: "la", Code3: "lat", Name: "Latin", NativeName: "Latina"},
+	{Code: "lv", Code3: "lav", Name: "Latvian", NativeName: "Latviešu"},
+	{Code: "lt", Code3: "lit", Name: "Lithuanian", NativeName: "Lietuvių"},
+	{Code: "mk", Code3: "mkd", Name: "Macedonian", NativeName: "Македонски", Aliases: []string{"mac"}},
+	{Code: "ms", Code3: "msa", Name: "Malay", NativeName: "Bahasa Melayu", Aliases: []string{"may", "malaysian"}},
+	{Code: "ml", Code3: "mal", Name: "Malayalam", NativeName: "മലയാളം"},
+	{Code: "mt", Code3: "mlt", Name: "Maltese", NativeName: "Malti"},
+	{Code: "mi", Code3: "mri", Name: "Maori", NativeName: "Te Reo Māori", Aliases: []string{"mao", "māori"}},
+	{Code: "mr", Code3: "mar", Name: "Marathi", NativeName: "मराठी"},
+	{Code: "mn", Code3: "mon", Name: "Mongolian", NativeName: "Монгол"},
+	{Code: "ne", Code3: "nep", Name: "Nepali", NativeName: "नेपाली"},
+	{Code: "no", Code3: "nor", Name: "Norwegian", NativeName: "Norsk", Aliases: []string{"nb", "nob", "bokmal", "bokmål"}},
+	{Code: "ps", Code3: "pus", Name: "Pashto", NativeName: "پښتو", Aliases: []string{"pushto"}},
+	{Code: "fa", Code3: "fas", Name: "Persian", NativeName: "فارسی", Aliases: []string{"per", "farsi"}},
+	{Code: "pl", Code3: "pol", Name: "Polish", NativeName: "Polski"},
+	{Code: "pt", Code3: "por", Name: "Portuguese", NativeName: "Português"},
+	{Code: "pa", Code3: "pan", Name: "Punjabi", NativeName: "ਪੰਜਾਬੀ", Aliases: []string{"panjabi"}},
+	{Code: "ro", Code3: "ron", Name: "Romanian", NativeName: "Română", Aliases: []string{"rum", "moldovan"}},
+	{Code: "ru", Code3: "rus", Name: "Russian", NativeName: "Русский"},
+	{Code: "sr", Code3: "srp", Name: "Serbian", NativeName: "Српски"},
+	{Code: "si", Code3: "sin", Name: "Sinhala", NativeName: "සිංහල", Aliases: []string{"sinhalese"}},
+	{Code: "sk", Code3: "slk", Name: "Slovak", NativeName: "Slovenčina", Aliases: []string{"slo"}},
+	{Code: "sl", Code3: "slv", Name: "Slovenian", NativeName: "Slovenščina", Aliases: []string{"slovene"}},
+	{Code: "so", Code3: "som", Name: "Somali", NativeName: "Soomaali"},
+	{Code: "es", Code3: "spa", Name: "Spanish", NativeName: "Español", Aliases: []string{"castilian"}},
+	{Code: "sw", Code3: "swa", Name: "Swahili", NativeName: "Kiswahili"},
+	{Code: "sv", Code3: "swe", Name: "Swedish", NativeName: "Svenska"},
+	{Code: "tl", Code3: "tgl", Name: "Tagalog", NativeName: "Tagalog"},
+	{Code: "tg", Code3: "tgk", Name: "Tajik", NativeName: "Тоҷикӣ"},
+	{Code: "ta", Code3: "tam", Name: "Tamil", NativeName: "தமிழ்"},
+	{Code: "te", Code3: "tel", Name: "Telugu", NativeName: "తెలుగు"},
+	{Code: "th", Code3: "tha", Name: "Thai", NativeName: "ไทย"},
+	{Code: "tr", Code3: "tur", Name: "Turkish", NativeName: "Türkçe"},
+	{Code: "uk", Code3: "ukr", Name: "Ukrainian", NativeName: "Українська"},
+	{Code: "ur", Code3: "urd", Name: "Urdu", NativeName: "اردو"},
+	{Code: "uz", Code3: "uzb", Name: "Uzbek", NativeName: "Oʻzbekcha"},
+	{Code: "vi", Code3: "vie", Name: "Vietnamese", NativeName: "Tiếng Việt"},
+	{Code: "cy", Code3: "cym", Name: "Welsh", NativeName: "Cymraeg", Aliases: []string{"wel"}},
+	{Code: "xh", Code3: "xho", Name: "Xhosa", NativeName: "isiXhosa"},
+	{Code: "yi", Code3: "yid", Name: "Yiddish", NativeName: "ייִדיש"},
+	{Code: "yo", Code3: "yor", Name: "Yoruba", NativeName: "Yorùbá"},
+	{Code: "zu", Code3: "zul", Name: "Zulu", NativeName: "isiZulu"},
+}
@@ -0,0 +1,116 @@
+// Package languages holds the canonical catalogue of languages a user can
+// speak or learn, keyed by ISO 639 code.
+package languages
+
+import (
+	"slices"
+	"strings"
+)
+
+type Language struct {
+	Code       string   `json:"code"`  // ISO 639-1 when available, otherwise ISO 639-3
+	Code3      string   `json:"code3"` // ISO 639-3
+	Name       string   `json:"name"`  // English name
+	NativeName string   `json:"native_name"`
+	Aliases    []string `json:"aliases"`
+}
+
+// index maps every lowercased code, name and alias to its catalogue position.
+var index = map[string]int{}
+
+func init() {
+	for i, lng := range catalogue {
+		keys := append([]string{lng.Code, lng.Code3, lng.Name, lng.NativeName}, lng.Aliases...)
+		for _, key := range keys {
+			key = strings.ToLower(key)
+			if _, exist := index[key]; exist {
+				continue
+			}
+			index[key] = i
+		}
+	}
+}
+
+// All returns the whole catalogue ordered by English name.
+func All() []Language {
+	return slices.Clone(catalogue)
+}
+
+// Lookup finds a language by code, name or alias, case-insensitively. Locale
+// tags such as "en-US" or "pt_BR" resolve to their base language.
+func Lookup(s string) (Language, bool) {
+	key := strings.ToLower(strings.TrimSpace(s))
+	if i, ok := index[key]; ok {
+		return catalogue[i], true
+	}
+
+	if base, _, found := strings.Cut(strings.ReplaceAll(key, "_", "-"), "-"); found {
+		if i, ok := index[base]; ok {
+			return catalogue[i], true
+		}
+	}
+	return Language{}, false
+}
+
+// Normalize returns the canonical code of s.
+func Normalize(s string) (string, bool) {
+	lng, ok := Lookup(s)
+	return lng.Code, ok
+}
+
+// Valid reports whether s is the canonical code of a known language.
+func Valid(code string) bool {
+	lng, ok := Lookup(code)
+	return ok && lng.Code == code
+}
+
+// Search returns languages whose code, name or alias matches query. Exact
+// matches come first, then prefix matches, then substring matches.
+func Search(query string, limit int) []Language {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return All()[:min(limit, len(catalogue))]
+	}
+
+	const (
+		rankExact = iota
+		rankPrefix
+		rankContains
+		rankNone
+	)
+
+	type hit struct {
+		lng  Language
+		rank int
+	}
+
+	hits := []hit{}
+	for _, lng := range catalogue {
+		rank := rankNone
+		keys := append([]string{lng.Code, lng.Code3, lng.Name, lng.NativeName}, lng.Aliases...)
+		for _, key := range keys {
+			key = strings.ToLower(key)
+			switch {
+			case key == query:
+				rank = min(rank, rankExact)
+			case strings.HasPrefix(key, query):
+				rank = min(rank, rankPrefix)
+			case strings.Contains(key, query):
+				rank = min(rank, rankContains)
+			}
+		}
+		if rank != rankNone {
+			hits = append(hits, hit{lng: lng, rank: rank})
+		}
+	}
+
+	slices.SortStableFunc(hits, func(a, b hit) int {
+		return a.rank - b.rank
+	})
+
+	result := make([]Language, 0, min(limit, len(hits)))
+	for _, h := range hits[:min(limit, len(hits))] {
+		result = append(result, h.lng)
+	}
+	return result
+}
@@ -0,0 +1,284 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Conversation is a channel of the native chat, keyed by the same ChannelID
+// the chat providers use.
+type Conversation struct {
+	ID        bson.ObjectID      `bson:"_id,omitempty" json:"id"`
+	ChannelID string             `bson:"channel_id" json:"channel_id"`
+	MemberIDs []bson.ObjectID    `bson:"member_ids" json:"member_ids"`
+	CreatedBy bson.ObjectID      `bson:"created_by" json:"created_by"`
+	Frozen    bool               `bson:"frozen" json:"frozen"`
+	Reads     []ConversationRead `bson:"reads" json:"reads"`
+	// Nil until the first message
+	LastMessageAt *time.Time `bson:"last_message_at" json:"last_message_at"`
+	CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// ConversationRead is the read receipt of a member: the last message they
+// read, nil when they read none.
+type ConversationRead struct {
+	UserID    bson.ObjectID  `bson:"user_id" json:"user_id"`
+	MessageID *bson.ObjectID `bson:"message_id" json:"message_id"`
+	ReadAt    *time.Time     `bson:"read_at" json:"read_at"`
+}
+
+// HasMember reports whether userID is a member of the conversation.
+func (c *Conversation) HasMember(userID bson.ObjectID) bool {
+	return slices.Contains(c.MemberIDs, userID)
+}
+
+// ConversationWithFriend is a conversation with the public profile of the
+// other member and its last message.
+type ConversationWithFriend struct {
+	Conversation `bson:",inline"`
+	Friend       *User    `bson:"friend" json:"friend"`
+	LastMessage  *Message `bson:"last_message" json:"last_message"`
+}
+
+type ConversationModel struct {
+	logger  zerolog.Logger
+	coll    *mongo.Collection
+	cursors *CursorCodec
+}
+
+func NewConversationModel(coll *mongo.Collection, cursors *CursorCodec, logger zerolog.Logger) *ConversationModel {
+	/* ------------------------ unique channel id ----------------------- */
+	channelIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "channel_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	name, err := coll.Indexes().CreateOne(context.TODO(), channelIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating unique channel id index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* ----------------------- member conversations --------------------- */
+	memberIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "member_ids", Value: 1},
+			{Key: "updated_at", Value: -1},
+			{Key: "_id", Value: -1},
+		},
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), memberIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating member conversations index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	return &ConversationModel{
+		coll:    coll,
+		cursors: cursors,
+		logger:  logger,
+	}
+}
+
+// Upsert returns the conversation channelID, creating it with members when it
+// doesn't exist yet. An existing conversation is left as is.
+func (m *ConversationModel) Upsert(channelID string, createdBy bson.ObjectID, members []bson.ObjectID) (*Conversation, error) {
+	current := time.Now()
+
+	reads := make([]ConversationRead, 0, len(members))
+	for _, memberID := range members {
+		reads = append(reads, ConversationRead{UserID: memberID})
+	}
+
+	filter := bson.D{{Key: "channel_id", Value: channelID}}
+	update := bson.D{{Key: "$setOnInsert", Value: bson.D{
+		{Key: "member_ids", Value: members},
+		{Key: "created_by", Value: createdBy},
+		{Key: "frozen", Value: false},
+		{Key: "reads", Value: reads},
+		{Key: "last_message_at", Value: nil},
+		{Key: "created_at", Value: current},
+		{Key: "updated_at", Value: current},
+	}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var conversation Conversation
+	err := m.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&conversation)
+	if err != nil {
+		// Another request inserted it first
+		if mongo.IsDuplicateKeyError(err) {
+			return m.GetByChannelID(channelID)
+		}
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (m *ConversationModel) GetByChannelID(channelID string) (*Conversation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var conversation Conversation
+	err := m.coll.FindOne(ctx, bson.D{{Key: "channel_id", Value: channelID}}).Decode(&conversation)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &conversation, nil
+}
+
+type ConversationListParam struct {
+	CurrentUser *User
+	Page        int64
+	PageSize    int64
+	Cursor      string // switches to cursor pagination, Page is ignored
+}
+
+// GetAll returns the conversations of the current user, the most recently
+// active first.
+func (m *ConversationModel) GetAll(param ConversationListParam) ([]*ConversationWithFriend, Metadata, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "member_ids", Value: param.CurrentUser.ID}}}},
+	}
+
+	ks := keyset{listing: "conversations", keys: []sortKey{
+		{Field: "updated_at", Order: -1},
+		{Field: "_id", Order: -1},
+	}}
+
+	lookup := mongo.Pipeline{
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "users"},
+			{Key: "let", Value: bson.D{{Key: "memberIds", Value: "$member_ids"}}},
+			{Key: "pipeline", Value: mongo.Pipeline{
+				bson.D{{Key: "$match", Value: bson.D{
+					{Key: "$expr", Value: bson.D{{Key: "$and", Value: bson.A{
+						bson.D{{Key: "$in", Value: bson.A{"$_id", "$$memberIds"}}},
+						bson.D{{Key: "$ne", Value: bson.A{"$_id", param.CurrentUser.ID}}},
+					}}}},
+				}}},
+			}},
+			{Key: "as", Value: "friend"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$friend"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+		publicProfileStage(param.CurrentUser.ID, "friend"),
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "messages"},
+			{Key: "localField", Value: "channel_id"},
+			{Key: "foreignField", Value: "channel_id"},
+			{Key: "pipeline", Value: mongo.Pipeline{
+				bson.D{{Key: "$sort", Value: bson.D{
+					{Key: "created_at", Value: -1},
+					{Key: "_id", Value: -1},
+				}}},
+				bson.D{{Key: "$limit", Value: 1}},
+			}},
+			{Key: "as", Value: "last_message"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$last_message"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return aggregatePage[ConversationWithFriend](ctx, m.coll, m.cursors, pipeline, lookup, ks, pagination{
+		page:     param.Page,
+		pageSize: param.PageSize,
+		cursor:   param.Cursor,
+	})
+}
+
+// Touch moves the conversation channelID to the top of the listings of its
+// members, after a message was sent at.
+func (m *ConversationModel) Touch(channelID string, at time.Time) error {
+	filter := bson.D{{Key: "channel_id", Value: channelID}}
+	update := bson.D{{Key: "$max", Value: bson.D{
+		{Key: "updated_at", Value: at},
+		{Key: "last_message_at", Value: at},
+	}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// MarkRead moves the read receipt of userID in channelID forward to
+// messageID. It returns false when userID already read messageID or a later
+// message.
+func (m *ConversationModel) MarkRead(channelID string, userID, messageID bson.ObjectID, at time.Time) (bool, error) {
+	filter := bson.D{
+		{Key: "channel_id", Value: channelID},
+		{Key: "reads", Value: bson.D{{Key: "$elemMatch", Value: bson.D{
+			{Key: "user_id", Value: userID},
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: "message_id", Value: nil}},
+				bson.D{{Key: "message_id", Value: bson.D{{Key: "$lt", Value: messageID}}}},
+			}},
+		}}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "reads.$.message_id", Value: messageID},
+		{Key: "reads.$.read_at", Value: at},
+	}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// Freeze stops new messages in channelID, it returns ErrRecordNotFound when
+// there is no such conversation.
+func (m *ConversationModel) Freeze(channelID string) error {
+	return m.setFrozen(channelID, true)
+}
+
+// Unfreeze lets the members of channelID write again.
+func (m *ConversationModel) Unfreeze(channelID string) error {
+	return m.setFrozen(channelID, false)
+}
+
+func (m *ConversationModel) setFrozen(channelID string, frozen bool) error {
+	filter := bson.D{{Key: "channel_id", Value: channelID}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "frozen", Value: frozen}}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ucok-man/streamify/internal/signer"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	CursorDirectionNext = "next"
+	CursorDirectionPrev = "prev"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at a document of a listing by the values of its sort keys, so
+// the next page starts right after it however many documents were inserted.
+type Cursor struct {
+	Listing   string          `bson:"l"`
+	Direction string          `bson:"d"`
+	Values    []bson.RawValue `bson:"v"`
+	// RankedAt is when the first page was ranked, listings sorting on values
+	// computed from the time rank the next pages at the same time.
+	RankedAt time.Time `bson:"t,omitempty"`
+}
+
+// CursorCodec turns cursors into opaque signed strings and back.
+type CursorCodec struct {
+	signer *signer.Signer
+}
+
+func NewCursorCodec(secret string) *CursorCodec {
+	return &CursorCodec{signer: signer.New(secret, "streamify-cursor")}
+}
+
+func (c *CursorCodec) Encode(cursor Cursor) (string, error) {
+	payload, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return c.signer.Sign(payload), nil
+}
+
+func (c *CursorCodec) Decode(s string) (Cursor, error) {
+	payload, err := c.signer.Verify(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := bson.Unmarshal(payload, &cursor); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if cursor.Direction != CursorDirectionNext && cursor.Direction != CursorDirectionPrev {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+type sortKey struct {
+	Field string
+	Order int // 1 ascending, -1 descending
+}
+
+// keyset is the sort order of a listing, it must end with _id so every
+// document has a distinct position.
+type keyset struct {
+	listing string
+	keys    []sortKey
+}
+
+func (k keyset) sortStage(reverse bool) bson.D {
+	sort := bson.D{}
+	for _, key := range k.keys {
+		order := key.Order
+		if reverse {
+			order = -order
+		}
+		sort = append(sort, bson.E{Key: key.Field, Value: order})
+	}
+	return bson.D{{Key: "$sort", Value: sort}}
+}
+
+// matchStage keeps the documents positioned after the cursor, or before it for
+// a previous page cursor.
+func (k keyset) matchStage(cursor Cursor) bson.D {
+	branches := bson.A{}
+	for i, key := range k.keys {
+		branch := bson.A{}
+		for j := 0; j < i; j++ {
+			branch = append(branch, bson.D{{Key: "$eq", Value: bson.A{
+				"$" + k.keys[j].Field,
+				bson.D{{Key: "$literal", Value: cursor.Values[j]}},
+			}}})
+		}
+
+		operator := "$gt"
+		if (key.Order < 0) != (cursor.Direction == CursorDirectionPrev) {
+			operator = "$lt"
+		}
+		branch = append(branch, bson.D{{Key: operator, Value: bson.A{
+			"$" + key.Field,
+			bson.D{{Key: "$literal", Value: cursor.Values[i]}},
+		}}})
+
+		branches = append(branches, bson.D{{Key: "$and", Value: branch}})
+	}
+
+	return bson.D{{Key: "$match", Value: bson.D{
+		{Key: "$expr", Value: bson.D{{Key: "$or", Value: branches}}},
+	}}}
+}
+
+// cursor returns the cursor pointing at doc.
+func (k keyset) cursor(doc bson.Raw, direction string) Cursor {
+	values := make([]bson.RawValue, 0, len(k.keys))
+	for _, key := range k.keys {
+		value, err := doc.LookupErr(strings.Split(key.Field, ".")...)
+		if err != nil {
+			value = bson.RawValue{Type: bson.TypeNull}
+		}
+		values = append(values, value)
+	}
+	return Cursor{Listing: k.listing, Direction: direction, Values: values}
+}
+
+// pagination selects either the offset mode, with Page, or the cursor mode when
+// Cursor is set.
+type pagination struct {
+	page     int64
+	pageSize int64
+	cursor   string
+	rankedAt time.Time // carried over by the cursors, see Cursor.RankedAt
+}
+
+// aggregatePage sorts the documents produced by pipeline along ks and returns
+// the requested page decoded into T. The stages of lookup run on the page only.
+// In cursor mode the total isn't counted, Metadata only holds the cursors.
+func aggregatePage[T any](ctx context.Context, coll *mongo.Collection, codec *CursorCodec, pipeline mongo.Pipeline, lookup mongo.Pipeline, ks keyset, page pagination) ([]*T, Metadata, error) {
+	var docs []bson.Raw
+	var metadata Metadata
+	var hasPrev, hasNext bool
+
+	if page.cursor == "" {
+		dataPipeline := mongo.Pipeline{
+			bson.D{{Key: "$skip", Value: (page.page - 1) * page.pageSize}},
+			bson.D{{Key: "$limit", Value: page.pageSize}},
+		}
+		dataPipeline = append(dataPipeline, lookup...)
+
+		facetStage := bson.D{{Key: "$facet", Value: bson.M{
+			"data": dataPipeline,
+			"count": mongo.Pipeline{
+				bson.D{{Key: "$count", Value: "total"}},
+			},
+		}}}
+
+		pipeline = append(slices.Clone(pipeline), ks.sortStage(false), facetStage)
+
+		cursor, err := coll.Aggregate(ctx, pipeline)
+		if err != nil {
+			return []*T{}, Metadata{}, err
+		}
+		defer cursor.Close(ctx)
+
+		var rawResult []struct {
+			Data  []bson.Raw `bson:"data"`
+			Count []struct {
+				Total int64 `bson:"total"`
+			} `bson:"count"`
+		}
+		if err := cursor.All(ctx, &rawResult); err != nil {
+			return []*T{}, Metadata{}, err
+		}
+		if len(rawResult) == 0 || len(rawResult[0].Data) == 0 {
+			return []*T{}, Metadata{}, nil
+		}
+
+		var totalCount int64
+		if len(rawResult[0].Count) > 0 {
+			totalCount = rawResult[0].Count[0].Total
+		}
+
+		docs = rawResult[0].Data
+		metadata = calculateMetadata(totalCount, page.page, page.pageSize)
+		hasPrev = page.page > 1
+		hasNext = page.page*page.pageSize < totalCount
+	} else {
+		after, err := ks.decodeCursor(codec, page.cursor)
+		if err != nil {
+			return []*T{}, Metadata{}, err
+		}
+		reverse := after.Direction == CursorDirectionPrev
+
+		// Fetch one more document to know whether there is a page beyond
+		pipeline = append(slices.Clone(pipeline),
+			ks.matchStage(after),
+			ks.sortStage(reverse),
+			bson.D{{Key: "$limit", Value: page.pageSize + 1}},
+		)
+		pipeline = append(pipeline, lookup...)
+
+		cursor, err := coll.Aggregate(ctx, pipeline)
+		if err != nil {
+			return []*T{}, Metadata{}, err
+		}
+		defer cursor.Close(ctx)
+
+		if err := cursor.All(ctx, &docs); err != nil {
+			return []*T{}, Metadata{}, err
+		}
+
+		more := int64(len(docs)) > page.pageSize
+		if more {
+			docs = docs[:page.pageSize]
+		}
+		if reverse {
+			slices.Reverse(docs)
+			hasPrev, hasNext = more, true
+		} else {
+			hasPrev, hasNext = true, more
+		}
+		if len(docs) == 0 {
+			return []*T{}, Metadata{PageSize: page.pageSize}, nil
+		}
+		metadata = Metadata{PageSize: page.pageSize}
+	}
+
+	results := make([]*T, 0, len(docs))
+	for _, doc := range docs {
+		var result T
+		if err := bson.Unmarshal(doc, &result); err != nil {
+			return []*T{}, Metadata{}, err
+		}
+		results = append(results, &result)
+	}
+
+	var err error
+	if hasPrev {
+		prev := ks.cursor(docs[0], CursorDirectionPrev)
+		prev.RankedAt = page.rankedAt
+		metadata.PrevCursor, err = codec.Encode(prev)
+		if err != nil {
+			return []*T{}, Metadata{}, err
+		}
+	}
+	if hasNext {
+		next := ks.cursor(docs[len(docs)-1], CursorDirectionNext)
+		next.RankedAt = page.rankedAt
+		metadata.NextCursor, err = codec.Encode(next)
+		if err != nil {
+			return []*T{}, Metadata{}, err
+		}
+	}
+
+	return results, metadata, nil
+}
+
+// decodeCursor decodes s and checks it was issued for this listing.
+func (k keyset) decodeCursor(codec *CursorCodec, s string) (Cursor, error) {
+	cursor, err := codec.Decode(s)
+	if err != nil {
+		return Cursor{}, err
+	}
+	if cursor.Listing != k.listing || len(cursor.Values) != len(k.keys) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// rankedAt returns the time the listing of cursor s was ranked at, now when s
+// is empty since the first page is being ranked.
+func (k keyset) rankedAt(codec *CursorCodec, s string) (time.Time, error) {
+	if s == "" {
+		return time.Now(), nil
+	}
+	cursor, err := k.decodeCursor(codec, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if cursor.RankedAt.IsZero() {
+		return time.Now(), nil
+	}
+	return cursor.RankedAt, nil
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// DigestTimezones returns the timezones of the users getting the digest at
+// cadence. Users without a timezone are listed under the empty string.
+func (m *UserModel) DigestTimezones(cadence DigestCadence) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var timezones []string
+	err := m.coll.Distinct(ctx, "location.timezone", digestFilter(cadence)).Decode(&timezones)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(timezones, "") {
+		return timezones, nil
+	}
+	// Distinct skips the documents missing the field
+	count, err := m.coll.CountDocuments(ctx, append(digestFilter(cadence),
+		bson.E{Key: "location.timezone", Value: bson.D{{Key: "$exists", Value: false}}},
+	), options.Count().SetLimit(1))
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		timezones = append(timezones, "")
+	}
+	return timezones, nil
+}
+
+// DigestRecipients returns up to limit users of timezone getting the digest at
+// cadence, and not sent one since sentBefore, by id from afterID. The empty
+// timezone matches the users without one.
+func (m *UserModel) DigestRecipients(cadence DigestCadence, timezone string, sentBefore time.Time, afterID bson.ObjectID, limit int64) ([]*User, error) {
+	timezones := bson.A{timezone}
+	if timezone == "" {
+		timezones = append(timezones, nil)
+	}
+
+	filter := append(digestFilter(cadence),
+		bson.E{Key: "_id", Value: bson.D{{Key: "$gt", Value: afterID}}},
+		bson.E{Key: "location.timezone", Value: bson.D{{Key: "$in", Value: timezones}}},
+		bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "digest_sent_at", Value: bson.D{{Key: "$exists", Value: false}}}},
+			bson.D{{Key: "digest_sent_at", Value: bson.D{{Key: "$lt", Value: sentBefore}}}},
+		}},
+	)
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return []*User{}, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return []*User{}, err
+	}
+	for _, user := range users {
+		user.Privacy = user.Privacy.withDefaults()
+	}
+	return users, nil
+}
+
+// MarkDigestSent records that user got their digest, it covers what happened
+// until now.
+func (m *UserModel) MarkDigestSent(user *User) error {
+	current := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "digest_sent_at", Value: current}}}}
+
+	_, err := m.coll.UpdateByID(ctx, user.ID, update)
+	if err != nil {
+		return err
+	}
+	user.DigestSentAt = &current
+	return nil
+}
+
+// NewPartners returns up to limit users who signed up since and whose
+// languages complement those of user, best match first.
+func (m *UserModel) NewPartners(user *User, since time.Time, limit int64) ([]*User, error) {
+	matchStage := bson.D{{Key: "$match", Value: bson.D{{Key: "$and", Value: bson.A{
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$ne", Value: user.ID}}}},
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$nin", Value: user.FriendIDs}}}},
+		bson.D{{Key: "is_onboarded", Value: true}},
+		bson.D{{Key: "created_at", Value: bson.D{{Key: "$gte", Value: since}}}},
+		discoverableCondition(),
+	}}}}}
+
+	pipeline := mongo.Pipeline{matchStage}
+	pipeline = append(pipeline, languageMatchStages(user)...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$match", Value: bson.D{{Key: "match_score", Value: bson.D{{Key: "$gt", Value: MatchScoreNone}}}}}},
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "match_score", Value: -1},
+			{Key: "created_at", Value: -1},
+		}}},
+		bson.D{{Key: "$limit", Value: limit}},
+		publicProfileStage(user.ID, ""),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return []*User{}, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return []*User{}, err
+	}
+	return users, nil
+}
+
+func digestFilter(cadence DigestCadence) bson.D {
+	return bson.D{
+		{Key: "is_onboarded", Value: true},
+		{Key: "email_preferences.digest", Value: cadence},
+		{Key: "email_preferences.disabled", Value: bson.D{{Key: "$ne", Value: EmailDigest}}},
+	}
+}
+
+// PendingSenders returns the senders of up to limit requests recipientID
+// hasn't answered, newest first.
+func (m *FriendRequestModel) PendingSenders(recipientID bson.ObjectID, limit int64) ([]*User, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{
+			{Key: "recipient_id", Value: recipientID},
+			{Key: "status", Value: bson.D{{Key: "$ne", Value: FriendRequestStatusAccepted}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+	}
+	return aggregateUsers(m.coll, pipeline, recipientID, "sender_id")
+}
+
+// ActorsSince returns the actors of up to limit notifications of userID of
+// one of types created since, newest first.
+func (m *NotificationModel) ActorsSince(userID bson.ObjectID, types []NotificationType, since time.Time, limit int64) ([]*User, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{
+			{Key: "user_id", Value: userID},
+			{Key: "type", Value: bson.D{{Key: "$in", Value: types}}},
+			{Key: "created_at", Value: bson.D{{Key: "$gte", Value: since}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+	}
+	return aggregateUsers(m.coll, pipeline, userID, "actor_id")
+}
+
+// aggregateUsers runs pipeline on coll and returns the users referenced by
+// the field userField of its results, as seen by viewerID. Deleted users are
+// left out.
+func aggregateUsers(coll *mongo.Collection, pipeline mongo.Pipeline, viewerID bson.ObjectID, userField string) ([]*User, error) {
+	pipeline = append(pipeline,
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "users"},
+			{Key: "localField", Value: userField},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "user"},
+		}}},
+		bson.D{{Key: "$unwind", Value: "$user"}},
+		bson.D{{Key: "$replaceRoot", Value: bson.D{{Key: "newRoot", Value: "$user"}}}},
+		publicProfileStage(viewerID, ""),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return []*User{}, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return []*User{}, err
+	}
+	return users, nil
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type EmailJobStatus = string
+
+const (
+	EmailJobPending EmailJobStatus = "pending"
+	EmailJobSending EmailJobStatus = "sending"
+	EmailJobSent    EmailJobStatus = "sent"
+	EmailJobFailed  EmailJobStatus = "failed" // gave up after the last attempt
+)
+
+// emailJobRetention is how long sent and failed jobs are kept for inspection.
+const emailJobRetention = 30 * 24 * time.Hour
+
+// EmailJob is a rendered email waiting in the delivery queue.
+type EmailJob struct {
+	ID            bson.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID        bson.ObjectID     `bson:"user_id" json:"user_id"`
+	Event         EmailEvent        `bson:"event" json:"event"`
+	To            string            `bson:"to" json:"to"`
+	Subject       string            `bson:"subject" json:"subject"`
+	HTML          string            `bson:"html" json:"html"`
+	Text          string            `bson:"text" json:"text"`
+	Headers       map[string]string `bson:"headers" json:"headers"`
+	Status        EmailJobStatus    `bson:"status" json:"status"`
+	Attempts      int               `bson:"attempts" json:"attempts"`
+	LastError     string            `bson:"last_error" json:"last_error"`
+	NextAttemptAt time.Time         `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil   *time.Time        `bson:"locked_until" json:"locked_until"`
+	FinishedAt    *time.Time        `bson:"finished_at,omitempty" json:"finished_at"`
+	CreatedAt     time.Time         `bson:"created_at" json:"created_at"`
+}
+
+type EmailJobModel struct {
+	logger zerolog.Logger
+	coll   *mongo.Collection
+}
+
+func NewEmailJobModel(coll *mongo.Collection, logger zerolog.Logger) *EmailJobModel {
+	/* --------------------------- due jobs --------------------------- */
+	dueIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "status", Value: 1},
+			{Key: "next_attempt_at", Value: 1},
+		},
+	}
+
+	name, err := coll.Indexes().CreateOne(context.TODO(), dueIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating due email jobs index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* ------------------------ ttl finished at ----------------------- */
+	// Jobs still queued have no finished_at, they are never expired.
+	ttlIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "finished_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(emailJobRetention.Seconds())),
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), ttlIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating finished at ttl index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	return &EmailJobModel{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+// Enqueue queues job to be sent right away.
+func (m *EmailJobModel) Enqueue(job *EmailJob) (*EmailJob, error) {
+	job.Status = EmailJobPending
+	job.Attempts = 0
+	job.CreatedAt = time.Now()
+	job.NextAttemptAt = job.CreatedAt
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.coll.InsertOne(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	idrecord, ok := result.InsertedID.(bson.ObjectID)
+	if !ok {
+		return nil, errors.New("ID is not ObjectID, you should let mongo manage the ID")
+	}
+
+	job.ID = idrecord
+	return job, nil
+}
+
+// Claim locks the next due job for lease and counts the attempt, it returns
+// ErrRecordNotFound when no job is due. A job whose lease ran out, its worker
+// stopped while sending, is due again.
+func (m *EmailJobModel) Claim(lease time.Duration) (*EmailJob, error) {
+	current := time.Now()
+
+	filter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{
+			{Key: "status", Value: EmailJobPending},
+			{Key: "next_attempt_at", Value: bson.D{{Key: "$lte", Value: current}}},
+		},
+		bson.D{
+			{Key: "status", Value: EmailJobSending},
+			{Key: "locked_until", Value: bson.D{{Key: "$lt", Value: current}}},
+		},
+	}}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: EmailJobSending},
+			{Key: "locked_until", Value: current.Add(lease)},
+		}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var job EmailJob
+	err := m.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &job, nil
+}
+
+func (m *EmailJobModel) MarkSent(job *EmailJob) error {
+	current := time.Now()
+	job.Status = EmailJobSent
+	job.FinishedAt = &current
+	job.LockedUntil = nil
+
+	return m.save(job)
+}
+
+// Retry puts job back in the queue for nextAttemptAt, or gives up on it when
+// nextAttemptAt is nil.
+func (m *EmailJobModel) Retry(job *EmailJob, sendErr error, nextAttemptAt *time.Time) error {
+	job.LastError = sendErr.Error()
+	job.LockedUntil = nil
+	if nextAttemptAt == nil {
+		current := time.Now()
+		job.Status = EmailJobFailed
+		job.FinishedAt = &current
+	} else {
+		job.Status = EmailJobPending
+		job.NextAttemptAt = *nextAttemptAt
+	}
+
+	return m.save(job)
+}
+
+func (m *EmailJobModel) save(job *EmailJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	set := bson.D{
+		{Key: "status", Value: job.Status},
+		{Key: "last_error", Value: job.LastError},
+		{Key: "next_attempt_at", Value: job.NextAttemptAt},
+		{Key: "locked_until", Value: job.LockedUntil},
+	}
+	if job.FinishedAt != nil {
+		set = append(set, bson.E{Key: "finished_at", Value: job.FinishedAt})
+	}
+
+	_, err := m.coll.UpdateByID(ctx, job.ID, bson.D{{Key: "$set", Value: set}})
+	return err
+}
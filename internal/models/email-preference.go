@@ -0,0 +1,164 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// EmailEvent is a kind of email a user can opt out of.
+type EmailEvent = string
+
+const (
+	EmailFriendRequestReceived EmailEvent = "friend_request.received"
+	EmailFriendRequestAccepted EmailEvent = "friend_request.accepted"
+	EmailInviteRedeemed        EmailEvent = "invite.redeemed"
+	EmailInactivityReminder    EmailEvent = "inactivity.reminder"
+	EmailDigest                EmailEvent = "digest"
+)
+
+var EmailEvents = []EmailEvent{
+	EmailFriendRequestReceived,
+	EmailFriendRequestAccepted,
+	EmailInviteRedeemed,
+	EmailInactivityReminder,
+	EmailDigest,
+}
+
+// DigestCadence is how often a user gets the digest email.
+type DigestCadence = string
+
+const (
+	DigestOff    DigestCadence = "off"
+	DigestDaily  DigestCadence = "daily"
+	DigestWeekly DigestCadence = "weekly"
+)
+
+var DigestCadences = []DigestCadence{DigestOff, DigestDaily, DigestWeekly}
+
+// EmailPreferences lists the emails a user opted out of, so every email is
+// sent to users who never changed them. The digest is the exception, users
+// opt in by choosing its cadence.
+type EmailPreferences struct {
+	Locale   string        `bson:"locale" json:"locale"` // empty follows the native language
+	Disabled []EmailEvent  `bson:"disabled" json:"disabled"`
+	Digest   DigestCadence `bson:"digest,omitempty" json:"digest"` // empty is off
+}
+
+// Enabled reports whether the user accepts the emails of event.
+func (p EmailPreferences) Enabled(event EmailEvent) bool {
+	return !slices.Contains(p.Disabled, event)
+}
+
+// Cadence returns how often the user gets the digest, off when they
+// unsubscribed from it.
+func (p EmailPreferences) Cadence() DigestCadence {
+	if p.Digest == "" || !p.Enabled(EmailDigest) {
+		return DigestOff
+	}
+	return p.Digest
+}
+
+func (m *UserModel) SetEmailPreferences(user *User, preferences EmailPreferences) (*User, error) {
+	if preferences.Disabled == nil {
+		preferences.Disabled = []EmailEvent{}
+	}
+	user.EmailPreferences = preferences
+	user.UpdatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "email_preferences", Value: user.EmailPreferences},
+		{Key: "updated_at", Value: user.UpdatedAt},
+	}}}
+
+	_, err := m.coll.UpdateByID(ctx, user.ID, update)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DisableEmailEvent opts userID out of the emails of event, it returns
+// ErrRecordNotFound when the user doesn't exist.
+func (m *UserModel) DisableEmailEvent(userID bson.ObjectID, event EmailEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// Users who never saved their preferences have no disabled array yet
+	update := mongo.Pipeline{
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "email_preferences.disabled", Value: bson.D{{Key: "$setUnion", Value: bson.A{
+				bson.D{{Key: "$ifNull", Value: bson.A{"$email_preferences.disabled", bson.A{}}}},
+				bson.A{event},
+			}}}},
+			{Key: "updated_at", Value: time.Now()},
+		}}},
+	}
+
+	result, err := m.coll.UpdateByID(ctx, userID, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// InactiveToRemind returns up to limit onboarded users not active since
+// before, and not reminded since they were last active.
+func (m *UserModel) InactiveToRemind(before time.Time, limit int64) ([]*User, error) {
+	filter := bson.D{
+		{Key: "is_onboarded", Value: true},
+		{Key: "last_active_at", Value: bson.D{{Key: "$lt", Value: before}}},
+		{Key: "email_preferences.disabled", Value: bson.D{{Key: "$ne", Value: EmailInactivityReminder}}},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "inactivity_reminded_at", Value: bson.D{{Key: "$exists", Value: false}}}},
+			bson.D{{Key: "$expr", Value: bson.D{{Key: "$lt", Value: bson.A{"$inactivity_reminded_at", "$last_active_at"}}}}},
+		}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_active_at", Value: 1}}).SetLimit(limit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return []*User{}, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return []*User{}, err
+	}
+	for _, user := range users {
+		user.Privacy = user.Privacy.withDefaults()
+	}
+	return users, nil
+}
+
+// MarkInactivityReminded records that user was reminded, they aren't again
+// before they come back.
+func (m *UserModel) MarkInactivityReminded(user *User) error {
+	current := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "inactivity_reminded_at", Value: current}}}}
+
+	_, err := m.coll.UpdateByID(ctx, user.ID, update)
+	if err != nil {
+		return err
+	}
+	user.InactivityRemindedAt = &current
+	return nil
+}
@@ -27,14 +27,20 @@ type FriendRequest struct {
 }
 
 type FriendRequestModel struct {
-	logger zerolog.Logger
-	coll   *mongo.Collection
+	logger  zerolog.Logger
+	coll    *mongo.Collection
+	users   *mongo.Collection
+	search  SearchBackend
+	cursors *CursorCodec
 }
 
-func NewFriendRequestModel(coll *mongo.Collection, logger zerolog.Logger) *FriendRequestModel {
+func NewFriendRequestModel(coll *mongo.Collection, users *mongo.Collection, search SearchBackend, cursors *CursorCodec, logger zerolog.Logger) *FriendRequestModel {
 	return &FriendRequestModel{
-		coll:   coll,
-		logger: logger,
+		coll:    coll,
+		users:   users,
+		search:  search,
+		cursors: cursors,
+		logger:  logger,
 	}
 }
 
@@ -90,6 +96,60 @@ func (m *FriendRequestModel) CheckExisting(senderId, receipentId bson.ObjectID)
 	return friendRequest == nil, nil
 }
 
+// GetBetween returns the friend request between two users, whoever sent it.
+func (m *FriendRequestModel) GetBetween(userId, otherId bson.ObjectID) (*FriendRequest, error) {
+	filter := bson.D{{
+		Key: "$or", Value: bson.A{
+			bson.D{
+				{Key: "sender_id", Value: userId},
+				{Key: "recipient_id", Value: otherId},
+			},
+			bson.D{
+				{Key: "sender_id", Value: otherId},
+				{Key: "recipient_id", Value: userId},
+			},
+		},
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var friendRequest FriendRequest
+	err := m.coll.FindOne(ctx, filter).Decode(&friendRequest)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &friendRequest, nil
+}
+
+// DeleteBetween deletes the friend requests between two users, whoever sent
+// them, so they start over.
+func (m *FriendRequestModel) DeleteBetween(userId, otherId bson.ObjectID) error {
+	filter := bson.D{{
+		Key: "$or", Value: bson.A{
+			bson.D{
+				{Key: "sender_id", Value: userId},
+				{Key: "recipient_id", Value: otherId},
+			},
+			bson.D{
+				{Key: "sender_id", Value: otherId},
+				{Key: "recipient_id", Value: userId},
+			},
+		},
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.DeleteMany(ctx, filter)
+	return err
+}
+
 func (m *FriendRequestModel) Create(friendRequest *FriendRequest) (*FriendRequest, error) {
 	friendRequest.CreatedAt = time.Now()
 	friendRequest.UpdatedAt = time.Now()
@@ -132,109 +192,56 @@ func (m *FriendRequestModel) Update(friendRequest *FriendRequest) (*FriendReques
 	return friendRequest, nil
 }
 
+// CountPending returns the number of requests recipientID hasn't answered.
+// Requests without a status are pending too.
+func (m *FriendRequestModel) CountPending(recipientID bson.ObjectID) (int64, error) {
+	filter := bson.D{
+		{Key: "recipient_id", Value: recipientID},
+		{Key: "status", Value: bson.D{{Key: "$ne", Value: FriendRequestStatusAccepted}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.coll.CountDocuments(ctx, filter)
+}
+
+const (
+	FriendRequestSortNewest = "newest"
+	FriendRequestSortOldest = "oldest"
+	FriendRequestSortName   = "name"
+)
+
 type GetAllFromFriendRequestParam struct {
 	CurrentUserId bson.ObjectID
 	Status        string
 	Page          int64
 	PageSize      int64
 	SearchSender  string
+	Sort          string
+	Cursor        string // switches to cursor pagination, Page is ignored
 }
 
 func (m *FriendRequestModel) GetAllFromFriendRequest(param GetAllFromFriendRequestParam) ([]*FriendRequestWithSender, Metadata, error) {
-	// Step 1: Match by recipient_id and status
-	matchStage := bson.D{
-		{Key: "$match", Value: bson.M{
-			"recipient_id": param.CurrentUserId,
-		}},
-	}
-	if param.Status != "All" {
-		matchStage[0].Value.(bson.M)["status"] = FriendRequestStatus(param.Status)
-	}
-
-	// Step 2: Lookup sender user data
-	lookupStage := bson.D{{Key: "$lookup", Value: bson.M{
-		"from":         "users",
-		"localField":   "sender_id",
-		"foreignField": "_id",
-		"as":           "sender",
-	}}}
-
-	// Step 3: Unwind sender array
-	unwindStage := bson.D{{Key: "$unwind", Value: bson.M{
-		"path":                       "$sender",
-		"preserveNullAndEmptyArrays": false,
-	}}}
-
-	// Step 4: Optional search by sender.full_name using Atlas Search
-	var searchStage bson.D
-	if param.SearchSender != "" {
-		searchStage = bson.D{{Key: "$search", Value: bson.M{
-			"index": "user_full_name_index", // dynamic index
-			"text": bson.M{
-				"query": param.SearchSender,
-				"path":  "sender.full_name",
-			},
-		}}}
-	}
-
-	// Step 5–6: Pagination
-	skipStage := bson.D{{Key: "$skip", Value: (param.Page - 1) * param.PageSize}}
-	limitStage := bson.D{{Key: "$limit", Value: param.PageSize}}
-
-	// $facet untuk split antara hasil dan count
-	resultsPipeline := mongo.Pipeline{}
-	if searchStage != nil {
-		resultsPipeline = append(resultsPipeline, searchStage)
-	}
-	resultsPipeline = append(resultsPipeline, skipStage, limitStage)
-
-	countPipeline := mongo.Pipeline{}
-	if searchStage != nil {
-		countPipeline = append(countPipeline, searchStage)
-	}
-	countPipeline = append(countPipeline, bson.D{{Key: "$count", Value: "total"}})
-
-	facetStage := bson.D{{Key: "$facet", Value: bson.M{
-		"data":  resultsPipeline,
-		"count": countPipeline,
-	}}}
+	coll, pipeline, ks := m.listingPipeline(friendRequestListing{
+		currentUserId: param.CurrentUserId,
+		name:          "friend_requests_from",
+		ownerField:    "recipient_id",
+		otherField:    "sender_id",
+		otherAs:       "sender",
+		status:        param.Status,
+		search:        param.SearchSender,
+		sort:          param.Sort,
+	})
 
-	pipeline := mongo.Pipeline{matchStage, lookupStage, unwindStage, facetStage}
-
-	// Execute
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	cursor, err := m.coll.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, Metadata{}, err
-	}
-	defer cursor.Close(ctx)
-
-	var rawResult []struct {
-		Data  []*FriendRequestWithSender `bson:"data"`
-		Count []struct {
-			Total int64 `bson:"total"`
-		} `bson:"count"`
-	}
-
-	if err := cursor.All(ctx, &rawResult); err != nil {
-		return nil, Metadata{}, err
-	}
-
-	if len(rawResult) <= 0 {
-		return nil, Metadata{}, err
-	}
-	result := rawResult[0]
-
-	var totalCount int64
-	if len(result.Count) > 0 {
-		totalCount = result.Count[0].Total
-	}
-
-	metadata := calculateMetadata(totalCount, param.Page, param.PageSize)
-
-	return result.Data, metadata, nil
+	return aggregatePage[FriendRequestWithSender](ctx, coll, m.cursors, pipeline, nil, ks, pagination{
+		page:     param.Page,
+		pageSize: param.PageSize,
+		cursor:   param.Cursor,
+	})
 }
 
 type GetAllSendFriendRequestParam struct {
@@ -243,101 +250,120 @@ type GetAllSendFriendRequestParam struct {
 	Page            int64
 	PageSize        int64
 	SearchRecipient string
+	Sort            string
+	Cursor          string // switches to cursor pagination, Page is ignored
 }
 
 func (m *FriendRequestModel) GetAllSendFriendRequest(param GetAllSendFriendRequestParam) ([]*FriendRequestWithRecipient, Metadata, error) {
-	// Step 1: Match by sender_id and status
-	matchStage := bson.D{
-		{Key: "$match", Value: bson.M{
-			"sender_id": param.CurrentUserId,
-		}},
-	}
-	if param.Status != "All" {
-		matchStage[0].Value.(bson.M)["status"] = FriendRequestStatus(param.Status)
-	}
+	coll, pipeline, ks := m.listingPipeline(friendRequestListing{
+		currentUserId: param.CurrentUserId,
+		name:          "friend_requests_send",
+		ownerField:    "sender_id",
+		otherField:    "recipient_id",
+		otherAs:       "recipient",
+		status:        param.Status,
+		search:        param.SearchRecipient,
+		sort:          param.Sort,
+	})
 
-	// Step 2: Lookup recipient
-	lookupStage := bson.D{{Key: "$lookup", Value: bson.M{
-		"from":         "users",
-		"localField":   "recipient_id",
-		"foreignField": "_id",
-		"as":           "recipient",
-	}}}
-
-	// Step 3: Unwind recipient
-	unwindStage := bson.D{{Key: "$unwind", Value: bson.M{
-		"path":                       "$recipient",
-		"preserveNullAndEmptyArrays": false,
-	}}}
-
-	// Step 4: Optional search
-	var searchStage bson.D
-	if param.SearchRecipient != "" {
-		searchStage = bson.D{{Key: "$search", Value: bson.M{
-			"index": "user_full_name_index",
-			"text": bson.M{
-				"query": param.SearchRecipient,
-				"path":  "recipient.full_name",
-			},
-		}}}
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	// Pagination
-	skipStage := bson.D{{Key: "$skip", Value: (param.Page - 1) * param.PageSize}}
-	limitStage := bson.D{{Key: "$limit", Value: param.PageSize}}
+	return aggregatePage[FriendRequestWithRecipient](ctx, coll, m.cursors, pipeline, nil, ks, pagination{
+		page:     param.Page,
+		pageSize: param.PageSize,
+		cursor:   param.Cursor,
+	})
+}
 
-	// $facet untuk split antara hasil dan count
-	resultsPipeline := mongo.Pipeline{}
-	if searchStage != nil {
-		resultsPipeline = append(resultsPipeline, searchStage)
-	}
-	resultsPipeline = append(resultsPipeline, skipStage, limitStage)
+// friendRequestListing describes a listing of the friend requests owned by the
+// current user, joined with the user on the other side of the request.
+type friendRequestListing struct {
+	name          string
+	currentUserId bson.ObjectID
+	ownerField    string // field holding the current user id
+	otherField    string // field holding the other user id
+	otherAs       string // field the other user is joined into
+	status        string
+	search        string // full name of the other user
+	sort          string
+}
 
-	countPipeline := mongo.Pipeline{}
-	if searchStage != nil {
-		countPipeline = append(countPipeline, searchStage)
+// listingPipeline returns the collection to aggregate, the pipeline producing
+// friend requests with the other user joined and their sort order. Searching requires the search stage
+// to be first, so with a search the pipeline starts from the users matching the
+// name and joins their requests, otherwise it starts from the requests.
+func (m *FriendRequestModel) listingPipeline(listing friendRequestListing) (*mongo.Collection, mongo.Pipeline, keyset) {
+	requestMatch := bson.D{{Key: listing.ownerField, Value: listing.currentUserId}}
+	if listing.status != "All" {
+		requestMatch = append(requestMatch, bson.E{Key: "status", Value: FriendRequestStatus(listing.status)})
 	}
-	countPipeline = append(countPipeline, bson.D{{Key: "$count", Value: "total"}})
 
-	facetStage := bson.D{{Key: "$facet", Value: bson.M{
-		"data":  resultsPipeline,
-		"count": countPipeline,
-	}}}
+	var coll *mongo.Collection
+	var pipeline mongo.Pipeline
 
-	pipeline := mongo.Pipeline{matchStage, lookupStage, unwindStage, facetStage}
+	if listing.search != "" {
+		coll = m.users
 
-	// Execute
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+		// Step 1: Search the other users by full name
+		pipeline = append(pipeline, m.search.SearchStages(listing.search, "full_name")...)
 
-	cursor, err := m.coll.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, Metadata{}, err
-	}
-	defer cursor.Close(ctx)
+		// Step 2: Join their requests with the current user
+		lookupStage := bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: m.coll.Name()},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: listing.otherField},
+			{Key: "pipeline", Value: bson.A{
+				bson.D{{Key: "$match", Value: requestMatch}},
+			}},
+			{Key: "as", Value: "friend_request"},
+		}}}
+		unwindStage := bson.D{{Key: "$unwind", Value: "$friend_request"}}
+
+		// Step 3: Reshape into a request with the other user embedded
+		embedStage := bson.D{{Key: "$replaceRoot", Value: bson.D{
+			{Key: "newRoot", Value: bson.D{{Key: "$mergeObjects", Value: bson.A{
+				"$friend_request",
+				bson.D{{Key: listing.otherAs, Value: "$$ROOT"}},
+			}}}},
+		}}}
+		unsetStage := bson.D{{Key: "$unset", Value: listing.otherAs + ".friend_request"}}
 
-	var rawResult []struct {
-		Data  []*FriendRequestWithRecipient `bson:"data"`
-		Count []struct {
-			Total int64 `bson:"total"`
-		} `bson:"count"`
-	}
+		pipeline = append(pipeline, lookupStage, unwindStage, embedStage, unsetStage)
+	} else {
+		coll = m.coll
 
-	if err := cursor.All(ctx, &rawResult); err != nil {
-		return nil, Metadata{}, err
-	}
+		// Step 1: Match the requests of the current user
+		matchStage := bson.D{{Key: "$match", Value: requestMatch}}
 
-	if len(rawResult) <= 0 {
-		return nil, Metadata{}, err
-	}
-	result := rawResult[0]
+		// Step 2: Join the other user
+		lookupStage := bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: m.users.Name()},
+			{Key: "localField", Value: listing.otherField},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: listing.otherAs},
+		}}}
+		unwindStage := bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$" + listing.otherAs},
+			{Key: "preserveNullAndEmptyArrays", Value: false},
+		}}}
 
-	var totalCount int64
-	if len(result.Count) > 0 {
-		totalCount = result.Count[0].Total
+		pipeline = append(pipeline, matchStage, lookupStage, unwindStage)
 	}
 
-	metadata := calculateMetadata(totalCount, param.Page, param.PageSize)
+	// Step 4: Hide what the other user doesn't share
+	pipeline = append(pipeline, publicProfileStage(listing.currentUserId, listing.otherAs))
+
+	var keys []sortKey
+	switch listing.sort {
+	case FriendRequestSortOldest:
+		keys = []sortKey{{Field: "created_at", Order: 1}, {Field: "_id", Order: 1}}
+	case FriendRequestSortName:
+		keys = []sortKey{{Field: listing.otherAs + ".full_name", Order: 1}, {Field: "_id", Order: 1}}
+	default:
+		listing.sort = FriendRequestSortNewest
+		keys = []sortKey{{Field: "created_at", Order: -1}, {Field: "_id", Order: -1}}
+	}
 
-	return result.Data, metadata, nil
+	return coll, pipeline, keyset{listing: listing.name + ":" + listing.sort, keys: keys}
 }
@@ -0,0 +1,283 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// friendRequestFixture is the current user with requests to and from four
+// other users, two of them named Walker.
+type friendRequestFixture struct {
+	owner    *User
+	requests *FriendRequestModel
+}
+
+func newFriendRequestFixture(t *testing.T, db *mongo.Database, search SearchBackend) *friendRequestFixture {
+	t.Helper()
+
+	users := newTestUserModel(db, search)
+	fixture := &friendRequestFixture{
+		owner:    insertTestUser(t, users, "Olivia Owner", nil),
+		requests: newTestFriendRequestModel(db, search),
+	}
+
+	// Names in creation order, newest last
+	others := []struct {
+		name   string
+		status FriendRequestStatus
+	}{
+		{"Dave Walker", FriendRequestStatusPending},
+		{"Bob Stone", FriendRequestStatusAccepted},
+		{"Alice Walker", FriendRequestStatusPending},
+		{"Carol Jones", FriendRequestStatusPending},
+	}
+
+	createdAt := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	for _, other := range others {
+		user := insertTestUser(t, users, other.name, nil)
+
+		createdAt = createdAt.Add(time.Minute)
+		insertTestFriendRequest(t, db, user.ID, fixture.owner.ID, other.status, createdAt)
+		insertTestFriendRequest(t, db, fixture.owner.ID, user.ID, other.status, createdAt)
+	}
+
+	waitSearchable(t, db, search)
+	return fixture
+}
+
+func insertTestFriendRequest(t *testing.T, db *mongo.Database, senderID, recipientID bson.ObjectID, status FriendRequestStatus, createdAt time.Time) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("friend_request").InsertOne(ctx, FriendRequest{
+		SenderID:    senderID,
+		RecipientID: recipientID,
+		Status:      status,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	})
+	if err != nil {
+		t.Fatalf("inserting friend request: %v", err)
+	}
+}
+
+// friendRequestListingFunc lists a page of requests as their counterpart names.
+type friendRequestListingFunc func(f *friendRequestFixture, status, search, sort string, page, pageSize int64, cursor string) ([]string, Metadata, error)
+
+func listFromFriendRequests(f *friendRequestFixture, status, search, sort string, page, pageSize int64, cursor string) ([]string, Metadata, error) {
+	requests, metadata, err := f.requests.GetAllFromFriendRequest(GetAllFromFriendRequestParam{
+		CurrentUserId: f.owner.ID,
+		Status:        status,
+		SearchSender:  search,
+		Sort:          sort,
+		Page:          page,
+		PageSize:      pageSize,
+		Cursor:        cursor,
+	})
+	names := []string{}
+	for _, request := range requests {
+		if request.RecipientID != f.owner.ID || request.SenderID != request.Sender.ID {
+			return nil, metadata, errUnexpectedRequest
+		}
+		names = append(names, request.Sender.FullName)
+	}
+	return names, metadata, err
+}
+
+func listSendFriendRequests(f *friendRequestFixture, status, search, sort string, page, pageSize int64, cursor string) ([]string, Metadata, error) {
+	requests, metadata, err := f.requests.GetAllSendFriendRequest(GetAllSendFriendRequestParam{
+		CurrentUserId:   f.owner.ID,
+		Status:          status,
+		SearchRecipient: search,
+		Sort:            sort,
+		Page:            page,
+		PageSize:        pageSize,
+		Cursor:          cursor,
+	})
+	names := []string{}
+	for _, request := range requests {
+		if request.SenderID != f.owner.ID || request.RecipientID != request.Recipient.ID {
+			return nil, metadata, errUnexpectedRequest
+		}
+		names = append(names, request.Recipient.FullName)
+	}
+	return names, metadata, err
+}
+
+var errUnexpectedRequest = errors.New("request of another user or joined with the wrong user")
+
+func TestFriendRequestListings(t *testing.T) {
+	listings := []struct {
+		name string
+		list friendRequestListingFunc
+	}{
+		{"from", listFromFriendRequests},
+		{"send", listSendFriendRequests},
+	}
+
+	forEachSearchBackend(t, func(t *testing.T, db *mongo.Database, search SearchBackend) {
+		fixture := newFriendRequestFixture(t, db, search)
+
+		for _, listing := range listings {
+			t.Run(listing.name, func(t *testing.T) {
+				testFriendRequestListing(t, fixture, listing.list)
+			})
+		}
+	})
+}
+
+func testFriendRequestListing(t *testing.T, f *friendRequestFixture, list friendRequestListingFunc) {
+	tests := []struct {
+		name   string
+		status string
+		search string
+		sort   string
+		want   []string
+	}{
+		{
+			name:   "newest by default",
+			status: "All",
+			want:   []string{"Carol Jones", "Alice Walker", "Bob Stone", "Dave Walker"},
+		},
+		{
+			name:   "oldest",
+			status: "All",
+			sort:   FriendRequestSortOldest,
+			want:   []string{"Dave Walker", "Bob Stone", "Alice Walker", "Carol Jones"},
+		},
+		{
+			name:   "name",
+			status: "All",
+			sort:   FriendRequestSortName,
+			want:   []string{"Alice Walker", "Bob Stone", "Carol Jones", "Dave Walker"},
+		},
+		{
+			name:   "status",
+			status: FriendRequestStatusAccepted,
+			want:   []string{"Bob Stone"},
+		},
+		{
+			name:   "search",
+			status: "All",
+			search: "walker",
+			sort:   FriendRequestSortNewest,
+			want:   []string{"Alice Walker", "Dave Walker"},
+		},
+		{
+			name:   "search by name",
+			status: "All",
+			search: "walker",
+			sort:   FriendRequestSortName,
+			want:   []string{"Alice Walker", "Dave Walker"},
+		},
+		{
+			name:   "search and status",
+			status: FriendRequestStatusPending,
+			search: "stone",
+			want:   []string{},
+		},
+		{
+			name:   "search without match",
+			status: "All",
+			search: "nobody",
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, metadata, err := list(f, tt.status, tt.search, tt.sort, 1, 10, "")
+			if err != nil {
+				t.Fatalf("listing: %v", err)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("got %v, want %v", names, tt.want)
+			}
+			if len(tt.want) > 0 && metadata.TotalRecords != int64(len(tt.want)) {
+				t.Errorf("got %d total records, want %d", metadata.TotalRecords, len(tt.want))
+			}
+		})
+
+		t.Run(tt.name+" by cursor", func(t *testing.T) {
+			testFriendRequestCursor(t, f, list, tt.status, tt.search, tt.sort, tt.want)
+		})
+	}
+
+	t.Run("cursor of another listing", func(t *testing.T) {
+		_, metadata, err := list(f, "All", "", FriendRequestSortNewest, 1, 1, "")
+		if err != nil {
+			t.Fatalf("listing: %v", err)
+		}
+		null := bson.RawValue{Type: bson.TypeNull}
+		cursor, err := f.requests.cursors.Encode(Cursor{Listing: "friends", Direction: CursorDirectionNext, Values: []bson.RawValue{null, null}})
+		if err != nil {
+			t.Fatalf("encoding cursor: %v", err)
+		}
+		if metadata.NextCursor == "" {
+			t.Fatal("no next cursor")
+		}
+		if _, _, err := list(f, "All", "", FriendRequestSortName, 0, 1, metadata.NextCursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor of another sort: got %v, want %v", err, ErrInvalidCursor)
+		}
+		if _, _, err := list(f, "All", "", FriendRequestSortNewest, 0, 1, cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor of another listing: got %v, want %v", err, ErrInvalidCursor)
+		}
+	})
+}
+
+// testFriendRequestCursor walks the listing a request at a time with the next
+// cursors, then back with the previous cursors.
+func testFriendRequestCursor(t *testing.T, f *friendRequestFixture, list friendRequestListingFunc, status, search, sort string, want []string) {
+	const pageSize = 1
+
+	names, metadata, err := list(f, status, search, sort, 1, pageSize, "")
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	forward := slices.Clone(names)
+	last := metadata
+
+	for metadata.NextCursor != "" {
+		if len(forward) > len(want) {
+			t.Fatalf("more pages than requests: %v", forward)
+		}
+		names, metadata, err = list(f, status, search, sort, 0, pageSize, metadata.NextCursor)
+		if err != nil {
+			t.Fatalf("next page: %v", err)
+		}
+		forward = append(forward, names...)
+		if len(names) > 0 {
+			last = metadata
+		}
+	}
+	if !slices.Equal(forward, want) {
+		t.Fatalf("next cursors: got %v, want %v", forward, want)
+	}
+	if len(want) < 2 {
+		return
+	}
+
+	backward := []string{}
+	metadata = last
+	for metadata.PrevCursor != "" {
+		if len(backward) > len(want) {
+			t.Fatalf("more pages than requests: %v", backward)
+		}
+		names, metadata, err = list(f, status, search, sort, 0, pageSize, metadata.PrevCursor)
+		if err != nil {
+			t.Fatalf("previous page: %v", err)
+		}
+		backward = append(slices.Clone(names), backward...)
+	}
+	if wantBackward := want[:len(want)-1]; !slices.Equal(backward, wantBackward) {
+		t.Errorf("previous cursors: got %v, want %v", backward, wantBackward)
+	}
+}
@@ -0,0 +1,294 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MaxActiveInvites is the number of invites a user can hold at once.
+const MaxActiveInvites = 20
+
+// Invite is a personal link letting whoever holds it befriend the inviter
+// without searching for them.
+type Invite struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	Token     string        `bson:"token" json:"token"`
+	InviterID bson.ObjectID `bson:"inviter_id" json:"inviter_id"`
+	MaxUses   int           `bson:"max_uses" json:"max_uses"` // 0 for unlimited
+	Uses      int           `bson:"uses" json:"uses"`
+	ExpiresAt *time.Time    `bson:"expires_at" json:"expires_at"` // nil never expires
+	RevokedAt *time.Time    `bson:"revoked_at" json:"revoked_at"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// Usable reports whether the invite can still be redeemed.
+func (i *Invite) Usable() bool {
+	if i.RevokedAt != nil {
+		return false
+	}
+	if i.ExpiresAt != nil && !i.ExpiresAt.After(time.Now()) {
+		return false
+	}
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
+
+type InviteModel struct {
+	logger zerolog.Logger
+	coll   *mongo.Collection
+}
+
+func NewInviteModel(coll *mongo.Collection, logger zerolog.Logger) *InviteModel {
+	/* -------------------------- unique token ------------------------- */
+	uniqueTokenIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "token", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	name, err := coll.Indexes().CreateOne(context.TODO(), uniqueTokenIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating unique token index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* ------------------------- inviter invites ----------------------- */
+	inviterIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "inviter_id", Value: 1},
+			{Key: "created_at", Value: -1},
+		},
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), inviterIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating inviter index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	return &InviteModel{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+// newInviteToken returns a random url safe token.
+func newInviteToken() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (m *InviteModel) GetById(id bson.ObjectID) (*Invite, error) {
+	return m.getOne(bson.D{{Key: "_id", Value: id}})
+}
+
+func (m *InviteModel) GetByToken(token string) (*Invite, error) {
+	return m.getOne(bson.D{{Key: "token", Value: token}})
+}
+
+func (m *InviteModel) getOne(filter bson.D) (*Invite, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var invite Invite
+	err := m.coll.FindOne(ctx, filter).Decode(&invite)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &invite, nil
+}
+
+// GetAllByInviter returns the invites of inviterID not revoked yet, newest first.
+func (m *InviteModel) GetAllByInviter(inviterID bson.ObjectID) ([]*Invite, error) {
+	filter := bson.D{
+		{Key: "inviter_id", Value: inviterID},
+		{Key: "revoked_at", Value: nil},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(MaxActiveInvites)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	cursor, err := m.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return []*Invite{}, err
+	}
+	defer cursor.Close(ctx)
+
+	invites := []*Invite{}
+	if err := cursor.All(ctx, &invites); err != nil {
+		return []*Invite{}, err
+	}
+	return invites, nil
+}
+
+// CountActive returns the number of invites of inviterID not revoked yet.
+func (m *InviteModel) CountActive(inviterID bson.ObjectID) (int64, error) {
+	filter := bson.D{
+		{Key: "inviter_id", Value: inviterID},
+		{Key: "revoked_at", Value: nil},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.coll.CountDocuments(ctx, filter)
+}
+
+func (m *InviteModel) Create(invite *Invite) (*Invite, error) {
+	token, err := newInviteToken()
+	if err != nil {
+		return nil, err
+	}
+	invite.Token = token
+	invite.Uses = 0
+	invite.CreatedAt = time.Now()
+	invite.UpdatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.coll.InsertOne(ctx, invite)
+	if err != nil {
+		return nil, err
+	}
+
+	idrecord, ok := result.InsertedID.(bson.ObjectID)
+	if !ok {
+		return nil, errors.New("ID is not ObjectID, you should let mongo manage the ID")
+	}
+
+	invite.ID = idrecord
+	return invite, nil
+}
+
+// Update saves the expiry and usage limit of invite.
+func (m *InviteModel) Update(invite *Invite) (*Invite, error) {
+	invite.UpdatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "max_uses", Value: invite.MaxUses},
+			{Key: "expires_at", Value: invite.ExpiresAt},
+			{Key: "updated_at", Value: invite.UpdatedAt},
+		}},
+	}
+
+	_, err := m.coll.UpdateByID(ctx, invite.ID, update)
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// Rotate replaces the token of invite, so links shared so far stop working,
+// and resets its usage count.
+func (m *InviteModel) Rotate(invite *Invite) (*Invite, error) {
+	token, err := newInviteToken()
+	if err != nil {
+		return nil, err
+	}
+	invite.Token = token
+	invite.Uses = 0
+	invite.UpdatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "token", Value: invite.Token},
+			{Key: "uses", Value: invite.Uses},
+			{Key: "updated_at", Value: invite.UpdatedAt},
+		}},
+	}
+
+	_, err = m.coll.UpdateByID(ctx, invite.ID, update)
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+func (m *InviteModel) Revoke(invite *Invite) error {
+	current := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "revoked_at", Value: current},
+			{Key: "updated_at", Value: current},
+		}},
+	}
+
+	_, err := m.coll.UpdateByID(ctx, invite.ID, update)
+	if err != nil {
+		return err
+	}
+
+	invite.RevokedAt = &current
+	invite.UpdatedAt = current
+	return nil
+}
+
+// Use counts one redemption of the invite with token. The checks and the
+// increment are a single update so concurrent redemptions can't exceed the
+// usage limit. ErrRecordNotFound is returned when the invite is not usable.
+func (m *InviteModel) Use(token string) (*Invite, error) {
+	current := time.Now()
+
+	filter := bson.D{
+		{Key: "token", Value: token},
+		{Key: "revoked_at", Value: nil},
+		{Key: "$and", Value: bson.A{
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "expires_at", Value: nil}},
+				bson.D{{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: current}}}},
+			}}},
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "max_uses", Value: 0}},
+				bson.D{{Key: "$expr", Value: bson.D{{Key: "$lt", Value: bson.A{"$uses", "$max_uses"}}}}},
+			}}},
+		}},
+	}
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "uses", Value: 1}}},
+		{Key: "$set", Value: bson.D{{Key: "updated_at", Value: current}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var invite Invite
+	err := m.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&invite)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &invite, nil
+}
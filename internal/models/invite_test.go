@@ -0,0 +1,178 @@
+package models
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func newTestInviteModel(db *mongo.Database) *InviteModel {
+	return NewInviteModel(db.Collection("invites"), zerolog.Nop())
+}
+
+func createTestInvite(t *testing.T, invites *InviteModel, edit func(invite *Invite)) *Invite {
+	t.Helper()
+
+	invite := &Invite{InviterID: bson.NewObjectID()}
+	if edit != nil {
+		edit(invite)
+	}
+	invite, err := invites.Create(invite)
+	if err != nil {
+		t.Fatalf("creating invite: %v", err)
+	}
+	return invite
+}
+
+func TestInviteUsable(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		invite Invite
+		want   bool
+	}{
+		{"unlimited", Invite{Uses: 100}, true},
+		{"under limit", Invite{MaxUses: 2, Uses: 1}, true},
+		{"used up", Invite{MaxUses: 2, Uses: 2}, false},
+		{"not expired", Invite{ExpiresAt: &future}, true},
+		{"expired", Invite{ExpiresAt: &past}, false},
+		{"revoked", Invite{RevokedAt: &past}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.invite.Usable(); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInviteUseLimit(t *testing.T) {
+	db := testDatabase(t)
+	invites := newTestInviteModel(db)
+
+	past := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name     string
+		edit     func(invite *Invite)
+		wantUses int // successful uses out of three
+	}{
+		{"unlimited", nil, 3},
+		{"limited", func(invite *Invite) { invite.MaxUses = 2 }, 2},
+		{"expired", func(invite *Invite) { invite.ExpiresAt = &past }, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invite := createTestInvite(t, invites, tt.edit)
+
+			uses := 0
+			for range 3 {
+				used, err := invites.Use(invite.Token)
+				if errors.Is(err, ErrRecordNotFound) {
+					continue
+				}
+				if err != nil {
+					t.Fatalf("using invite: %v", err)
+				}
+				uses++
+				if used.Uses != uses {
+					t.Errorf("got uses %d, want %d", used.Uses, uses)
+				}
+			}
+			if uses != tt.wantUses {
+				t.Errorf("got %d uses, want %d", uses, tt.wantUses)
+			}
+		})
+	}
+
+	t.Run("revoked", func(t *testing.T) {
+		invite := createTestInvite(t, invites, nil)
+		if err := invites.Revoke(invite); err != nil {
+			t.Fatalf("revoking invite: %v", err)
+		}
+		if _, err := invites.Use(invite.Token); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("got %v, want %v", err, ErrRecordNotFound)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		const maxUses = 3
+		invite := createTestInvite(t, invites, func(invite *Invite) { invite.MaxUses = maxUses })
+
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			uses int
+		)
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := invites.Use(invite.Token); err == nil {
+					mu.Lock()
+					uses++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if uses != maxUses {
+			t.Errorf("got %d uses, want %d", uses, maxUses)
+		}
+		stored, err := invites.GetById(invite.ID)
+		if err != nil {
+			t.Fatalf("getting invite: %v", err)
+		}
+		if stored.Uses != maxUses {
+			t.Errorf("got stored uses %d, want %d", stored.Uses, maxUses)
+		}
+	})
+}
+
+func TestInviteRotate(t *testing.T) {
+	db := testDatabase(t)
+	invites := newTestInviteModel(db)
+
+	invite := createTestInvite(t, invites, func(invite *Invite) { invite.MaxUses = 1 })
+	oldToken := invite.Token
+	if _, err := invites.Use(oldToken); err != nil {
+		t.Fatalf("using invite: %v", err)
+	}
+
+	rotated, err := invites.Rotate(invite)
+	if err != nil {
+		t.Fatalf("rotating invite: %v", err)
+	}
+	if rotated.Token == oldToken {
+		t.Fatal("rotating kept the token")
+	}
+
+	if _, err := invites.GetByToken(oldToken); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("getting by the old token: got %v, want %v", err, ErrRecordNotFound)
+	}
+	if _, err := invites.Use(oldToken); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("using the old token: got %v, want %v", err, ErrRecordNotFound)
+	}
+
+	// The usage count starts over with the new token
+	used, err := invites.Use(rotated.Token)
+	if err != nil {
+		t.Fatalf("using the new token: %v", err)
+	}
+	if used.ID != invite.ID || used.Uses != 1 {
+		t.Errorf("got invite %s with %d uses, want %s with 1", used.ID.Hex(), used.Uses, invite.ID.Hex())
+	}
+	if _, err := invites.Use(rotated.Token); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("using the new token past its limit: got %v, want %v", err, ErrRecordNotFound)
+	}
+}
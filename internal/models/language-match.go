@@ -0,0 +1,85 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type MatchReason = string
+
+const (
+	// Their native language is what I learn and they learn my native language.
+	MatchReasonMutualExchange MatchReason = "mutual_exchange"
+	// Their native language is what I learn.
+	MatchReasonSpeaksYourTarget MatchReason = "speaks_your_target_language"
+	// They learn my native language.
+	MatchReasonLearnsYourNative MatchReason = "learns_your_native_language"
+	MatchReasonNone             MatchReason = "none"
+)
+
+const (
+	MatchScoreMutualExchange   = 100
+	MatchScoreSpeaksYourTarget = 60
+	MatchScoreLearnsYourNative = 40
+	MatchScoreNone             = 0
+)
+
+// languageMatchStages scores every document on how well its languages
+// complement the languages of user, setting match_score and match_reason.
+func languageMatchStages(user *User) mongo.Pipeline {
+	// Codes of the document languages spoken natively and being learned
+	theirNative := bson.D{{Key: "$map", Value: bson.D{
+		{Key: "input", Value: bson.D{{Key: "$filter", Value: bson.D{
+			{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$languages", bson.A{}}}}},
+			{Key: "as", Value: "lng"},
+			{Key: "cond", Value: bson.D{{Key: "$eq", Value: bson.A{"$$lng.level", LanguageLevelNative}}}},
+		}}}},
+		{Key: "as", Value: "lng"},
+		{Key: "in", Value: "$$lng.code"},
+	}}}
+	theirLearning := bson.D{{Key: "$map", Value: bson.D{
+		{Key: "input", Value: bson.D{{Key: "$filter", Value: bson.D{
+			{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$languages", bson.A{}}}}},
+			{Key: "as", Value: "lng"},
+			{Key: "cond", Value: bson.D{{Key: "$eq", Value: bson.A{"$$lng.learning", true}}}},
+		}}}},
+		{Key: "as", Value: "lng"},
+		{Key: "in", Value: "$$lng.code"},
+	}}}
+
+	theySpeakMyTarget := bson.D{{Key: "$gt", Value: bson.A{
+		bson.D{{Key: "$size", Value: bson.D{{Key: "$setIntersection", Value: bson.A{theirNative, user.LearningLanguages()}}}}},
+		0,
+	}}}
+	theyLearnMyNative := bson.D{{Key: "$gt", Value: bson.A{
+		bson.D{{Key: "$size", Value: bson.D{{Key: "$setIntersection", Value: bson.A{theirLearning, user.NativeLanguages()}}}}},
+		0,
+	}}}
+
+	languageMatchStage := bson.D{{Key: "$addFields", Value: bson.D{
+		{Key: "language_match", Value: bson.D{{Key: "$switch", Value: bson.D{
+			{Key: "branches", Value: bson.A{
+				bson.D{
+					{Key: "case", Value: bson.D{{Key: "$and", Value: bson.A{theySpeakMyTarget, theyLearnMyNative}}}},
+					{Key: "then", Value: bson.D{{Key: "score", Value: MatchScoreMutualExchange}, {Key: "reason", Value: MatchReasonMutualExchange}}},
+				},
+				bson.D{
+					{Key: "case", Value: theySpeakMyTarget},
+					{Key: "then", Value: bson.D{{Key: "score", Value: MatchScoreSpeaksYourTarget}, {Key: "reason", Value: MatchReasonSpeaksYourTarget}}},
+				},
+				bson.D{
+					{Key: "case", Value: theyLearnMyNative},
+					{Key: "then", Value: bson.D{{Key: "score", Value: MatchScoreLearnsYourNative}, {Key: "reason", Value: MatchReasonLearnsYourNative}}},
+				},
+			}},
+			{Key: "default", Value: bson.D{{Key: "score", Value: MatchScoreNone}, {Key: "reason", Value: MatchReasonNone}}},
+		}}}},
+	}}}
+
+	matchScoreStage := bson.D{{Key: "$addFields", Value: bson.D{
+		{Key: "match_score", Value: "$language_match.score"},
+		{Key: "match_reason", Value: "$language_match.reason"},
+	}}}
+
+	return mongo.Pipeline{languageMatchStage, matchScoreStage}
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/ucok-man/streamify/internal/geo"
+)
+
+type Location struct {
+	City        string    `bson:"city" json:"city"`
+	CountryCode string    `bson:"country_code" json:"country_code"` // ISO 3166-1 alpha-2
+	Point       *GeoPoint `bson:"point,omitempty" json:"point,omitempty"`
+	Timezone    string    `bson:"timezone" json:"timezone"` // IANA name
+}
+
+// GeoPoint is a GeoJSON point, indexed with 2dsphere.
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"` // [longitude, latitude]
+}
+
+func NewLocation(place *geo.Place) Location {
+	return Location{
+		City:        place.City,
+		CountryCode: place.CountryCode,
+		Point: &GeoPoint{
+			Type:        "Point",
+			Coordinates: []float64{place.Longitude, place.Latitude},
+		},
+		Timezone: place.Timezone,
+	}
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Message is a message of the native chat. A deleted message keeps its place
+// in the history without its text.
+type Message struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	ChannelID string        `bson:"channel_id" json:"channel_id"`
+	SenderID  bson.ObjectID `bson:"sender_id" json:"sender_id"`
+	Text      string        `bson:"text" json:"text"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	EditedAt  *time.Time    `bson:"edited_at" json:"edited_at"`
+	DeletedAt *time.Time    `bson:"deleted_at" json:"deleted_at"`
+}
+
+type MessageModel struct {
+	logger  zerolog.Logger
+	coll    *mongo.Collection
+	cursors *CursorCodec
+}
+
+func NewMessageModel(coll *mongo.Collection, cursors *CursorCodec, logger zerolog.Logger) *MessageModel {
+	/* ------------------------- channel history ------------------------ */
+	historyIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "channel_id", Value: 1},
+			{Key: "created_at", Value: -1},
+			{Key: "_id", Value: -1},
+		},
+	}
+
+	name, err := coll.Indexes().CreateOne(context.TODO(), historyIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating channel history index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	return &MessageModel{
+		coll:    coll,
+		cursors: cursors,
+		logger:  logger,
+	}
+}
+
+// Insert saves message, its time is the server's whatever the client sent.
+func (m *MessageModel) Insert(message *Message) (*Message, error) {
+	message.CreatedAt = time.Now()
+	message.EditedAt = nil
+	message.DeletedAt = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.coll.InsertOne(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	idrecord, ok := result.InsertedID.(bson.ObjectID)
+	if !ok {
+		return nil, errors.New("ID is not ObjectID, you should let mongo manage the ID")
+	}
+
+	message.ID = idrecord
+	return message, nil
+}
+
+// GetByID returns the message id of channelID, deleted or not.
+func (m *MessageModel) GetByID(channelID string, id bson.ObjectID) (*Message, error) {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "channel_id", Value: channelID},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var message Message
+	err := m.coll.FindOne(ctx, filter).Decode(&message)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &message, nil
+}
+
+// UpdateText replaces the text of message, it returns ErrRecordNotFound when
+// the message was deleted meanwhile.
+func (m *MessageModel) UpdateText(message *Message, text string) (*Message, error) {
+	filter := bson.D{
+		{Key: "_id", Value: message.ID},
+		{Key: "deleted_at", Value: nil},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "text", Value: text},
+		{Key: "edited_at", Value: time.Now()},
+	}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var updated Message
+	err := m.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &updated, nil
+}
+
+// Delete clears the text of message, it returns ErrRecordNotFound when it was
+// already deleted.
+func (m *MessageModel) Delete(message *Message) (*Message, error) {
+	filter := bson.D{
+		{Key: "_id", Value: message.ID},
+		{Key: "deleted_at", Value: nil},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "text", Value: ""},
+		{Key: "deleted_at", Value: time.Now()},
+	}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var deleted Message
+	err := m.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&deleted)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &deleted, nil
+}
+
+type MessageListParam struct {
+	ChannelID string
+	Page      int64
+	PageSize  int64
+	Cursor    string // switches to cursor pagination, Page is ignored
+}
+
+// GetAll returns the history of a channel, newest first: the next pages go
+// back in time.
+func (m *MessageModel) GetAll(param MessageListParam) ([]*Message, Metadata, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "channel_id", Value: param.ChannelID}}}},
+	}
+
+	// The channel is part of the listing so a cursor can't page another one
+	ks := keyset{listing: "messages:" + param.ChannelID, keys: []sortKey{
+		{Field: "created_at", Order: -1},
+		{Field: "_id", Order: -1},
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return aggregatePage[Message](ctx, m.coll, m.cursors, pipeline, nil, ks, pagination{
+		page:     param.Page,
+		pageSize: param.PageSize,
+		cursor:   param.Cursor,
+	})
+}
@@ -0,0 +1,237 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func newTestMessageModel(db *mongo.Database) *MessageModel {
+	return NewMessageModel(db.Collection("messages"), NewCursorCodec("test"), zerolog.Nop())
+}
+
+func newTestConversationModel(db *mongo.Database) *ConversationModel {
+	return NewConversationModel(db.Collection("conversations"), NewCursorCodec("test"), zerolog.Nop())
+}
+
+func insertTestMessage(t *testing.T, messages *MessageModel, channelID string, senderID bson.ObjectID, text string) *Message {
+	t.Helper()
+
+	message, err := messages.Insert(&Message{ChannelID: channelID, SenderID: senderID, Text: text})
+	if err != nil {
+		t.Fatalf("inserting message %q: %v", text, err)
+	}
+	return message
+}
+
+func TestMessageHistory(t *testing.T) {
+	db := testDatabase(t)
+	messages := newTestMessageModel(db)
+
+	senderID := bson.NewObjectID()
+	texts := []string{"one", "two", "three", "four", "five"}
+	for _, text := range texts {
+		insertTestMessage(t, messages, "channel", senderID, text)
+	}
+	insertTestMessage(t, messages, "other", senderID, "elsewhere")
+
+	// Newest first, the ties on the time are broken by id
+	want := slices.Clone(texts)
+	slices.Reverse(want)
+
+	history, metadata, err := messages.GetAll(MessageListParam{ChannelID: "channel", Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("listing messages: %v", err)
+	}
+	if got := messageTexts(history); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if metadata.TotalRecords != int64(len(texts)) {
+		t.Errorf("got %d records, want %d", metadata.TotalRecords, len(texts))
+	}
+
+	// Going back in time a page at a time
+	got := []string{}
+	cursor, pages := "", 0
+	var secondPrev string
+	for {
+		page, metadata, err := messages.GetAll(MessageListParam{ChannelID: "channel", PageSize: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("listing page %d: %v", pages+1, err)
+		}
+		got = append(got, messageTexts(page)...)
+		pages++
+		if pages == 2 {
+			secondPrev = metadata.PrevCursor
+		}
+		if metadata.NextCursor == "" {
+			break
+		}
+		cursor = metadata.NextCursor
+	}
+	if !slices.Equal(got, want) || pages != 3 {
+		t.Errorf("got %v in %d pages, want %v in 3", got, pages, want)
+	}
+
+	page, _, err := messages.GetAll(MessageListParam{ChannelID: "channel", PageSize: 2, Cursor: secondPrev})
+	if err != nil {
+		t.Fatalf("listing the previous page: %v", err)
+	}
+	if got := messageTexts(page); !slices.Equal(got, want[:2]) {
+		t.Errorf("got %v back, want %v", got, want[:2])
+	}
+
+	// A cursor doesn't page the history of another channel
+	_, _, err = messages.GetAll(MessageListParam{ChannelID: "other", PageSize: 2, Cursor: secondPrev})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor of another channel: got %v, want %v", err, ErrInvalidCursor)
+	}
+}
+
+func messageTexts(messages []*Message) []string {
+	texts := make([]string, 0, len(messages))
+	for _, message := range messages {
+		texts = append(texts, message.Text)
+	}
+	return texts
+}
+
+func TestMessageEditAndDelete(t *testing.T) {
+	db := testDatabase(t)
+	messages := newTestMessageModel(db)
+
+	message := insertTestMessage(t, messages, "channel", bson.NewObjectID(), "helo")
+	if message.EditedAt != nil || message.DeletedAt != nil {
+		t.Errorf("got a new message edited at %v, deleted at %v", message.EditedAt, message.DeletedAt)
+	}
+
+	edited, err := messages.UpdateText(message, "hello")
+	if err != nil {
+		t.Fatalf("editing: %v", err)
+	}
+	if edited.Text != "hello" || edited.EditedAt == nil {
+		t.Errorf("got %q edited at %v, want %q edited", edited.Text, edited.EditedAt, "hello")
+	}
+
+	if _, err := messages.GetByID("other", message.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("getting from another channel: got %v, want %v", err, ErrRecordNotFound)
+	}
+
+	deleted, err := messages.Delete(message)
+	if err != nil {
+		t.Fatalf("deleting: %v", err)
+	}
+	if deleted.Text != "" || deleted.DeletedAt == nil {
+		t.Errorf("got %q deleted at %v, want no text and deleted", deleted.Text, deleted.DeletedAt)
+	}
+
+	// The deleted message keeps its place, it can't be changed anymore
+	got, err := messages.GetByID("channel", message.ID)
+	if err != nil {
+		t.Fatalf("getting deleted message: %v", err)
+	}
+	if got.DeletedAt == nil {
+		t.Error("got the message not deleted")
+	}
+	if _, err := messages.UpdateText(message, "hello again"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("editing deleted message: got %v, want %v", err, ErrRecordNotFound)
+	}
+	if _, err := messages.Delete(message); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("deleting again: got %v, want %v", err, ErrRecordNotFound)
+	}
+}
+
+func TestConversationUpsert(t *testing.T) {
+	db := testDatabase(t)
+	conversations := newTestConversationModel(db)
+
+	userID, friendID := bson.NewObjectID(), bson.NewObjectID()
+	created, err := conversations.Upsert("channel", userID, []bson.ObjectID{userID, friendID})
+	if err != nil {
+		t.Fatalf("creating conversation: %v", err)
+	}
+	if !created.HasMember(userID) || !created.HasMember(friendID) || len(created.Reads) != 2 {
+		t.Errorf("got members %v and %d read receipts, want both members and 2", created.MemberIDs, len(created.Reads))
+	}
+
+	// Opening it again, from either side, leaves it as is
+	again, err := conversations.Upsert("channel", friendID, []bson.ObjectID{friendID, bson.NewObjectID()})
+	if err != nil {
+		t.Fatalf("opening conversation again: %v", err)
+	}
+	if again.ID != created.ID || again.CreatedBy != userID || !slices.Equal(again.MemberIDs, created.MemberIDs) {
+		t.Errorf("got %+v, want %+v", again, created)
+	}
+
+	sentAt := time.Now().Truncate(time.Millisecond)
+	for _, at := range []time.Time{sentAt, sentAt.Add(-time.Hour)} {
+		if err := conversations.Touch("channel", at); err != nil {
+			t.Fatalf("touching conversation: %v", err)
+		}
+	}
+	touched, err := conversations.GetByChannelID("channel")
+	if err != nil {
+		t.Fatalf("getting conversation: %v", err)
+	}
+	// A late write doesn't move the conversation back
+	if touched.LastMessageAt == nil || !touched.LastMessageAt.Equal(sentAt) {
+		t.Errorf("got last message at %v, want %v", touched.LastMessageAt, sentAt)
+	}
+
+	if err := conversations.Freeze("missing"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("freezing missing conversation: got %v, want %v", err, ErrRecordNotFound)
+	}
+}
+
+func TestConversationMarkRead(t *testing.T) {
+	db := testDatabase(t)
+	conversations := newTestConversationModel(db)
+	messages := newTestMessageModel(db)
+
+	userID, friendID := bson.NewObjectID(), bson.NewObjectID()
+	if _, err := conversations.Upsert("channel", userID, []bson.ObjectID{userID, friendID}); err != nil {
+		t.Fatalf("creating conversation: %v", err)
+	}
+	first := insertTestMessage(t, messages, "channel", friendID, "first")
+	second := insertTestMessage(t, messages, "channel", friendID, "second")
+
+	tests := []struct {
+		name      string
+		userID    bson.ObjectID
+		messageID bson.ObjectID
+		want      bool
+	}{
+		{"unread", userID, second.ID, true},
+		{"earlier message", userID, first.ID, false},
+		{"same message", userID, second.ID, false},
+		{"other member", friendID, first.ID, true},
+		{"not a member", bson.NewObjectID(), second.ID, false},
+	}
+
+	// In order, each case starts from the receipts the previous ones left
+	for _, tt := range tests {
+		moved, err := conversations.MarkRead("channel", tt.userID, tt.messageID, time.Now())
+		if err != nil {
+			t.Fatalf("%s: marking read: %v", tt.name, err)
+		}
+		if moved != tt.want {
+			t.Errorf("%s: got moved %t, want %t", tt.name, moved, tt.want)
+		}
+	}
+
+	conversation, err := conversations.GetByChannelID("channel")
+	if err != nil {
+		t.Fatalf("getting conversation: %v", err)
+	}
+	want := map[bson.ObjectID]bson.ObjectID{userID: second.ID, friendID: first.ID}
+	for _, read := range conversation.Reads {
+		if read.MessageID == nil || *read.MessageID != want[read.UserID] || read.ReadAt == nil {
+			t.Errorf("got %s read up to %v, want %s", read.UserID.Hex(), read.MessageID, want[read.UserID].Hex())
+		}
+	}
+}
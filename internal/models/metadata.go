@@ -3,11 +3,13 @@ package models
 import "math"
 
 type Metadata struct {
-	CurrentPage  int64 `json:"current_page"`
-	PageSize     int64 `json:"page_size"`
-	FirstPage    int64 `json:"first_page"`
-	LastPage     int64 `json:"last_page"`
-	TotalRecords int64 `json:"total_records"`
+	CurrentPage  int64  `json:"current_page"`
+	PageSize     int64  `json:"page_size"`
+	FirstPage    int64  `json:"first_page"`
+	LastPage     int64  `json:"last_page"`
+	TotalRecords int64  `json:"total_records"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
 }
 
 func calculateMetadata(totalRecords, page, pageSize int64) Metadata {
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -10,24 +12,84 @@ var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrEditConflict   = errors.New("edit conflict")
 	ErrDuplicateEmail = errors.New("error duplicate email")
+
+	ErrDuplicateUsername = errors.New("error duplicate username")
 )
 
 type Models struct {
 	Logger        *zerolog.Logger
 	User          *UserModel
 	FriendRequest *FriendRequestModel
+
+	UsernameRedirect *UsernameRedirectModel
+	Invite           *InviteModel
+	Referral         *ReferralModel
+	Notification     *NotificationModel
+	EmailJob         *EmailJobModel
+	PushSubscription *PushSubscriptionModel
+	Conversation     *ConversationModel
+	Message          *MessageModel
 }
 
-func NewModels(db *mongo.Database, logger *zerolog.Logger) Models {
+func NewModels(db *mongo.Database, search SearchBackend, cursors *CursorCodec, notificationRetention time.Duration, logger *zerolog.Logger) Models {
 	return Models{
 		User: NewUserModel(
 			db.Collection("users"),
+			search,
+			cursors,
 			logger.With().Str("context", "user_model_service").Logger(),
 		),
 
 		FriendRequest: NewFriendRequestModel(
 			db.Collection("friend_request"),
+			db.Collection("users"),
+			search,
+			cursors,
 			logger.With().Str("context", "friend_request_model_service").Logger(),
 		),
+
+		UsernameRedirect: NewUsernameRedirectModel(
+			db.Collection("username_redirects"),
+			logger.With().Str("context", "username_redirect_model_service").Logger(),
+		),
+
+		Invite: NewInviteModel(
+			db.Collection("invites"),
+			logger.With().Str("context", "invite_model_service").Logger(),
+		),
+
+		Referral: NewReferralModel(
+			db.Collection("referrals"),
+			logger.With().Str("context", "referral_model_service").Logger(),
+		),
+
+		Notification: NewNotificationModel(
+			db.Collection("notifications"),
+			cursors,
+			notificationRetention,
+			logger.With().Str("context", "notification_model_service").Logger(),
+		),
+
+		EmailJob: NewEmailJobModel(
+			db.Collection("email_jobs"),
+			logger.With().Str("context", "email_job_model_service").Logger(),
+		),
+
+		PushSubscription: NewPushSubscriptionModel(
+			db.Collection("push_subscriptions"),
+			logger.With().Str("context", "push_subscription_model_service").Logger(),
+		),
+
+		Conversation: NewConversationModel(
+			db.Collection("conversations"),
+			cursors,
+			logger.With().Str("context", "conversation_model_service").Logger(),
+		),
+
+		Message: NewMessageModel(
+			db.Collection("messages"),
+			cursors,
+			logger.With().Str("context", "message_model_service").Logger(),
+		),
 	}
 }
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// The model tests run against a real MongoDB deployment, in a throwaway
+// database. They are skipped unless STREAMIFY_TEST_MONGO_URI is set, point it
+// at an Atlas deployment (mongodb/mongodb-atlas-local works) to also run them
+// against Atlas Search.
+const testMongoURIEnv = "STREAMIFY_TEST_MONGO_URI"
+
+// testDatabase returns a new database dropped when the test ends.
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv(testMongoURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", testMongoURIEnv)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db := client.Database("streamify_test_" + bson.NewObjectID().Hex())
+	// $listSearchIndexes needs the collections to exist
+	for _, name := range []string{"users", "friend_request"} {
+		if err := db.CreateCollection(ctx, name); err != nil {
+			t.Fatalf("creating collection %s: %v", name, err)
+		}
+	}
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db
+}
+
+// forEachSearchBackend runs fn in a subtest per search backend db supports:
+// the regex one always, Atlas Search when available.
+func forEachSearchBackend(t *testing.T, fn func(t *testing.T, db *mongo.Database, search SearchBackend)) {
+	t.Helper()
+
+	backends := []SearchBackend{&RegexSearchBackend{}, &AtlasSearchBackend{}}
+	for _, search := range backends {
+		t.Run(search.Name(), func(t *testing.T) {
+			db := testDatabase(t)
+			if search.Name() == SearchBackendAtlas && !supportsAtlasSearch(db.Collection("users")) {
+				t.Skip("Atlas Search is not available")
+			}
+			fn(t, db, search)
+		})
+	}
+}
+
+func newTestUserModel(db *mongo.Database, search SearchBackend) *UserModel {
+	return NewUserModel(db.Collection("users"), search, NewCursorCodec("test"), zerolog.Nop())
+}
+
+func newTestFriendRequestModel(db *mongo.Database, search SearchBackend) *FriendRequestModel {
+	return NewFriendRequestModel(db.Collection("friend_request"), db.Collection("users"), search, NewCursorCodec("test"), zerolog.Nop())
+}
+
+// insertTestUser inserts an onboarded user, edit sets the other fields.
+func insertTestUser(t *testing.T, users *UserModel, fullName string, edit func(user *User)) *User {
+	t.Helper()
+
+	user := &User{
+		FullName:    fullName,
+		Email:       bson.NewObjectID().Hex() + "@example.com",
+		IsOnboarded: true,
+	}
+	if edit != nil {
+		edit(user)
+	}
+	privacy := user.Privacy
+
+	user, err := users.Insert(user)
+	if err != nil {
+		t.Fatalf("inserting user %s: %v", fullName, err)
+	}
+	// Insert fills the settings left empty, keep the ones edit set
+	if privacy != (PrivacySettings{}) {
+		user.Privacy = privacy
+		if _, err := users.UpdatePrivacy(user); err != nil {
+			t.Fatalf("updating user %s: %v", fullName, err)
+		}
+	}
+	return user
+}
+
+// waitSearchable waits until the users search index caught up with the
+// writes made so far, Atlas Search indexes them asynchronously. It writes a
+// marker user, never onboarded, and waits for it: the index applies the
+// writes in order.
+func waitSearchable(t *testing.T, db *mongo.Database, search SearchBackend) {
+	t.Helper()
+
+	if search.Name() != SearchBackendAtlas {
+		return
+	}
+
+	coll := db.Collection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	result, err := coll.InsertOne(ctx, bson.D{{Key: "full_name", Value: "Search index marker"}})
+	if err != nil {
+		t.Fatalf("inserting search index marker: %v", err)
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$search", Value: bson.D{
+			{Key: "index", Value: atlasUserSearchIndex},
+			{Key: "exists", Value: bson.D{{Key: "path", Value: "full_name"}}},
+		}}},
+		bson.D{{Key: "$match", Value: bson.D{{Key: "_id", Value: result.InsertedID}}}},
+	}
+
+	for {
+		var found []bson.Raw
+		cursor, err := coll.Aggregate(ctx, pipeline)
+		if err == nil {
+			err = cursor.All(ctx, &found)
+		}
+		if err == nil && len(found) > 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("search index not ready: %v", err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MutualFriendsPreviewSize is the maximum number of mutual friends returned
+// alongside a user as a preview.
+const MutualFriendsPreviewSize = 3
+
+type MutualFriend struct {
+	ID         bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	FullName   string        `bson:"full_name" json:"full_name"`
+	ProfilePic string        `bson:"profile_pic" json:"profile_pic"`
+}
+
+// mutualFriendIDs returns the ids contained in both a and b, preserving the order of a.
+func mutualFriendIDs(a, b []bson.ObjectID) []bson.ObjectID {
+	set := make(map[bson.ObjectID]struct{}, len(b))
+	for _, id := range b {
+		set[id] = struct{}{}
+	}
+
+	mutual := []bson.ObjectID{}
+	for _, id := range a {
+		if _, ok := set[id]; ok {
+			mutual = append(mutual, id)
+		}
+	}
+	return mutual
+}
+
+// addFieldsMutualFriendsStage computes the mutual_friend_ids of every document
+// with viewer. They are left empty for the users hiding their friend list from
+// the viewer, intersecting both lists would reveal it.
+func addFieldsMutualFriendsStage(viewer *User) bson.D {
+	friendIDs := viewer.FriendIDs
+	if friendIDs == nil {
+		friendIDs = []bson.ObjectID{}
+	}
+
+	return bson.D{{Key: "$addFields", Value: bson.D{
+		{Key: "mutual_friend_ids", Value: bson.D{{Key: "$cond", Value: bson.A{
+			visibleExpr(viewer.ID, "", "friend_list_visibility", DefaultPrivacySettings.FriendListVisibility),
+			bson.D{{Key: "$setIntersection", Value: bson.A{
+				bson.D{{Key: "$ifNull", Value: bson.A{"$friend_ids", bson.A{}}}},
+				friendIDs,
+			}}},
+			bson.A{},
+		}}}},
+	}}}
+}
+
+// addFieldsMutualFriendsCountStage must run after addFieldsMutualFriendsStage.
+func addFieldsMutualFriendsCountStage() bson.D {
+	return bson.D{{Key: "$addFields", Value: bson.D{
+		{Key: "mutual_friends_count", Value: bson.D{{Key: "$size", Value: "$mutual_friend_ids"}}},
+	}}}
+}
+
+// lookupMutualFriendsStage joins a small preview of the mutual friends, it must
+// run after addFieldsMutualFriendsStage.
+func lookupMutualFriendsStage() bson.D {
+	return bson.D{{Key: "$lookup", Value: bson.D{
+		{Key: "from", Value: "users"},
+		{Key: "let", Value: bson.D{
+			{Key: "mutualIds", Value: bson.D{{Key: "$slice", Value: bson.A{"$mutual_friend_ids", MutualFriendsPreviewSize}}}},
+		}},
+		{Key: "pipeline", Value: bson.A{
+			bson.D{{Key: "$match", Value: bson.D{
+				{Key: "$expr", Value: bson.D{{Key: "$in", Value: bson.A{"$_id", "$$mutualIds"}}}},
+			}}},
+			bson.D{{Key: "$project", Value: bson.D{
+				{Key: "full_name", Value: 1},
+				{Key: "profile_pic", Value: 1},
+			}}},
+		}},
+		{Key: "as", Value: "mutual_friends"},
+	}}}
+}
@@ -0,0 +1,242 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type NotificationType = string
+
+const (
+	NotificationFriendRequestReceived NotificationType = "friend_request.received"
+	NotificationFriendRequestAccepted NotificationType = "friend_request.accepted"
+	NotificationInviteRedeemed        NotificationType = "invite.redeemed"
+)
+
+var NotificationTypes = []NotificationType{
+	NotificationFriendRequestReceived,
+	NotificationFriendRequestAccepted,
+	NotificationInviteRedeemed,
+}
+
+// Notification tells UserID that ActorID did something. The subject, the
+// friend request or the invite involved, depends on Type.
+type Notification struct {
+	ID              bson.ObjectID    `bson:"_id,omitempty" json:"id"`
+	UserID          bson.ObjectID    `bson:"user_id" json:"user_id"`
+	Type            NotificationType `bson:"type" json:"type"`
+	ActorID         bson.ObjectID    `bson:"actor_id" json:"actor_id"`
+	FriendRequestID *bson.ObjectID   `bson:"friend_request_id,omitempty" json:"friend_request_id,omitempty"`
+	InviteID        *bson.ObjectID   `bson:"invite_id,omitempty" json:"invite_id,omitempty"`
+	ReadAt          *time.Time       `bson:"read_at" json:"read_at"`
+	CreatedAt       time.Time        `bson:"created_at" json:"created_at"`
+	ExpiresAt       time.Time        `bson:"expires_at" json:"expires_at"`
+}
+
+// NotificationWithActor is a notification with the public profile of its actor.
+type NotificationWithActor struct {
+	Notification `bson:",inline"`
+	Actor        *User `bson:"actor" json:"actor"`
+}
+
+type NotificationModel struct {
+	logger    zerolog.Logger
+	coll      *mongo.Collection
+	cursors   *CursorCodec
+	retention time.Duration
+}
+
+// NewNotificationModel keeps notifications for retention, see the expires_at
+// TTL index.
+func NewNotificationModel(coll *mongo.Collection, cursors *CursorCodec, retention time.Duration, logger zerolog.Logger) *NotificationModel {
+	/* ------------------------ user notifications ---------------------- */
+	userIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "created_at", Value: -1},
+			{Key: "_id", Value: -1},
+		},
+	}
+
+	name, err := coll.Indexes().CreateOne(context.TODO(), userIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating user notifications index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* ------------------------- unread by user ------------------------- */
+	unreadIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "read_at", Value: 1},
+		},
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), unreadIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating unread notifications index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* ------------------------- ttl expires at ------------------------ */
+	// The retention is stored per document, so changing it doesn't require
+	// rebuilding the index and applies to new notifications only.
+	ttlIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), ttlIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating expires at ttl index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	return &NotificationModel{
+		coll:      coll,
+		cursors:   cursors,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+func (m *NotificationModel) Insert(notification *Notification) (*Notification, error) {
+	notification.CreatedAt = time.Now()
+	notification.ExpiresAt = notification.CreatedAt.Add(m.retention)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.coll.InsertOne(ctx, notification)
+	if err != nil {
+		return nil, err
+	}
+
+	idrecord, ok := result.InsertedID.(bson.ObjectID)
+	if !ok {
+		return nil, errors.New("ID is not ObjectID, you should let mongo manage the ID")
+	}
+
+	notification.ID = idrecord
+	return notification, nil
+}
+
+type NotificationListParam struct {
+	CurrentUser *User
+	UnreadOnly  bool
+	Page        int64
+	PageSize    int64
+	Cursor      string // switches to cursor pagination, Page is ignored
+}
+
+// GetAll returns the notifications of the current user, newest first.
+func (m *NotificationModel) GetAll(param NotificationListParam) ([]*NotificationWithActor, Metadata, error) {
+	filter := bson.D{{Key: "user_id", Value: param.CurrentUser.ID}}
+	listing := "notifications"
+	if param.UnreadOnly {
+		filter = append(filter, bson.E{Key: "read_at", Value: nil})
+		listing = "notifications:unread"
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: filter}},
+	}
+
+	ks := keyset{listing: listing, keys: []sortKey{
+		{Field: "created_at", Order: -1},
+		{Field: "_id", Order: -1},
+	}}
+
+	lookup := mongo.Pipeline{
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "users"},
+			{Key: "localField", Value: "actor_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "actor"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$actor"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+		publicProfileStage(param.CurrentUser.ID, "actor"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return aggregatePage[NotificationWithActor](ctx, m.coll, m.cursors, pipeline, lookup, ks, pagination{
+		page:     param.Page,
+		pageSize: param.PageSize,
+		cursor:   param.Cursor,
+	})
+}
+
+// MarkRead marks the notification id of userID as read, it returns
+// ErrRecordNotFound when userID has no such notification.
+func (m *NotificationModel) MarkRead(userID, id bson.ObjectID) (*Notification, error) {
+	current := time.Now()
+
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "user_id", Value: userID},
+	}
+	// Keep the time it was first read
+	update := mongo.Pipeline{
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "read_at", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$read_at", current}}}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var notification Notification
+	err := m.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&notification)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &notification, nil
+}
+
+// MarkAllRead marks every unread notification of userID as read and returns
+// how many were.
+func (m *NotificationModel) MarkAllRead(userID bson.ObjectID) (int64, error) {
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "read_at", Value: nil},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "read_at", Value: time.Now()}}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (m *NotificationModel) CountUnread(userID bson.ObjectID) (int64, error) {
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "read_at", Value: nil},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.coll.CountDocuments(ctx, filter)
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type Presence = string
+
+const (
+	PresenceOnline  Presence = "online"
+	PresenceAway    Presence = "away"
+	PresenceOffline Presence = "offline"
+)
+
+const (
+	// LastActiveThrottle is the minimum time between two writes of
+	// LastActiveAt, requests in between don't touch the user document.
+	LastActiveThrottle = time.Minute
+	// PresenceOnlineWindow is how long a user stays online after a request.
+	PresenceOnlineWindow = 5 * time.Minute
+	// PresenceAwayWindow is how long a user stays away after a request, they
+	// are offline afterwards.
+	PresenceAwayWindow = 30 * time.Minute
+)
+
+// Activity boosts added to the recommendation score. Users hiding their
+// presence from the viewer get none, the score would reveal when they were
+// last active.
+const (
+	ActivityScoreDay   = 20
+	ActivityScoreWeek  = 10
+	ActivityScoreMonth = 5
+)
+
+// PresenceAt returns the presence of a user last active at lastActiveAt.
+func PresenceAt(lastActiveAt *time.Time, now time.Time) Presence {
+	if lastActiveAt == nil {
+		return PresenceOffline
+	}
+	idle := now.Sub(*lastActiveAt)
+	switch {
+	case idle < PresenceOnlineWindow:
+		return PresenceOnline
+	case idle < PresenceAwayWindow:
+		return PresenceAway
+	default:
+		return PresenceOffline
+	}
+}
+
+// TouchLastActive records that user just made a request. It writes at most
+// once per LastActiveThrottle, the filter keeps concurrent requests from
+// writing again.
+func (m *UserModel) TouchLastActive(user *User) error {
+	current := time.Now()
+	if user.LastActiveAt != nil && current.Sub(*user.LastActiveAt) < LastActiveThrottle {
+		return nil
+	}
+
+	filter := bson.D{
+		{Key: "_id", Value: user.ID},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "last_active_at", Value: bson.D{{Key: "$exists", Value: false}}}},
+			bson.D{{Key: "last_active_at", Value: bson.D{{Key: "$lt", Value: current.Add(-LastActiveThrottle)}}}},
+		}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "last_active_at", Value: current}}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	user.LastActiveAt = &current
+	return nil
+}
+
+// activeSinceCondition matches the users active since the given time, among
+// those sharing their presence with the viewer. Users not seen since
+// last_active_at exists fall back on their last update.
+func activeSinceCondition(viewerID bson.ObjectID, since time.Time) bson.D {
+	activeSince := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "last_active_at", Value: bson.D{{Key: "$gte", Value: since}}}},
+		bson.D{
+			{Key: "last_active_at", Value: bson.D{{Key: "$exists", Value: false}}},
+			{Key: "updated_at", Value: bson.D{{Key: "$gte", Value: since}}},
+		},
+	}}}
+	return bson.D{{Key: "$and", Value: bson.A{activeSince, presenceVisibleCondition(viewerID)}}}
+}
+
+// activityScoreStages adds to match_score a boost for users active shortly
+// before now, when they share their presence with the viewer. It must run
+// after languageMatchStages, and before friend_ids is projected out. Paginated
+// listings pass the time they were ranked at, or users move between buckets
+// from a page to the next.
+func activityScoreStages(viewerID bson.ObjectID, now time.Time) mongo.Pipeline {
+	lastActive := bson.D{{Key: "$ifNull", Value: bson.A{"$last_active_at", "$updated_at"}}}
+	activeSince := func(since time.Time) bson.D {
+		return bson.D{{Key: "$gte", Value: bson.A{lastActive, since}}}
+	}
+
+	activityScore := bson.D{{Key: "$switch", Value: bson.D{
+		{Key: "branches", Value: bson.A{
+			bson.D{{Key: "case", Value: activeSince(now.AddDate(0, 0, -1))}, {Key: "then", Value: ActivityScoreDay}},
+			bson.D{{Key: "case", Value: activeSince(now.AddDate(0, 0, -7))}, {Key: "then", Value: ActivityScoreWeek}},
+			bson.D{{Key: "case", Value: activeSince(now.AddDate(0, 0, -30))}, {Key: "then", Value: ActivityScoreMonth}},
+		}},
+		{Key: "default", Value: 0},
+	}}}
+	activityScore = bson.D{{Key: "$cond", Value: bson.A{presenceVisibleExpr(viewerID), activityScore, 0}}}
+
+	return mongo.Pipeline{
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "match_score", Value: bson.D{{Key: "$add", Value: bson.A{"$match_score", activityScore}}}},
+		}}},
+	}
+}
@@ -0,0 +1,298 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type FriendRequestPolicy = string
+
+const (
+	FriendRequestPolicyEveryone         FriendRequestPolicy = "everyone"
+	FriendRequestPolicyFriendsOfFriends FriendRequestPolicy = "friends_of_friends"
+	FriendRequestPolicyNobody           FriendRequestPolicy = "nobody"
+)
+
+var FriendRequestPolicies = []FriendRequestPolicy{
+	FriendRequestPolicyEveryone,
+	FriendRequestPolicyFriendsOfFriends,
+	FriendRequestPolicyNobody,
+}
+
+// InviteAction is what redeeming an invite of a user does.
+type InviteAction = string
+
+const (
+	InviteActionBefriend InviteAction = "befriend" // friends right away
+	InviteActionRequest  InviteAction = "request"  // pending request to the inviter
+)
+
+var InviteActions = []InviteAction{
+	InviteActionBefriend,
+	InviteActionRequest,
+}
+
+type Visibility = string
+
+const (
+	VisibilityEveryone Visibility = "everyone"
+	VisibilityFriends  Visibility = "friends"
+	VisibilityOnlyMe   Visibility = "only_me"
+)
+
+var Visibilities = []Visibility{
+	VisibilityEveryone,
+	VisibilityFriends,
+	VisibilityOnlyMe,
+}
+
+type PrivacySettings struct {
+	Discoverable         bool                `bson:"discoverable" json:"discoverable"` // listed in recommendations and search
+	FriendRequests       FriendRequestPolicy `bson:"friend_requests" json:"friend_requests"`
+	EmailVisibility      Visibility          `bson:"email_visibility" json:"email_visibility"`
+	LocationVisibility   Visibility          `bson:"location_visibility" json:"location_visibility"`
+	FriendListVisibility Visibility          `bson:"friend_list_visibility" json:"friend_list_visibility"`
+	InviteAction         InviteAction        `bson:"invite_action" json:"invite_action"`
+	PresenceVisibility   Visibility          `bson:"presence_visibility" json:"presence_visibility"` // online state and last seen
+}
+
+// DefaultPrivacySettings also applies to users created before privacy settings
+// existed, see the privacy migration and the $ifNull of publicProfileStage.
+var DefaultPrivacySettings = PrivacySettings{
+	Discoverable:         true,
+	FriendRequests:       FriendRequestPolicyEveryone,
+	EmailVisibility:      VisibilityOnlyMe,
+	LocationVisibility:   VisibilityEveryone,
+	FriendListVisibility: VisibilityFriends,
+	InviteAction:         InviteActionBefriend,
+	PresenceVisibility:   VisibilityFriends,
+}
+
+// withDefaults fills the settings missing from documents written before they
+// existed.
+func (p PrivacySettings) withDefaults() PrivacySettings {
+	if p == (PrivacySettings{}) {
+		return DefaultPrivacySettings
+	}
+	if p.FriendRequests == "" {
+		p.FriendRequests = DefaultPrivacySettings.FriendRequests
+	}
+	if p.EmailVisibility == "" {
+		p.EmailVisibility = DefaultPrivacySettings.EmailVisibility
+	}
+	if p.LocationVisibility == "" {
+		p.LocationVisibility = DefaultPrivacySettings.LocationVisibility
+	}
+	if p.FriendListVisibility == "" {
+		p.FriendListVisibility = DefaultPrivacySettings.FriendListVisibility
+	}
+	if p.PresenceVisibility == "" {
+		p.PresenceVisibility = DefaultPrivacySettings.PresenceVisibility
+	}
+	if p.InviteAction == "" {
+		p.InviteAction = DefaultPrivacySettings.InviteAction
+	}
+	return p
+}
+
+// PublicProfile is a user as seen by another user, fields hidden by the
+// owner's privacy settings are left empty.
+type PublicProfile struct {
+	ID           bson.ObjectID   `json:"id"`
+	FullName     string          `json:"full_name"`
+	Username     string          `json:"username"`
+	Email        string          `json:"email,omitempty"`
+	Bio          string          `json:"bio"`
+	ProfilePic   string          `json:"profile_pic"`
+	Languages    []UserLanguage  `json:"languages"`
+	Location     *Location       `json:"location,omitempty"`
+	IsOnboarded  bool            `json:"is_onboarded"`
+	FriendIDs    []bson.ObjectID `json:"friend_ids,omitempty"`
+	Presence     Presence        `json:"presence,omitempty"`
+	LastActiveAt *time.Time      `json:"last_active_at,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// PublicProfile returns the profile of u as seen by viewer.
+func (u *User) PublicProfile(viewer *User) *PublicProfile {
+	privacy := u.Privacy.withDefaults()
+
+	profile := &PublicProfile{
+		ID:          u.ID,
+		FullName:    u.FullName,
+		Username:    u.Username,
+		Bio:         u.Bio,
+		ProfilePic:  u.ProfilePic,
+		Languages:   u.Languages,
+		IsOnboarded: u.IsOnboarded,
+		CreatedAt:   u.CreatedAt,
+	}
+
+	if u.CanView(viewer, privacy.EmailVisibility) {
+		profile.Email = u.Email
+	}
+	if u.CanView(viewer, privacy.LocationVisibility) {
+		location := u.Location
+		profile.Location = &location
+	}
+	if u.CanView(viewer, privacy.FriendListVisibility) {
+		profile.FriendIDs = u.FriendIDs
+	}
+	if u.CanView(viewer, privacy.PresenceVisibility) {
+		profile.Presence = PresenceAt(u.LastActiveAt, time.Now())
+		profile.LastActiveAt = u.LastActiveAt
+	}
+	return profile
+}
+
+// CanView reports whether viewer can see a field of u with the given visibility.
+func (u *User) CanView(viewer *User, visibility Visibility) bool {
+	if viewer.ID == u.ID {
+		return true
+	}
+	switch visibility {
+	case VisibilityEveryone:
+		return true
+	case VisibilityFriends:
+		// Friendship is mutual, checking the viewer side keeps working when
+		// the friend list of u was projected out.
+		return slices.Contains(viewer.FriendIDs, u.ID)
+	default:
+		return false
+	}
+}
+
+// SharesFriendListWith reports whether u shares their friend list with viewer,
+// and so the friends they have in common.
+func (u *User) SharesFriendListWith(viewer *User) bool {
+	return u.CanView(viewer, u.Privacy.withDefaults().FriendListVisibility)
+}
+
+// Blocks reports whether either user blocked the other.
+func (u *User) Blocks(other *User) bool {
+	return slices.Contains(u.BlockedIDs, other.ID) || slices.Contains(other.BlockedIDs, u.ID)
+}
+
+// AcceptsFriendRequestFrom reports whether sender is allowed to send u a friend
+// request.
+func (u *User) AcceptsFriendRequestFrom(sender *User) bool {
+	if u.Blocks(sender) {
+		return false
+	}
+	switch u.Privacy.withDefaults().FriendRequests {
+	case FriendRequestPolicyNobody:
+		return false
+	case FriendRequestPolicyFriendsOfFriends:
+		return len(mutualFriendIDs(u.FriendIDs, sender.FriendIDs)) > 0
+	default:
+		return true
+	}
+}
+
+// discoverableCondition filters out the users hidden from discovery, users
+// without settings are discoverable.
+func discoverableCondition() bson.D {
+	return bson.D{{Key: "privacy.discoverable", Value: bson.D{{Key: "$ne", Value: false}}}}
+}
+
+// publicProfileStage removes the fields of the users hidden from the viewer by
+// their privacy settings. It is the aggregation counterpart of PublicProfile and
+// must run once friend_ids isn't needed anymore. With embeddedAs the user is
+// the document embedded in that field rather than the root document.
+func publicProfileStage(viewerID bson.ObjectID, embeddedAs string) bson.D {
+	prefix := ""
+	if embeddedAs != "" {
+		prefix = embeddedAs + "."
+	}
+
+	field := func(name string, setting string, fallback Visibility) bson.E {
+		return bson.E{Key: prefix + name, Value: bson.D{{Key: "$cond", Value: bson.A{
+			visibleExpr(viewerID, prefix, setting, fallback),
+			"$" + prefix + name,
+			"$$REMOVE",
+		}}}}
+	}
+
+	return bson.D{{Key: "$set", Value: bson.D{
+		field("email", "email_visibility", DefaultPrivacySettings.EmailVisibility),
+		field("location", "location_visibility", DefaultPrivacySettings.LocationVisibility),
+		field("friend_ids", "friend_list_visibility", DefaultPrivacySettings.FriendListVisibility),
+		field("last_active_at", "presence_visibility", DefaultPrivacySettings.PresenceVisibility),
+	}}}
+}
+
+// visibleExpr is true when the user, at prefix in the document, shares the
+// field of setting with the viewer.
+func visibleExpr(viewerID bson.ObjectID, prefix string, setting string, fallback Visibility) bson.D {
+	visibility := bson.D{{Key: "$ifNull", Value: bson.A{"$" + prefix + "privacy." + setting, fallback}}}
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "$eq", Value: bson.A{"$" + prefix + "_id", viewerID}}},
+		bson.D{{Key: "$eq", Value: bson.A{visibility, VisibilityEveryone}}},
+		bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: "$eq", Value: bson.A{visibility, VisibilityFriends}}},
+			bson.D{{Key: "$in", Value: bson.A{viewerID, bson.D{{Key: "$ifNull", Value: bson.A{"$" + prefix + "friend_ids", bson.A{}}}}}}},
+		}}},
+	}}}
+}
+
+// visibleCondition is the query counterpart of visibleExpr, for the users at
+// the root of the document. Filters on a field hidden by its owner must be
+// combined with it, or their results reveal the field.
+func visibleCondition(viewerID bson.ObjectID, setting string, fallback Visibility) bson.D {
+	path := "privacy." + setting
+	visibility := func(value Visibility) bson.D {
+		if value == fallback {
+			// Matches the users without the setting too
+			return bson.D{{Key: path, Value: bson.D{{Key: "$in", Value: bson.A{value, nil}}}}}
+		}
+		return bson.D{{Key: path, Value: value}}
+	}
+
+	friends := visibility(VisibilityFriends)
+	friends = append(friends, bson.E{Key: "friend_ids", Value: viewerID})
+
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "_id", Value: viewerID}},
+		visibility(VisibilityEveryone),
+		friends,
+	}}}
+}
+
+// locationVisibleCondition matches the users sharing their location with the
+// viewer.
+func locationVisibleCondition(viewerID bson.ObjectID) bson.D {
+	return visibleCondition(viewerID, "location_visibility", DefaultPrivacySettings.LocationVisibility)
+}
+
+// locationVisibleExpr is the expression counterpart of
+// locationVisibleCondition.
+func locationVisibleExpr(viewerID bson.ObjectID) bson.D {
+	return visibleExpr(viewerID, "", "location_visibility", DefaultPrivacySettings.LocationVisibility)
+}
+
+// presenceVisibleCondition matches the users sharing their presence with the
+// viewer.
+func presenceVisibleCondition(viewerID bson.ObjectID) bson.D {
+	return visibleCondition(viewerID, "presence_visibility", DefaultPrivacySettings.PresenceVisibility)
+}
+
+// presenceVisibleExpr is the expression counterpart of
+// presenceVisibleCondition.
+func presenceVisibleExpr(viewerID bson.ObjectID) bson.D {
+	return visibleExpr(viewerID, "", "presence_visibility", DefaultPrivacySettings.PresenceVisibility)
+}
+
+// notBlockedCondition filters out the users, at prefix in the document, the
+// viewer blocked and those who blocked the viewer.
+func notBlockedCondition(viewer *User, prefix string) bson.D {
+	blockedIDs := viewer.BlockedIDs
+	if blockedIDs == nil {
+		blockedIDs = []bson.ObjectID{}
+	}
+	return bson.D{
+		{Key: prefix + "_id", Value: bson.D{{Key: "$nin", Value: blockedIDs}}},
+		{Key: prefix + "blocked_ids", Value: bson.D{{Key: "$ne", Value: viewer.ID}}},
+	}
+}
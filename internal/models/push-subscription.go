@@ -0,0 +1,169 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MaxPushSubscriptions is how many browsers a user can receive push
+// notifications on, the least recently subscribed is dropped past it.
+const MaxPushSubscriptions = 10
+
+// PushSubscription is a browser of UserID accepting Web Push messages at
+// Endpoint, encrypted with its P256dh key and Auth secret.
+type PushSubscription struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`
+	Endpoint  string        `bson:"endpoint" json:"endpoint"`
+	P256dh    string        `bson:"p256dh" json:"-"`
+	Auth      string        `bson:"auth" json:"-"`
+	UserAgent string        `bson:"user_agent" json:"user_agent"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+}
+
+type PushSubscriptionModel struct {
+	logger zerolog.Logger
+	coll   *mongo.Collection
+}
+
+func NewPushSubscriptionModel(coll *mongo.Collection, logger zerolog.Logger) *PushSubscriptionModel {
+	/* ------------------------- unique endpoint ------------------------ */
+	endpointIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "endpoint", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	name, err := coll.Indexes().CreateOne(context.TODO(), endpointIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating unique endpoint index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* ------------------------ user subscriptions ---------------------- */
+	userIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "created_at", Value: -1},
+		},
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), userIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating user push subscriptions index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	return &PushSubscriptionModel{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+// Upsert saves subscription, keyed by its endpoint. A browser subscribing
+// again, maybe signed in as another user, replaces its previous subscription.
+func (m *PushSubscriptionModel) Upsert(subscription *PushSubscription) (*PushSubscription, error) {
+	subscription.CreatedAt = time.Now()
+
+	filter := bson.D{{Key: "endpoint", Value: subscription.Endpoint}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "user_id", Value: subscription.UserID},
+		{Key: "p256dh", Value: subscription.P256dh},
+		{Key: "auth", Value: subscription.Auth},
+		{Key: "user_agent", Value: subscription.UserAgent},
+		{Key: "created_at", Value: subscription.CreatedAt},
+	}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var saved PushSubscription
+	if err := m.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved); err != nil {
+		return nil, err
+	}
+
+	if err := m.trim(ctx, saved.UserID); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// trim drops the oldest subscriptions of userID past MaxPushSubscriptions.
+func (m *PushSubscriptionModel) trim(ctx context.Context, userID bson.ObjectID) error {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(MaxPushSubscriptions).
+		SetProjection(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := m.coll.Find(ctx, bson.D{{Key: "user_id", Value: userID}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var stale []PushSubscription
+	if err := cursor.All(ctx, &stale); err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	ids := make([]bson.ObjectID, 0, len(stale))
+	for _, subscription := range stale {
+		ids = append(ids, subscription.ID)
+	}
+	_, err = m.coll.DeleteMany(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}})
+	return err
+}
+
+func (m *PushSubscriptionModel) GetAllByUser(userID bson.ObjectID) ([]*PushSubscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := m.coll.Find(ctx, bson.D{{Key: "user_id", Value: userID}}, opts)
+	if err != nil {
+		return []*PushSubscription{}, err
+	}
+	defer cursor.Close(ctx)
+
+	subscriptions := []*PushSubscription{}
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return []*PushSubscription{}, err
+	}
+	return subscriptions, nil
+}
+
+// DeleteByEndpoint removes the subscription of userID at endpoint, it returns
+// ErrRecordNotFound when userID has no such subscription.
+func (m *PushSubscriptionModel) DeleteByEndpoint(userID bson.ObjectID, endpoint string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.coll.DeleteOne(ctx, bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "endpoint", Value: endpoint},
+	})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete removes the subscription id, the push service said it is gone.
+func (m *PushSubscriptionModel) Delete(id bson.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	return err
+}
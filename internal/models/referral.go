@@ -0,0 +1,245 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ReferralSource = string
+
+const (
+	ReferralSourceCode   ReferralSource = "code"   // referral code of the referrer
+	ReferralSourceInvite ReferralSource = "invite" // invite link of the referrer
+)
+
+const (
+	referralCodeLength = 8
+	// referralCodeAlphabet leaves out characters easily confused when a code
+	// is typed from a screen: 0, 1, I and O.
+	referralCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+)
+
+// Referral records that a user signed up through another user.
+type Referral struct {
+	ID          bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ReferrerID  bson.ObjectID  `bson:"referrer_id" json:"referrer_id"`
+	RefereeID   bson.ObjectID  `bson:"referee_id" json:"referee_id"`
+	Source      ReferralSource `bson:"source" json:"source"`
+	Code        string         `bson:"code" json:"code"` // referral code or invite token used
+	SignedUpAt  time.Time      `bson:"signed_up_at" json:"signed_up_at"`
+	OnboardedAt *time.Time     `bson:"onboarded_at" json:"onboarded_at"`
+}
+
+type ReferralStats struct {
+	Total           int64      `bson:"total" json:"total"`
+	Onboarded       int64      `bson:"onboarded" json:"onboarded"`
+	FirstReferredAt *time.Time `bson:"first_referred_at" json:"first_referred_at"`
+	LastReferredAt  *time.Time `bson:"last_referred_at" json:"last_referred_at"`
+}
+
+// TopReferrer is a line of the top referrers report.
+type TopReferrer struct {
+	ReferrerID bson.ObjectID `bson:"_id"`
+	FullName   string        `bson:"full_name"`
+	Username   string        `bson:"username"`
+	Email      string        `bson:"email"`
+	Total      int64         `bson:"total"`
+	Onboarded  int64         `bson:"onboarded"`
+}
+
+// NormalizeReferralCode returns code the way referral codes are stored.
+func NormalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func newReferralCode() (string, error) {
+	b := make([]byte, referralCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = referralCodeAlphabet[int(b[i])%len(referralCodeAlphabet)]
+	}
+	return string(b), nil
+}
+
+type ReferralModel struct {
+	logger zerolog.Logger
+	coll   *mongo.Collection
+}
+
+func NewReferralModel(coll *mongo.Collection, logger zerolog.Logger) *ReferralModel {
+	/* ------------------------- unique referee ------------------------ */
+	// A user is referred once, by whoever brought them in first.
+	uniqueRefereeIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "referee_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	name, err := coll.Indexes().CreateOne(context.TODO(), uniqueRefereeIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating unique referee index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* ------------------------ referrer signups ----------------------- */
+	referrerIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "referrer_id", Value: 1},
+			{Key: "signed_up_at", Value: -1},
+		},
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), referrerIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating referrer index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* ---------------------------- signups ---------------------------- */
+	signedUpIdx := mongo.IndexModel{
+		Keys: bson.D{{Key: "signed_up_at", Value: 1}},
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), signedUpIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating signed up at index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	return &ReferralModel{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+func (m *ReferralModel) Insert(referral *Referral) (*Referral, error) {
+	referral.SignedUpAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.coll.InsertOne(ctx, referral)
+	if err != nil {
+		return nil, err
+	}
+
+	idrecord, ok := result.InsertedID.(bson.ObjectID)
+	if !ok {
+		return nil, errors.New("ID is not ObjectID, you should let mongo manage the ID")
+	}
+
+	referral.ID = idrecord
+	return referral, nil
+}
+
+// MarkOnboarded records when the referee finished onboarding, only the first
+// time.
+func (m *ReferralModel) MarkOnboarded(refereeID bson.ObjectID) error {
+	filter := bson.D{
+		{Key: "referee_id", Value: refereeID},
+		{Key: "onboarded_at", Value: nil},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "onboarded_at", Value: time.Now()}}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (m *ReferralModel) Stats(referrerID bson.ObjectID) (ReferralStats, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "referrer_id", Value: referrerID}}}},
+		referralGroupStage("$referrer_id"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return ReferralStats{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var stats []ReferralStats
+	if err := cursor.All(ctx, &stats); err != nil {
+		return ReferralStats{}, err
+	}
+	if len(stats) == 0 {
+		return ReferralStats{}, nil
+	}
+	return stats[0], nil
+}
+
+// TopReferrers returns the users who referred the most signups in [from, to).
+func (m *ReferralModel) TopReferrers(from, to time.Time, limit int64) ([]*TopReferrer, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "signed_up_at", Value: bson.D{
+			{Key: "$gte", Value: from},
+			{Key: "$lt", Value: to},
+		}}}}},
+		referralGroupStage("$referrer_id"),
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "total", Value: -1},
+			{Key: "onboarded", Value: -1},
+			{Key: "_id", Value: 1},
+		}}},
+		bson.D{{Key: "$limit", Value: limit}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "users"},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "referrer"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$referrer"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "full_name", Value: "$referrer.full_name"},
+			{Key: "username", Value: "$referrer.username"},
+			{Key: "email", Value: "$referrer.email"},
+		}}},
+		bson.D{{Key: "$unset", Value: "referrer"}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return []*TopReferrer{}, err
+	}
+	defer cursor.Close(ctx)
+
+	referrers := []*TopReferrer{}
+	if err := cursor.All(ctx, &referrers); err != nil {
+		return []*TopReferrer{}, err
+	}
+	return referrers, nil
+}
+
+func referralGroupStage(by string) bson.D {
+	return bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: by},
+		{Key: "total", Value: bson.D{{Key: "$sum", Value: 1}}},
+		{Key: "onboarded", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+			bson.D{{Key: "$gt", Value: bson.A{"$onboarded_at", nil}}},
+			1,
+			0,
+		}}}}}},
+		{Key: "first_referred_at", Value: bson.D{{Key: "$min", Value: "$signed_up_at"}}},
+		{Key: "last_referred_at", Value: bson.D{{Key: "$max", Value: "$signed_up_at"}}},
+	}}}
+}
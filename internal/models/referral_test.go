@@ -0,0 +1,205 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func newTestReferralModel(db *mongo.Database) *ReferralModel {
+	return NewReferralModel(db.Collection("referrals"), zerolog.Nop())
+}
+
+func insertTestReferral(t *testing.T, referrals *ReferralModel, referrerID, refereeID bson.ObjectID) *Referral {
+	t.Helper()
+
+	referral, err := referrals.Insert(&Referral{
+		ReferrerID: referrerID,
+		RefereeID:  refereeID,
+		Source:     ReferralSourceCode,
+	})
+	if err != nil {
+		t.Fatalf("inserting referral: %v", err)
+	}
+	return referral
+}
+
+func TestNormalizeReferralCode(t *testing.T) {
+	if got := NormalizeReferralCode("  ab3cd4ef \n"); got != "AB3CD4EF" {
+		t.Errorf("got %q, want %q", got, "AB3CD4EF")
+	}
+}
+
+func TestNewReferralCode(t *testing.T) {
+	for range 100 {
+		code, err := newReferralCode()
+		if err != nil {
+			t.Fatalf("generating code: %v", err)
+		}
+		if len(code) != referralCodeLength {
+			t.Fatalf("got code %q of length %d, want %d", code, len(code), referralCodeLength)
+		}
+		if i := strings.IndexFunc(code, func(r rune) bool { return !strings.ContainsRune(referralCodeAlphabet, r) }); i >= 0 {
+			t.Fatalf("got code %q with %q out of the alphabet", code, code[i])
+		}
+		if NormalizeReferralCode(code) != code {
+			t.Fatalf("got code %q not normalized", code)
+		}
+	}
+}
+
+func TestReferralAttribution(t *testing.T) {
+	db := testDatabase(t)
+	users := newTestUserModel(db, &RegexSearchBackend{})
+	referrals := newTestReferralModel(db)
+
+	referrer := insertTestUser(t, users, "Rita Referrer", nil)
+	other := insertTestUser(t, users, "Oscar Other", nil)
+	referee := insertTestUser(t, users, "Eve Referee", nil)
+
+	referrer, err := users.EnsureReferralCode(referrer)
+	if err != nil {
+		t.Fatalf("ensuring referral code: %v", err)
+	}
+	code := referrer.ReferralCode
+	again, err := users.EnsureReferralCode(&User{ID: referrer.ID})
+	if err != nil {
+		t.Fatalf("ensuring referral code again: %v", err)
+	}
+	if again.ReferralCode != code {
+		t.Errorf("got code %q the second time, want %q", again.ReferralCode, code)
+	}
+
+	// Codes are typed by hand, the case and the spaces around don't matter
+	found, err := users.GetByReferralCode(" " + strings.ToLower(code) + " ")
+	if err != nil {
+		t.Fatalf("getting by referral code: %v", err)
+	}
+	if found.ID != referrer.ID {
+		t.Errorf("got referrer %s, want %s", found.FullName, referrer.FullName)
+	}
+
+	insertTestReferral(t, referrals, referrer.ID, referee.ID)
+	// Whoever brought the referee in first keeps the referral
+	if _, err := referrals.Insert(&Referral{ReferrerID: other.ID, RefereeID: referee.ID, Source: ReferralSourceCode}); !mongo.IsDuplicateKeyError(err) {
+		t.Errorf("referring the referee again: got %v, want a duplicate key error", err)
+	}
+
+	stats, err := referrals.Stats(referrer.ID)
+	if err != nil {
+		t.Fatalf("getting stats: %v", err)
+	}
+	if stats.Total != 1 || stats.Onboarded != 0 {
+		t.Errorf("got %d referrals, %d onboarded, want 1, 0", stats.Total, stats.Onboarded)
+	}
+	if stats, err := referrals.Stats(other.ID); err != nil || stats.Total != 0 {
+		t.Errorf("got %d referrals for the other user (err %v), want 0", stats.Total, err)
+	}
+
+	for i := range 2 {
+		if err := referrals.MarkOnboarded(referee.ID); err != nil {
+			t.Fatalf("marking onboarded, time %d: %v", i+1, err)
+		}
+	}
+	stats, err = referrals.Stats(referrer.ID)
+	if err != nil {
+		t.Fatalf("getting stats: %v", err)
+	}
+	if stats.Total != 1 || stats.Onboarded != 1 {
+		t.Errorf("got %d referrals, %d onboarded, want 1, 1", stats.Total, stats.Onboarded)
+	}
+}
+
+func TestMarkOnboardedKeepsFirstTime(t *testing.T) {
+	db := testDatabase(t)
+	referrals := newTestReferralModel(db)
+
+	refereeID := bson.NewObjectID()
+	insertTestReferral(t, referrals, bson.NewObjectID(), refereeID)
+
+	onboardedAt := func() time.Time {
+		t.Helper()
+
+		var referral Referral
+		err := db.Collection("referrals").FindOne(context.Background(), bson.D{{Key: "referee_id", Value: refereeID}}).Decode(&referral)
+		if err != nil {
+			t.Fatalf("getting referral: %v", err)
+		}
+		if referral.OnboardedAt == nil {
+			t.Fatal("referral not onboarded")
+		}
+		return *referral.OnboardedAt
+	}
+
+	if err := referrals.MarkOnboarded(refereeID); err != nil {
+		t.Fatalf("marking onboarded: %v", err)
+	}
+	first := onboardedAt()
+
+	time.Sleep(10 * time.Millisecond)
+	if err := referrals.MarkOnboarded(refereeID); err != nil {
+		t.Fatalf("marking onboarded again: %v", err)
+	}
+	if got := onboardedAt(); !got.Equal(first) {
+		t.Errorf("got onboarded at %v, want %v", got, first)
+	}
+}
+
+func TestTopReferrers(t *testing.T) {
+	db := testDatabase(t)
+	users := newTestUserModel(db, &RegexSearchBackend{})
+	referrals := newTestReferralModel(db)
+
+	// Signups per referrer, and how many of them onboarded
+	referrers := []struct {
+		name      string
+		signups   int
+		onboarded int
+	}{
+		{"Ann", 1, 1},
+		{"Ben", 3, 0},
+		{"Cid", 2, 2},
+		{"Dan", 2, 1},
+	}
+	for _, r := range referrers {
+		referrer := insertTestUser(t, users, r.name, nil)
+		for i := range r.signups {
+			refereeID := bson.NewObjectID()
+			insertTestReferral(t, referrals, referrer.ID, refereeID)
+			if i < r.onboarded {
+				if err := referrals.MarkOnboarded(refereeID); err != nil {
+					t.Fatalf("marking onboarded: %v", err)
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	top, err := referrals.TopReferrers(now.Add(-time.Hour), now.Add(time.Hour), 3)
+	if err != nil {
+		t.Fatalf("getting top referrers: %v", err)
+	}
+	names := []string{}
+	for _, referrer := range top {
+		names = append(names, referrer.FullName)
+	}
+	// Ties on signups are broken by onboarded referees
+	want := []string{"Ben", "Cid", "Dan"}
+	if !slices.Equal(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+
+	top, err = referrals.TopReferrers(now.Add(time.Hour), now.Add(2*time.Hour), 3)
+	if err != nil {
+		t.Fatalf("getting top referrers out of the window: %v", err)
+	}
+	if len(top) != 0 {
+		t.Errorf("got %d referrers out of the window, want 0", len(top))
+	}
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"context"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const atlasUserSearchIndex = "user_full_name_index"
+
+// AtlasSearchBackend searches with the Atlas Search $search stage.
+type AtlasSearchBackend struct{}
+
+func (b *AtlasSearchBackend) Name() string {
+	return SearchBackendAtlas
+}
+
+// EnsureIndexes creates the search index, or updates its definition when it
+// already exists.
+func (b *AtlasSearchBackend) EnsureIndexes(ctx context.Context, coll *mongo.Collection) error {
+	definition := bson.D{{Key: "mappings", Value: bson.D{
+		{Key: "dynamic", Value: true},
+		{Key: "fields", Value: bson.D{
+			// full_name is also indexed as edge grams for autocomplete
+			{Key: "full_name", Value: bson.A{
+				bson.D{{Key: "type", Value: "string"}},
+				bson.D{
+					{Key: "type", Value: "autocomplete"},
+					{Key: "tokenization", Value: "edgeGram"},
+					{Key: "minGrams", Value: 2},
+					{Key: "maxGrams", Value: 15},
+					{Key: "foldDiacritics", Value: true},
+				},
+			}},
+			// Filtered on by atlasVisibleFilter
+			{Key: "friend_ids", Value: bson.D{{Key: "type", Value: "objectId"}}},
+			{Key: "privacy", Value: bson.D{
+				{Key: "type", Value: "document"},
+				{Key: "dynamic", Value: true},
+				{Key: "fields", Value: bson.D{
+					{Key: "location_visibility", Value: bson.D{{Key: "type", Value: "token"}}},
+				}},
+			}},
+		}},
+	}}}
+
+	_, err := coll.SearchIndexes().CreateOne(ctx, mongo.SearchIndexModel{
+		Options:    options.SearchIndexes().SetName(atlasUserSearchIndex),
+		Definition: definition,
+	})
+	if err != nil {
+		return coll.SearchIndexes().UpdateOne(ctx, atlasUserSearchIndex, definition)
+	}
+	return nil
+}
+
+func (b *AtlasSearchBackend) SearchStages(query string, paths ...string) mongo.Pipeline {
+	return mongo.Pipeline{
+		bson.D{{Key: "$search", Value: bson.M{
+			"index": atlasUserSearchIndex,
+			"text": bson.M{
+				"query": query,
+				"path":  paths,
+			},
+		}}},
+	}
+}
+
+func (b *AtlasSearchBackend) UserSearchStages(query UserSearchQuery) mongo.Pipeline {
+	var fuzzy bson.D
+	if query.Fuzzy {
+		fuzzy = bson.D{{Key: "maxEdits", Value: 1}, {Key: "prefixLength", Value: 1}}
+	}
+
+	clause := func(operator string, query string, path string, weight float64, fuzzy bson.D) bson.D {
+		options := bson.D{
+			{Key: "query", Value: query},
+			{Key: "path", Value: path},
+			{Key: "score", Value: bson.D{{Key: "boost", Value: bson.D{{Key: "value", Value: weight}}}}},
+		}
+		if fuzzy != nil {
+			options = append(options, bson.E{Key: "fuzzy", Value: fuzzy})
+		}
+		return bson.D{{Key: operator, Value: options}}
+	}
+
+	// The location only matches for the users sharing it with the viewer, the
+	// filter doesn't add to the score
+	visibleLocation := func(clause bson.D) bson.D {
+		return bson.D{{Key: "compound", Value: bson.D{
+			{Key: "must", Value: bson.A{clause}},
+			{Key: "filter", Value: bson.A{atlasVisibleFilter(query.ViewerID, "location_visibility", DefaultPrivacySettings.LocationVisibility)}},
+		}}}
+	}
+
+	should := bson.A{}
+	highlightPaths := bson.A{"full_name"}
+
+	if query.Ranking.Name > 0 {
+		if query.Autocomplete {
+			should = append(should, clause("autocomplete", query.Text, "full_name", query.Ranking.Name, fuzzy))
+		} else {
+			should = append(should, clause("text", query.Text, "full_name", query.Ranking.Name, fuzzy))
+		}
+	}
+	if !query.Autocomplete {
+		if query.Ranking.Bio > 0 {
+			should = append(should, clause("text", query.Text, "bio", query.Ranking.Bio, fuzzy))
+			highlightPaths = append(highlightPaths, "bio")
+		}
+		if query.Ranking.Location > 0 {
+			should = append(should, visibleLocation(clause("text", query.Text, "location.city", query.Ranking.Location, fuzzy)))
+			highlightPaths = append(highlightPaths, "location.city")
+			if len(query.CountryCodes) > 0 {
+				should = append(should, visibleLocation(clause("text", strings.Join(query.CountryCodes, " "), "location.country_code", query.Ranking.Location, nil)))
+			}
+		}
+		if query.Ranking.Languages > 0 && len(query.LanguageCodes) > 0 {
+			should = append(should, clause("text", strings.Join(query.LanguageCodes, " "), "languages.code", query.Ranking.Languages, nil))
+		}
+	}
+
+	return mongo.Pipeline{
+		bson.D{{Key: "$search", Value: bson.D{
+			{Key: "index", Value: atlasUserSearchIndex},
+			{Key: "compound", Value: bson.D{
+				{Key: "should", Value: should},
+				{Key: "minimumShouldMatch", Value: 1},
+			}},
+			{Key: "highlight", Value: bson.D{{Key: "path", Value: highlightPaths}}},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.D{
+			{Key: "search_score", Value: bson.D{{Key: "$meta", Value: "searchScore"}}},
+			{Key: "search_highlights", Value: bson.D{{Key: "$meta", Value: "searchHighlights"}}},
+		}}},
+	}
+}
+
+// atlasVisibleFilter is the $search counterpart of visibleCondition. The viewer
+// is left out, the searches never return them.
+func atlasVisibleFilter(viewerID bson.ObjectID, setting string, fallback Visibility) bson.D {
+	path := "privacy." + setting
+	visibility := func(value Visibility) bson.D {
+		is := bson.D{{Key: "equals", Value: bson.D{{Key: "path", Value: path}, {Key: "value", Value: value}}}}
+		if value != fallback {
+			return is
+		}
+		// Matches the users without the setting too, mustNot only excludes
+		// from what another clause matches
+		missing := bson.D{{Key: "compound", Value: bson.D{
+			{Key: "must", Value: bson.A{bson.D{{Key: "exists", Value: bson.D{{Key: "path", Value: "full_name"}}}}}},
+			{Key: "mustNot", Value: bson.A{bson.D{{Key: "exists", Value: bson.D{{Key: "path", Value: path}}}}}},
+		}}}
+		return bson.D{{Key: "compound", Value: bson.D{
+			{Key: "should", Value: bson.A{is, missing}},
+			{Key: "minimumShouldMatch", Value: 1},
+		}}}
+	}
+
+	friends := bson.D{{Key: "compound", Value: bson.D{
+		{Key: "must", Value: bson.A{
+			visibility(VisibilityFriends),
+			bson.D{{Key: "equals", Value: bson.D{{Key: "path", Value: "friend_ids"}, {Key: "value", Value: viewerID}}}},
+		}},
+	}}}
+
+	return bson.D{{Key: "compound", Value: bson.D{
+		{Key: "should", Value: bson.A{visibility(VisibilityEveryone), friends}},
+		{Key: "minimumShouldMatch", Value: 1},
+	}}}
+}
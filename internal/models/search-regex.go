@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// RegexSearchBackend searches with an escaped, case-insensitive regex. It works
+// on any MongoDB deployment and doesn't need a dedicated index.
+type RegexSearchBackend struct{}
+
+func (b *RegexSearchBackend) Name() string {
+	return SearchBackendRegex
+}
+
+func (b *RegexSearchBackend) EnsureIndexes(ctx context.Context, coll *mongo.Collection) error {
+	return nil
+}
+
+func (b *RegexSearchBackend) SearchStages(query string, paths ...string) mongo.Pipeline {
+	pattern := regexp.QuoteMeta(query)
+
+	conditions := bson.A{}
+	for _, path := range paths {
+		conditions = append(conditions, bson.D{{Key: path, Value: bson.D{
+			{Key: "$regex", Value: pattern},
+			{Key: "$options", Value: "i"},
+		}}})
+	}
+
+	return mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "$or", Value: conditions}}}},
+	}
+}
+
+// UserSearchStages matches every term of the query against the searched fields
+// and scores a document with the weights of the fields it matches. Highlights
+// are left to the caller.
+func (b *RegexSearchBackend) UserSearchStages(query UserSearchQuery) mongo.Pipeline {
+	regex := func(pattern string) bson.D {
+		return bson.D{
+			{Key: "$regex", Value: pattern},
+			{Key: "$options", Value: "i"},
+		}
+	}
+	regexMatch := func(path string, pattern string) bson.D {
+		return bson.D{{Key: "$regexMatch", Value: bson.D{
+			{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$" + path, ""}}}},
+			{Key: "regex", Value: pattern},
+			{Key: "options", Value: "i"},
+		}}}
+	}
+	weighted := func(condition bson.D, weight float64) bson.D {
+		return bson.D{{Key: "$cond", Value: bson.A{condition, weight, 0}}}
+	}
+
+	// The location only matches for the users sharing it with the viewer
+	locationVisible := locationVisibleCondition(query.ViewerID)
+	visibleLocation := func(condition bson.D) bson.D {
+		return bson.D{{Key: "$and", Value: bson.A{condition, locationVisible}}}
+	}
+	visibleLocationExpr := func(condition bson.D) bson.D {
+		return bson.D{{Key: "$and", Value: bson.A{condition, locationVisibleExpr(query.ViewerID)}}}
+	}
+
+	conditions := bson.A{}
+	scores := bson.A{}
+
+	for _, pattern := range query.TermPatterns() {
+		if query.Ranking.Name > 0 {
+			namePattern := pattern
+			if query.Autocomplete {
+				namePattern = `(?:^|\s)` + pattern
+			}
+			conditions = append(conditions, bson.D{{Key: "full_name", Value: regex(namePattern)}})
+			scores = append(scores, weighted(regexMatch("full_name", namePattern), query.Ranking.Name))
+		}
+		if query.Autocomplete {
+			continue
+		}
+		if query.Ranking.Bio > 0 {
+			conditions = append(conditions, bson.D{{Key: "bio", Value: regex(pattern)}})
+			scores = append(scores, weighted(regexMatch("bio", pattern), query.Ranking.Bio))
+		}
+		if query.Ranking.Location > 0 {
+			conditions = append(conditions, visibleLocation(bson.D{{Key: "location.city", Value: regex(pattern)}}))
+			scores = append(scores, weighted(visibleLocationExpr(regexMatch("location.city", pattern)), query.Ranking.Location))
+		}
+	}
+
+	if !query.Autocomplete {
+		if query.Ranking.Location > 0 && len(query.CountryCodes) > 0 {
+			conditions = append(conditions, visibleLocation(bson.D{{Key: "location.country_code", Value: bson.D{{Key: "$in", Value: query.CountryCodes}}}}))
+			scores = append(scores, weighted(
+				visibleLocationExpr(bson.D{{Key: "$in", Value: bson.A{"$location.country_code", query.CountryCodes}}}),
+				query.Ranking.Location,
+			))
+		}
+		if query.Ranking.Languages > 0 && len(query.LanguageCodes) > 0 {
+			conditions = append(conditions, bson.D{{Key: "languages.code", Value: bson.D{{Key: "$in", Value: query.LanguageCodes}}}})
+			scores = append(scores, weighted(
+				bson.D{{Key: "$gt", Value: bson.A{
+					bson.D{{Key: "$size", Value: bson.D{{Key: "$setIntersection", Value: bson.A{
+						bson.D{{Key: "$ifNull", Value: bson.A{"$languages.code", bson.A{}}}},
+						query.LanguageCodes,
+					}}}}},
+					0,
+				}}},
+				query.Ranking.Languages,
+			))
+		}
+	}
+
+	pipeline := mongo.Pipeline{}
+	// No field is searched when every weight is off, MongoDB rejects an empty $or
+	if len(conditions) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.D{{Key: "$or", Value: conditions}}}})
+	}
+	return append(pipeline, bson.D{{Key: "$addFields", Value: bson.D{
+		{Key: "search_score", Value: bson.D{{Key: "$add", Value: append(bson.A{0}, scores...)}}},
+	}}})
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestRegexUserSearchStagesMatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		autocomplete bool
+		ranking      UserSearchRanking
+		wantMatch    bool
+	}{
+		{"default ranking", false, DefaultUserSearchRanking, true},
+		{"autocomplete", true, DefaultUserSearchRanking, true},
+		{"autocomplete without the name", true, UserSearchRanking{Bio: 1, Languages: 2, Location: 2}, false},
+		{"every weight off", false, UserSearchRanking{}, false},
+	}
+
+	backend := &RegexSearchBackend{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := NewUserSearchQuery("olivia lisbon", false, tt.autocomplete, tt.ranking)
+			pipeline := backend.UserSearchStages(query)
+
+			matched := false
+			for _, stage := range pipeline {
+				if stage[0].Key != "$match" {
+					continue
+				}
+				matched = true
+				or := stage[0].Value.(bson.D)[0].Value.(bson.A)
+				if len(or) == 0 {
+					t.Error("empty $or")
+				}
+			}
+			if matched != tt.wantMatch {
+				t.Errorf("got $match %t, want %t", matched, tt.wantMatch)
+			}
+		})
+	}
+}
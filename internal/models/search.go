@@ -0,0 +1,66 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	SearchBackendAuto  = "auto"
+	SearchBackendAtlas = "atlas"
+	SearchBackendRegex = "regex"
+)
+
+// SearchBackend builds the pipeline stages used to filter documents by a free
+// text query, so the same pipelines run on Atlas and on community MongoDB.
+type SearchBackend interface {
+	Name() string
+	// EnsureIndexes creates the indexes the backend relies on for coll.
+	EnsureIndexes(ctx context.Context, coll *mongo.Collection) error
+	// SearchStages returns the stages filtering documents whose paths match
+	// query. They must be placed first in the pipeline.
+	SearchStages(query string, paths ...string) mongo.Pipeline
+	// UserSearchStages returns the stages filtering users matching query and
+	// setting their search_score, and search_highlights when the backend
+	// supports it. They must be placed first in the pipeline.
+	UserSearchStages(query UserSearchQuery) mongo.Pipeline
+}
+
+// NewSearchBackend returns the backend of the given kind. With SearchBackendAuto,
+// Atlas Search is used when the deployment supports it and regex otherwise.
+func NewSearchBackend(kind string, coll *mongo.Collection, logger zerolog.Logger) (SearchBackend, error) {
+	switch kind {
+	case SearchBackendAtlas:
+		return &AtlasSearchBackend{}, nil
+	case SearchBackendRegex:
+		return &RegexSearchBackend{}, nil
+	case SearchBackendAuto:
+		if supportsAtlasSearch(coll) {
+			logger.Info().Str("search_backend", SearchBackendAtlas).Msg("Atlas Search detected")
+			return &AtlasSearchBackend{}, nil
+		}
+		logger.Info().Str("search_backend", SearchBackendRegex).Msg("Atlas Search not available, falling back")
+		return &RegexSearchBackend{}, nil
+	default:
+		return nil, fmt.Errorf("unknown search backend %q", kind)
+	}
+}
+
+// supportsAtlasSearch reports whether the deployment answers $listSearchIndexes,
+// which is only available on Atlas.
+func supportsAtlasSearch(coll *mongo.Collection) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	cursor, err := coll.SearchIndexes().List(ctx, nil)
+	if err != nil {
+		return false
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.Err() == nil
+}
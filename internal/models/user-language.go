@@ -0,0 +1,52 @@
+package models
+
+type LanguageLevel = string
+
+// Proficiency levels follow the CEFR scale, with native for first languages.
+const (
+	LanguageLevelA1     LanguageLevel = "A1"
+	LanguageLevelA2     LanguageLevel = "A2"
+	LanguageLevelB1     LanguageLevel = "B1"
+	LanguageLevelB2     LanguageLevel = "B2"
+	LanguageLevelC1     LanguageLevel = "C1"
+	LanguageLevelC2     LanguageLevel = "C2"
+	LanguageLevelNative LanguageLevel = "native"
+)
+
+var LanguageLevels = []LanguageLevel{
+	LanguageLevelA1,
+	LanguageLevelA2,
+	LanguageLevelB1,
+	LanguageLevelB2,
+	LanguageLevelC1,
+	LanguageLevelC2,
+	LanguageLevelNative,
+}
+
+type UserLanguage struct {
+	Code     string        `bson:"code" json:"code"` // ISO 639 code
+	Level    LanguageLevel `bson:"level" json:"level"`
+	Learning bool          `bson:"learning" json:"learning"`
+}
+
+// NativeLanguages returns the codes of the languages the user speaks natively.
+func (u *User) NativeLanguages() []string {
+	codes := []string{}
+	for _, lng := range u.Languages {
+		if lng.Level == LanguageLevelNative {
+			codes = append(codes, lng.Code)
+		}
+	}
+	return codes
+}
+
+// LearningLanguages returns the codes of the languages the user is learning.
+func (u *User) LearningLanguages() []string {
+	codes := []string{}
+	for _, lng := range u.Languages {
+		if lng.Learning {
+			codes = append(codes, lng.Code)
+		}
+	}
+	return codes
+}
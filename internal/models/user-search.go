@@ -0,0 +1,221 @@
+package models
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ucok-man/streamify/internal/geo"
+	"github.com/ucok-man/streamify/internal/languages"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// UserSearchMaxTerms is the maximum number of words of a query taken into account.
+	UserSearchMaxTerms = 5
+	// UserSearchMaxTermLength is the length terms are truncated to.
+	UserSearchMaxTermLength = 32
+	// UserSearchFacetSize is the maximum number of buckets of a language facet.
+	UserSearchFacetSize = 20
+
+	// fuzzyMinTermLength is the length under which terms are matched exactly,
+	// allowing a typo in shorter words matches almost anything.
+	fuzzyMinTermLength = 4
+	// resolveMinTermLength is the length under which terms aren't resolved to a
+	// language or a country, so "in" or "it" stay plain words.
+	resolveMinTermLength = 4
+
+	HighlightTypeHit  = "hit"
+	HighlightTypeText = "text"
+)
+
+// UserSearchRanking holds the weight of every searched field in the score.
+type UserSearchRanking struct {
+	Name      float64
+	Bio       float64
+	Languages float64
+	Location  float64
+}
+
+var DefaultUserSearchRanking = UserSearchRanking{
+	Name:      4,
+	Bio:       1,
+	Languages: 2,
+	Location:  2,
+}
+
+// UserSearchQuery is a parsed user search query as given to a SearchBackend.
+type UserSearchQuery struct {
+	Text          string
+	Terms         []string // lower cased words of Text
+	LanguageCodes []string // languages named in Text
+	CountryCodes  []string // countries named in Text
+	Fuzzy         bool     // tolerate one typo per term
+	Autocomplete  bool     // match prefixes of the name only
+	Ranking       UserSearchRanking
+	// ViewerID is the user searching, the fields users hide from them are
+	// neither matched nor scored.
+	ViewerID bson.ObjectID
+}
+
+// NewUserSearchQuery splits text into terms and resolves the languages and
+// countries it names.
+func NewUserSearchQuery(text string, fuzzy, autocomplete bool, ranking UserSearchRanking) UserSearchQuery {
+	query := UserSearchQuery{
+		Text:          strings.TrimSpace(text),
+		Terms:         []string{},
+		LanguageCodes: []string{},
+		CountryCodes:  []string{},
+		Fuzzy:         fuzzy,
+		Autocomplete:  autocomplete,
+		Ranking:       ranking,
+	}
+
+	for _, term := range strings.Fields(strings.ToLower(query.Text)) {
+		if len(query.Terms) == UserSearchMaxTerms {
+			break
+		}
+		if utf8.RuneCountInString(term) > UserSearchMaxTermLength {
+			term = string([]rune(term)[:UserSearchMaxTermLength])
+		}
+		query.Terms = append(query.Terms, term)
+	}
+
+	candidates := append([]string{query.Text}, query.Terms...)
+	for _, candidate := range candidates {
+		if utf8.RuneCountInString(candidate) < resolveMinTermLength {
+			continue
+		}
+		if code, ok := languages.Normalize(candidate); ok && !slices.Contains(query.LanguageCodes, code) {
+			query.LanguageCodes = append(query.LanguageCodes, code)
+		}
+		if code, ok := geo.CountryCode(candidate); ok && !slices.Contains(query.CountryCodes, code) {
+			query.CountryCodes = append(query.CountryCodes, code)
+		}
+	}
+
+	return query
+}
+
+// TermPatterns returns a regex per term, see termPattern.
+func (q UserSearchQuery) TermPatterns() []string {
+	patterns := make([]string, 0, len(q.Terms))
+	for _, term := range q.Terms {
+		patterns = append(patterns, termPattern(term, q.Fuzzy))
+	}
+	return patterns
+}
+
+// termPattern returns a regex matching term. With fuzzy, terms long enough also
+// match with one character substituted, missing, added, or two adjacent
+// characters swapped.
+func termPattern(term string, fuzzy bool) string {
+	chars := []rune(term)
+	if !fuzzy || len(chars) < fuzzyMinTermLength {
+		return regexp.QuoteMeta(term)
+	}
+
+	quote := func(r []rune) string { return regexp.QuoteMeta(string(r)) }
+
+	variants := []string{quote(chars)}
+	for i := range chars {
+		variants = append(variants,
+			quote(chars[:i])+`\S`+quote(chars[i+1:]), // substituted
+			quote(chars[:i])+quote(chars[i+1:]),      // added in the query
+			quote(chars[:i])+`\S`+quote(chars[i:]),   // missing in the query
+		)
+		if i+1 < len(chars) && chars[i] != chars[i+1] {
+			swapped := append([]rune{}, chars[:i]...)
+			swapped = append(swapped, chars[i+1], chars[i])
+			swapped = append(swapped, chars[i+2:]...)
+			variants = append(variants, quote(swapped))
+		}
+	}
+
+	unique := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		if !slices.Contains(unique, variant) {
+			unique = append(unique, variant)
+		}
+	}
+	return "(?:" + strings.Join(unique, "|") + ")"
+}
+
+type SearchHighlight struct {
+	Path  string                `bson:"path" json:"path"`
+	Texts []SearchHighlightText `bson:"texts" json:"texts"`
+}
+
+type SearchHighlightText struct {
+	Value string `bson:"value" json:"value"`
+	Type  string `bson:"type" json:"type"` // hit or text
+}
+
+// highlightUser splits the searchable text fields of user into matching and
+// non matching fragments. It is used when the backend doesn't highlight.
+func highlightUser(query UserSearchQuery, user *User) []SearchHighlight {
+	highlights := []SearchHighlight{}
+
+	patterns := query.TermPatterns()
+	if len(patterns) == 0 {
+		return highlights
+	}
+	re, err := regexp.Compile("(?i)" + strings.Join(patterns, "|"))
+	if err != nil {
+		return highlights
+	}
+	// Highlight the whole word rather than a shorter fuzzy variant
+	re.Longest()
+
+	fields := []struct {
+		path  string
+		value string
+	}{
+		{"full_name", user.FullName},
+		{"bio", user.Bio},
+		{"location.city", user.Location.City},
+	}
+	if query.Autocomplete {
+		fields = fields[:1]
+	}
+
+	for _, field := range fields {
+		matches := re.FindAllStringIndex(field.value, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		texts := []SearchHighlightText{}
+		last := 0
+		for _, match := range matches {
+			if match[0] > last {
+				texts = append(texts, SearchHighlightText{Value: field.value[last:match[0]], Type: HighlightTypeText})
+			}
+			texts = append(texts, SearchHighlightText{Value: field.value[match[0]:match[1]], Type: HighlightTypeHit})
+			last = match[1]
+		}
+		if last < len(field.value) {
+			texts = append(texts, SearchHighlightText{Value: field.value[last:], Type: HighlightTypeText})
+		}
+
+		highlights = append(highlights, SearchHighlight{Path: field.path, Texts: texts})
+	}
+	return highlights
+}
+
+type UserSearchResult struct {
+	UserWithFriendRequest `bson:",inline"`
+	SearchScore           float64           `bson:"search_score" json:"search_score"`
+	Highlights            []SearchHighlight `bson:"search_highlights" json:"highlights"`
+}
+
+type LanguageFacet struct {
+	Code  string `bson:"_id" json:"code"`
+	Count int64  `bson:"count" json:"count"`
+}
+
+type UserSearchFacets struct {
+	NativeLng   []LanguageFacet `bson:"native_lng" json:"native_lng"`
+	LearningLng []LanguageFacet `bson:"learning_lng" json:"learning_lng"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestUserSearchRespectsPrivacyAndBlocks(t *testing.T) {
+	forEachSearchBackend(t, func(t *testing.T, db *mongo.Database, search SearchBackend) {
+		users := newTestUserModel(db, search)
+
+		lisbon := func(visibility Visibility) func(user *User) {
+			return func(user *User) {
+				user.Location = Location{City: "Lisbon", CountryCode: "PT", Timezone: "Europe/Lisbon"}
+				user.Privacy = DefaultPrivacySettings
+				user.Privacy.LocationVisibility = visibility
+			}
+		}
+
+		viewer := insertTestUser(t, users, "Victor Viewer", nil)
+		insertTestUser(t, users, "Anna Everyone", lisbon(VisibilityEveryone))
+		insertTestUser(t, users, "Bruno Hidden", lisbon(VisibilityOnlyMe))
+		friend := insertTestUser(t, users, "Carla Friend", lisbon(VisibilityFriends))
+		insertTestUser(t, users, "Dario Stranger", lisbon(VisibilityFriends))
+		blocked := insertTestUser(t, users, "Eve Blocked", lisbon(VisibilityEveryone))
+		blocker := insertTestUser(t, users, "Fay Blocker", lisbon(VisibilityEveryone))
+
+		for _, err := range []error{
+			users.AddFriends(viewer.ID, friend.ID),
+			users.AddFriends(friend.ID, viewer.ID),
+			users.Block(viewer.ID, blocked.ID),
+			users.Block(blocker.ID, viewer.ID),
+		} {
+			if err != nil {
+				t.Fatalf("setting up relations: %v", err)
+			}
+		}
+		viewer, err := users.GetById(viewer.ID)
+		if err != nil {
+			t.Fatalf("getting viewer: %v", err)
+		}
+		waitSearchable(t, db, search)
+
+		tests := []struct {
+			name  string
+			query string
+			want  []string
+		}{
+			{"city", "lisbon", []string{"Anna Everyone", "Carla Friend"}},
+			{"country", "portugal", []string{"Anna Everyone", "Carla Friend"}},
+			{"name of a blocked user", "blocked", []string{}},
+			{"name of a blocker", "blocker", []string{}},
+			{"name of a user hiding their location", "bruno", []string{"Bruno Hidden"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				results, _, _, err := users.Search(UserSearchParam{
+					CurrentUser: viewer,
+					Query:       tt.query,
+					Page:        1,
+					PageSize:    10,
+				})
+				if err != nil {
+					t.Fatalf("searching: %v", err)
+				}
+
+				names := []string{}
+				for _, result := range results {
+					names = append(names, result.FullName)
+					for _, highlight := range result.Highlights {
+						if highlight.Path == "location.city" && result.Location.City == "" {
+							t.Errorf("%s: hidden city highlighted", result.FullName)
+						}
+					}
+				}
+				slices.Sort(names)
+				if !slices.Equal(names, tt.want) {
+					t.Errorf("got %v, want %v", names, tt.want)
+				}
+			})
+		}
+	})
+}
@@ -3,36 +3,52 @@ package models
 import (
 	"context"
 	"errors"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/geo"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type User struct {
-	ID          bson.ObjectID   `bson:"_id,omitempty" json:"id"`
-	FullName    string          `bson:"full_name" json:"full_name"`
-	Email       string          `bson:"email" json:"email"`
-	Password    password        `bson:"inline" json:"-"`
-	Bio         string          `bson:"bio" json:"bio"`
-	ProfilePic  string          `bson:"profile_pic" json:"profile_pic"`
-	NativeLng   string          `bson:"native_lng" json:"native_lng"`
-	LearningLng string          `bson:"learning_lng" json:"learning_lng"`
-	Location    string          `bson:"location" json:"location"`
-	IsOnboarded bool            `bson:"is_onboarded" json:"is_onboarded"`
-	FriendIDs   []bson.ObjectID `bson:"friend_ids" json:"friend_ids"`
-	CreatedAt   time.Time       `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time       `bson:"updated_at" json:"updated_at"`
+	ID                   bson.ObjectID    `bson:"_id,omitempty" json:"id"`
+	FullName             string           `bson:"full_name" json:"full_name"`
+	Username             string           `bson:"username,omitempty" json:"username"`
+	UsernameKey          string           `bson:"username_key,omitempty" json:"-"` // lower cased, unique
+	Email                string           `bson:"email" json:"email"`
+	Password             password         `bson:"inline" json:"-"`
+	Bio                  string           `bson:"bio" json:"bio"`
+	ProfilePic           string           `bson:"profile_pic" json:"profile_pic"`
+	Languages            []UserLanguage   `bson:"languages" json:"languages"`
+	Location             Location         `bson:"location" json:"location"`
+	IsOnboarded          bool             `bson:"is_onboarded" json:"is_onboarded"`
+	FriendIDs            []bson.ObjectID  `bson:"friend_ids" json:"friend_ids"`
+	BlockedIDs           []bson.ObjectID  `bson:"blocked_ids,omitempty" json:"-"`
+	Privacy              PrivacySettings  `bson:"privacy" json:"-"`
+	UsernameChangedAt    *time.Time       `bson:"username_changed_at,omitempty" json:"-"`
+	ReferralCode         string           `bson:"referral_code,omitempty" json:"-"`
+	LastActiveAt         *time.Time       `bson:"last_active_at,omitempty" json:"-"`
+	EmailPreferences     EmailPreferences `bson:"email_preferences" json:"-"`
+	InactivityRemindedAt *time.Time       `bson:"inactivity_reminded_at,omitempty" json:"-"`
+	DigestSentAt         *time.Time       `bson:"digest_sent_at,omitempty" json:"-"`
+	SuspendedAt          *time.Time       `bson:"suspended_at,omitempty" json:"-"`
+	CreatedAt            time.Time        `bson:"created_at" json:"created_at"`
+	UpdatedAt            time.Time        `bson:"updated_at" json:"updated_at"`
 }
 
 type UserModel struct {
-	logger zerolog.Logger
-	coll   *mongo.Collection
+	logger  zerolog.Logger
+	coll    *mongo.Collection
+	search  SearchBackend
+	cursors *CursorCodec
 }
 
-func NewUserModel(coll *mongo.Collection, logger zerolog.Logger) *UserModel {
+func NewUserModel(coll *mongo.Collection, search SearchBackend, cursors *CursorCodec, logger zerolog.Logger) *UserModel {
 	/* ------------------------- unique email ------------------------- */
 	uniqeEmailIdx := mongo.IndexModel{
 		Keys:    bson.D{{Key: "email", Value: 1}},
@@ -45,21 +61,72 @@ func NewUserModel(coll *mongo.Collection, logger zerolog.Logger) *UserModel {
 	}
 	logger.Info().Str("index_name", name).Msg("Success creating index")
 
-	/* ------------------ text search index fullname ------------------ */
-	name, err = coll.SearchIndexes().CreateOne(context.Background(), mongo.SearchIndexModel{
-		Options: options.SearchIndexes().SetName("user_full_name_index"),
-		Definition: bson.D{{Key: "mappings", Value: bson.D{
-			{Key: "dynamic", Value: true},
-		}}},
-	})
+	/* ------------------------ unique username ------------------------ */
+	// Partial so users without a username don't collide on the missing key.
+	uniqueUsernameIdx := mongo.IndexModel{
+		Keys: bson.D{{Key: "username_key", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.D{
+			{Key: "username_key", Value: bson.D{{Key: "$type", Value: "string"}}},
+		}),
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), uniqueUsernameIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating unique username index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* --------------------- unique referral code ---------------------- */
+	uniqueReferralCodeIdx := mongo.IndexModel{
+		Keys: bson.D{{Key: "referral_code", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.D{
+			{Key: "referral_code", Value: bson.D{{Key: "$type", Value: "string"}}},
+		}),
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), uniqueReferralCodeIdx)
 	if err != nil {
-		panic(err)
+		logger.Fatal().Err(err).Msg("Error creating unique referral code index")
 	}
 	logger.Info().Str("index_name", name).Msg("Success creating index")
 
+	/* -------------------------- last active -------------------------- */
+	lastActiveIdx := mongo.IndexModel{
+		Keys: bson.D{{Key: "last_active_at", Value: -1}},
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), lastActiveIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating last active index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* ------------------- geo index location point ------------------- */
+	geoIdx := mongo.IndexModel{
+		Keys: bson.D{{Key: "location.point", Value: "2dsphere"}},
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), geoIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating location 2dsphere index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* --------------------- search backend indexes -------------------- */
+	// Search indexes are optional, without them search queries fail but the rest
+	// of the API keeps working.
+	err = search.EnsureIndexes(context.Background(), coll)
+	if err != nil {
+		logger.Warn().Err(err).Str("search_backend", search.Name()).Msg("Error creating search index")
+	} else {
+		logger.Info().Str("search_backend", search.Name()).Msg("Success creating search index")
+	}
+
 	return &UserModel{
-		coll:   coll,
-		logger: logger,
+		coll:    coll,
+		search:  search,
+		cursors: cursors,
+		logger:  logger,
 	}
 }
 
@@ -82,6 +149,7 @@ func (m *UserModel) GetByEmail(email string) (*User, error) {
 			return nil, err
 		}
 	}
+	user.Privacy = user.Privacy.withDefaults()
 	return &user, nil
 }
 
@@ -104,6 +172,53 @@ func (m *UserModel) GetById(id bson.ObjectID) (*User, error) {
 			return nil, err
 		}
 	}
+	user.Privacy = user.Privacy.withDefaults()
+	return &user, nil
+}
+
+func (m *UserModel) GetByUsername(username string) (*User, error) {
+	filter := bson.D{{
+		Key:   "username_key",
+		Value: UsernameKey(username),
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var user User
+	err := m.coll.FindOne(ctx, filter).Decode(&user)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	user.Privacy = user.Privacy.withDefaults()
+	return &user, nil
+}
+
+func (m *UserModel) GetByReferralCode(code string) (*User, error) {
+	filter := bson.D{{
+		Key:   "referral_code",
+		Value: NormalizeReferralCode(code),
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var user User
+	err := m.coll.FindOne(ctx, filter).Decode(&user)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	user.Privacy = user.Privacy.withDefaults()
 	return &user, nil
 }
 
@@ -112,6 +227,10 @@ func (m *UserModel) Insert(user *User) (*User, error) {
 	user.CreatedAt = current
 	user.UpdatedAt = current
 	user.FriendIDs = []bson.ObjectID{}
+	if user.Languages == nil {
+		user.Languages = []UserLanguage{}
+	}
+	user.Privacy = user.Privacy.withDefaults()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -143,15 +262,17 @@ func (m *UserModel) Update(user *User) (*User, error) {
 	defer cancel()
 
 	update := bson.D{
-		{Key: "$set", Value: bson.D{{"full_name", user.FullName}}},
-		{Key: "$set", Value: bson.D{{"bio", user.Bio}}},
-		{Key: "$set", Value: bson.D{{"profile_pic", user.ProfilePic}}},
-		{Key: "$set", Value: bson.D{{"native_lng", user.NativeLng}}},
-		{Key: "$set", Value: bson.D{{"learning_lng", user.LearningLng}}},
-		{Key: "$set", Value: bson.D{{"location", user.Location}}},
-		{Key: "$set", Value: bson.D{{"is_onboarded", user.IsOnboarded}}},
-		{Key: "$set", Value: bson.D{{"updated_at", user.UpdatedAt}}},
-		{Key: "$set", Value: bson.D{{"friend_ids", user.FriendIDs}}},
+		{Key: "$set", Value: bson.D{
+			{Key: "full_name", Value: user.FullName},
+			{Key: "bio", Value: user.Bio},
+			{Key: "profile_pic", Value: user.ProfilePic},
+			{Key: "languages", Value: user.Languages},
+			{Key: "location", Value: user.Location},
+			{Key: "is_onboarded", Value: user.IsOnboarded},
+			{Key: "updated_at", Value: user.UpdatedAt},
+			{Key: "friend_ids", Value: user.FriendIDs},
+			{Key: "privacy", Value: user.Privacy},
+		}},
 	}
 
 	_, err := m.coll.UpdateByID(ctx, user.ID, update)
@@ -162,150 +283,276 @@ func (m *UserModel) Update(user *User) (*User, error) {
 	return user, nil
 }
 
-type RecommendedUserParam struct {
-	CurrentUser *User
-	Page        int64
-	PageSize    int64
-	Query       string
-}
+// UpdatePrivacy saves the privacy settings of user only, the rest of the
+// document may have changed since user was read.
+func (m *UserModel) UpdatePrivacy(user *User) (*User, error) {
+	current := time.Now()
+	user.UpdatedAt = current
 
-func (m *UserModel) Recommended(param RecommendedUserParam) ([]*UserWithFriendRequest, Metadata, error) {
-	matchStage := bson.D{{Key: "$match", Value: bson.D{
-		{"$and", bson.A{
-			bson.D{{"_id", bson.D{{"$ne", param.CurrentUser.ID}}}},
-			bson.D{{"_id", bson.D{{"$nin", param.CurrentUser.FriendIDs}}}},
-			bson.D{{"is_onboarded", true}},
-		}},
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "privacy", Value: user.Privacy},
+		{Key: "updated_at", Value: current},
 	}}}
 
-	var searchStage bson.D
-	if param.Query != "" {
-		searchStage = bson.D{{Key: "$search", Value: bson.M{
-			"index": "user_full_name_index",
-			"text": bson.M{
-				"query": param.Query,
-				"path":  "full_name",
-			},
-		}}}
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
 
-	lookupStageSentFriendRequest := bson.D{{Key: "$lookup", Value: bson.D{
-		{Key: "from", Value: "friend_request"},
-		{Key: "let", Value: bson.D{
-			{Key: "recipientId", Value: "$_id"},
-		}},
-		{Key: "pipeline", Value: bson.A{
-			bson.D{{Key: "$match", Value: bson.D{
-				{Key: "$expr", Value: bson.D{
-					{Key: "$and", Value: bson.A{
-						bson.D{{Key: "$eq", Value: bson.A{"$sender_id", param.CurrentUser.ID}}},
-						bson.D{{Key: "$eq", Value: bson.A{"$recipient_id", "$$recipientId"}}},
-					}},
-				}},
-			}}},
-		}},
-		{Key: "as", Value: "sent_friend_request"},
-	}}}
+	_, err := m.coll.UpdateByID(ctx, user.ID, update)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
 
-	lookupStageFromFriendRequest := bson.D{{Key: "$lookup", Value: bson.D{
-		{Key: "from", Value: "friend_request"},
-		{Key: "let", Value: bson.D{
-			{Key: "recipientId", Value: "$_id"},
-		}},
-		{Key: "pipeline", Value: bson.A{
-			bson.D{{Key: "$match", Value: bson.D{
-				{Key: "$expr", Value: bson.D{
-					{Key: "$and", Value: bson.A{
-						bson.D{{Key: "$eq", Value: bson.A{"$sender_id", "$$recipientId"}}},
-						bson.D{{Key: "$eq", Value: bson.A{"$recipient_id", param.CurrentUser.ID}}},
-					}},
-				}},
-			}}},
-		}},
-		{Key: "as", Value: "from_friend_request"},
-	}}}
+// SetPassword replaces the password of user, which Password.Set hashed.
+func (m *UserModel) SetPassword(user *User) (*User, error) {
+	current := time.Now()
+	user.UpdatedAt = current
 
-	addFieldsStage := bson.D{{Key: "$addFields", Value: bson.D{
-		{Key: "has_friend_request", Value: bson.D{
-			{Key: "$or", Value: bson.A{
-				bson.D{{Key: "$gt", Value: bson.A{bson.D{{Key: "$size", Value: "$sent_friend_request"}}, 0}}},
-				bson.D{{Key: "$gt", Value: bson.A{bson.D{{Key: "$size", Value: "$from_friend_request"}}, 0}}},
-			}},
-		}},
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "password", Value: user.Password.Hash},
+		{Key: "updated_at", Value: current},
 	}}}
 
-	sortStage := bson.D{{Key: "$sort", Value: bson.D{
-		{Key: "has_friend_request", Value: 1}, // sort if !has_friend_request appear first
-	}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
 
-	skipStage := bson.D{{Key: "$skip", Value: (param.Page - 1) * param.PageSize}}
-	limitStage := bson.D{{Key: "$limit", Value: param.PageSize}}
+	_, err := m.coll.UpdateByID(ctx, user.ID, update)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
 
-	/* ---------------------------------------------------------------- */
-	/*                          Result Pipeline                         */
-	/* ---------------------------------------------------------------- */
+// SetSuspended suspends user, who can't sign in nor use their session
+// anymore, or lifts the suspension.
+func (m *UserModel) SetSuspended(user *User, suspended bool) (*User, error) {
+	current := time.Now()
+	user.UpdatedAt = current
 
-	resultsPipeline := mongo.Pipeline{}
-	if searchStage != nil {
-		resultsPipeline = append(resultsPipeline, searchStage)
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "suspended_at", Value: current},
+		{Key: "updated_at", Value: current},
+	}}}
+	user.SuspendedAt = &current
+	if !suspended {
+		update = bson.D{
+			{Key: "$unset", Value: bson.D{{Key: "suspended_at", Value: ""}}},
+			{Key: "$set", Value: bson.D{{Key: "updated_at", Value: current}}},
+		}
+		user.SuspendedAt = nil
 	}
-	resultsPipeline = append(resultsPipeline,
-		matchStage,
-		lookupStageSentFriendRequest,
-		lookupStageFromFriendRequest,
-		addFieldsStage,
-		sortStage,
-		skipStage,
-		limitStage,
-	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	cursor, err := m.coll.Aggregate(ctx, resultsPipeline)
+	_, err := m.coll.UpdateByID(ctx, user.ID, update)
 	if err != nil {
-		return []*UserWithFriendRequest{}, Metadata{}, err
+		return nil, err
 	}
-	defer cursor.Close(ctx)
+	return user, nil
+}
 
-	var results []*UserWithFriendRequest
-	if err := cursor.All(ctx, &results); err != nil {
-		return []*UserWithFriendRequest{}, Metadata{}, err
+// SetUsername changes the username of user. Neither the first username nor
+// changing only the case count as a change for the cooldown.
+func (m *UserModel) SetUsername(user *User, username string) (*User, error) {
+	current := time.Now()
+
+	set := bson.D{
+		{Key: "username", Value: username},
+		{Key: "username_key", Value: UsernameKey(username)},
+		{Key: "updated_at", Value: current},
+	}
+	if user.UsernameKey != "" && UsernameKey(username) != user.UsernameKey {
+		set = append(set, bson.E{Key: "username_changed_at", Value: current})
+		user.UsernameChangedAt = &current
 	}
 
-	if len(results) == 0 {
-		return []*UserWithFriendRequest{}, Metadata{}, nil
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.UpdateByID(ctx, user.ID, bson.D{{Key: "$set", Value: set}})
+	if err != nil {
+		switch {
+		case mongo.IsDuplicateKeyError(err):
+			return nil, ErrDuplicateUsername
+		default:
+			return nil, err
+		}
 	}
 
-	/* ---------------------------------------------------------------- */
-	/*                          Count Pipeline                          */
-	/* ---------------------------------------------------------------- */
+	user.Username = username
+	user.UsernameKey = UsernameKey(username)
+	user.UpdatedAt = current
+	return user, nil
+}
 
-	countPipeline := mongo.Pipeline{}
-	if searchStage != nil {
-		countPipeline = append(countPipeline, searchStage)
+// EnsureReferralCode gives user a referral code the first time they need one.
+func (m *UserModel) EnsureReferralCode(user *User) (*User, error) {
+	if user.ReferralCode != "" {
+		return user, nil
 	}
-	countPipeline = append(countPipeline, matchStage)
-	countPipeline = append(countPipeline, bson.D{{Key: "$count", Value: "total"}})
 
-	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	cursor, err = m.coll.Aggregate(ctx, countPipeline)
+	// Retry the rare code already taken by someone else
+	for attempt := 0; attempt < 3; attempt++ {
+		code, err := newReferralCode()
+		if err != nil {
+			return nil, err
+		}
+
+		filter := bson.D{
+			{Key: "_id", Value: user.ID},
+			{Key: "referral_code", Value: bson.D{{Key: "$exists", Value: false}}},
+		}
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "referral_code", Value: code}}}}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+		var updated User
+		err = m.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+		switch {
+		case err == nil:
+			user.ReferralCode = updated.ReferralCode
+			return user, nil
+		case errors.Is(err, mongo.ErrNoDocuments):
+			// Set concurrently by another request
+			return m.GetById(user.ID)
+		case mongo.IsDuplicateKeyError(err):
+			continue
+		default:
+			return nil, err
+		}
+	}
+	return nil, errors.New("error generating unique referral code")
+}
+
+type RecommendedUserParam struct {
+	CurrentUser      *User
+	Page             int64
+	PageSize         int64
+	Query            string
+	Location         string
+	ActiveWithinDays int64
+	NativeLng        []string // ISO 639 codes, any of
+	LearningLng      []string // ISO 639 codes, any of
+	WithinKm         float64  // requires CurrentUser.Location.Point
+	OverlapHours     int64    // minimum shared waking hours, requires CurrentUser.Location.Timezone
+	Cursor           string   // switches to cursor pagination, Page is ignored
+}
+
+func (m *UserModel) Recommended(param RecommendedUserParam) ([]*UserWithFriendRequest, Metadata, error) {
+	ks := keyset{listing: "recommended", keys: []sortKey{
+		{Field: "has_friend_request", Order: 1}, // sort if !has_friend_request appear first
+		{Field: "match_score", Order: -1},
+		{Field: "mutual_friends_count", Order: -1},
+		{Field: "_id", Order: 1},
+	}}
+
+	// The ranking changes as requests are sent and time passes, every page is
+	// ranked as the first one was so the cursors neither skip nor repeat users.
+	rankedAt, err := ks.rankedAt(m.cursors, param.Cursor)
 	if err != nil {
 		return []*UserWithFriendRequest{}, Metadata{}, err
 	}
-	defer cursor.Close(ctx)
 
-	var count []struct {
-		Total int64 `bson:"total"`
+	conditions := bson.A{
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$ne", Value: param.CurrentUser.ID}}}},
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$nin", Value: param.CurrentUser.FriendIDs}}}},
+		bson.D{{Key: "is_onboarded", Value: true}},
+		discoverableCondition(),
+		notBlockedCondition(param.CurrentUser, ""),
 	}
-	if err := cursor.All(ctx, &count); err != nil {
-		return []*UserWithFriendRequest{}, Metadata{}, err
+	// Filtering on the location of users hiding it from the viewer would
+	// reveal it, they only match when they share it.
+	visibleLocation := func(condition bson.D) bson.D {
+		return bson.D{{Key: "$and", Value: bson.A{condition, locationVisibleCondition(param.CurrentUser.ID)}}}
+	}
+	if param.Location != "" {
+		conditions = append(conditions, visibleLocation(bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "location.city", Value: bson.D{
+				{Key: "$regex", Value: regexp.QuoteMeta(param.Location)},
+				{Key: "$options", Value: "i"},
+			}}},
+			bson.D{{Key: "location.country_code", Value: strings.ToUpper(param.Location)}},
+		}}}))
+	}
+	if param.WithinKm > 0 && param.CurrentUser.Location.Point != nil {
+		conditions = append(conditions, visibleLocation(bson.D{{Key: "location.point", Value: bson.D{
+			{Key: "$geoWithin", Value: bson.D{
+				{Key: "$centerSphere", Value: bson.A{
+					param.CurrentUser.Location.Point.Coordinates,
+					geo.KmToRadians(param.WithinKm),
+				}},
+			}},
+		}}}))
+	}
+	if param.OverlapHours > 0 && param.CurrentUser.Location.Timezone != "" {
+		timezones, err := m.timezonesOverlapping(param.CurrentUser.Location.Timezone, time.Duration(param.OverlapHours)*time.Hour)
+		if err != nil {
+			return []*UserWithFriendRequest{}, Metadata{}, err
+		}
+		conditions = append(conditions, visibleLocation(bson.D{{Key: "location.timezone", Value: bson.D{{Key: "$in", Value: timezones}}}}))
+	}
+	if param.ActiveWithinDays > 0 {
+		activeSince := rankedAt.AddDate(0, 0, -int(param.ActiveWithinDays))
+		conditions = append(conditions, activeSinceCondition(param.CurrentUser.ID, activeSince))
+	}
+	if len(param.NativeLng) > 0 {
+		conditions = append(conditions, bson.D{{Key: "languages", Value: bson.D{{Key: "$elemMatch", Value: bson.D{
+			{Key: "code", Value: bson.D{{Key: "$in", Value: param.NativeLng}}},
+			{Key: "level", Value: LanguageLevelNative},
+		}}}}})
+	}
+	if len(param.LearningLng) > 0 {
+		conditions = append(conditions, bson.D{{Key: "languages", Value: bson.D{{Key: "$elemMatch", Value: bson.D{
+			{Key: "code", Value: bson.D{{Key: "$in", Value: param.LearningLng}}},
+			{Key: "learning", Value: true},
+		}}}}})
+	}
+
+	matchStage := bson.D{{Key: "$match", Value: bson.D{
+		{Key: "$and", Value: conditions},
+	}}}
+
+	var searchStages mongo.Pipeline
+	if param.Query != "" {
+		searchStages = m.search.SearchStages(param.Query, "full_name")
 	}
 
-	metadata := calculateMetadata(count[0].Total, param.Page, param.PageSize)
-	return results, metadata, nil
+	lookupStageSentFriendRequest := lookupSentFriendRequestStage(param.CurrentUser.ID)
+	lookupStageFromFriendRequest := lookupFromFriendRequestStage(param.CurrentUser.ID)
+
+	pipeline := mongo.Pipeline{}
+	pipeline = append(pipeline, searchStages...)
+	pipeline = append(pipeline,
+		matchStage,
+		lookupStageSentFriendRequest,
+		lookupStageFromFriendRequest,
+		addFieldsHasFriendRequestStage(rankedAt),
+	)
+	pipeline = append(pipeline, languageMatchStages(param.CurrentUser)...)
+	pipeline = append(pipeline, activityScoreStages(param.CurrentUser.ID, rankedAt)...)
+	pipeline = append(pipeline,
+		addFieldsMutualFriendsStage(param.CurrentUser),
+		addFieldsMutualFriendsCountStage(),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lookup := mongo.Pipeline{
+		lookupMutualFriendsStage(),
+		publicProfileStage(param.CurrentUser.ID, ""),
+	}
+
+	return aggregatePage[UserWithFriendRequest](ctx, m.coll, m.cursors, pipeline, lookup, ks, pagination{
+		page:     param.Page,
+		pageSize: param.PageSize,
+		cursor:   param.Cursor,
+		rankedAt: rankedAt,
+	})
 }
 
 type MyFriendsParam struct {
@@ -313,6 +560,7 @@ type MyFriendsParam struct {
 	Query       string
 	Page        int64
 	PageSize    int64
+	Cursor      string // switches to cursor pagination, Page is ignored
 }
 
 func (m *UserModel) MyFriends(param MyFriendsParam) ([]*User, Metadata, error) {
@@ -322,91 +570,514 @@ func (m *UserModel) MyFriends(param MyFriendsParam) ([]*User, Metadata, error) {
 		"is_onboarded": true,
 	}}}
 
-	// Step 2: Optional search by full_name using Atlas Search
-	var searchStage bson.D
+	// Step 2: Optional search by full_name
+	var searchStages mongo.Pipeline
 	if param.Query != "" {
-		searchStage = bson.D{{Key: "$search", Value: bson.M{
-			"index": "user_full_name_index",
-			"text": bson.M{
-				"query": param.Query,
-				"path":  "full_name",
-			},
-		}}}
+		searchStages = m.search.SearchStages(param.Query, "full_name")
+	}
+
+	pipeline := mongo.Pipeline{}
+	pipeline = append(pipeline, searchStages...)
+	pipeline = append(pipeline, matchStage)
+
+	// Step 3: Pagination by name
+	ks := keyset{listing: "friends", keys: []sortKey{
+		{Field: "full_name", Order: 1},
+		{Field: "_id", Order: 1},
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lookup := mongo.Pipeline{
+		publicProfileStage(param.CurrentUser.ID, ""),
+	}
+
+	return aggregatePage[User](ctx, m.coll, m.cursors, pipeline, lookup, ks, pagination{
+		page:     param.Page,
+		pageSize: param.PageSize,
+		cursor:   param.Cursor,
+	})
+}
+
+func (m *UserModel) AddFriends(id bson.ObjectID, friendId bson.ObjectID) error {
+	filter := bson.D{{"_id", id}}
+	update := bson.D{{"$push", bson.D{{"friend_ids", friendId}}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.UpdateOne(ctx, filter, update, options.UpdateOne())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RemoveFriends removes friendId from the friends of id.
+func (m *UserModel) RemoveFriends(id bson.ObjectID, friendId bson.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$pull", Value: bson.D{{Key: "friend_ids", Value: friendId}}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// Block adds blockedId to the users id blocked, they stop being friends.
+func (m *UserModel) Block(id bson.ObjectID, blockedId bson.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	update := bson.D{
+		{Key: "$addToSet", Value: bson.D{{Key: "blocked_ids", Value: blockedId}}},
+		{Key: "$pull", Value: bson.D{{Key: "friend_ids", Value: blockedId}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	return m.RemoveFriends(blockedId, id)
+}
+
+func (m *UserModel) Unblock(id bson.ObjectID, blockedId bson.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$pull", Value: bson.D{{Key: "blocked_ids", Value: blockedId}}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// WithFriends returns the next limit users having friends, by id after
+// afterID, only their ids and friend ids are set. Start with the zero
+// ObjectID.
+func (m *UserModel) WithFriends(afterID bson.ObjectID, limit int64) ([]*User, error) {
+	filter := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$gt", Value: afterID}}},
+		{Key: "friend_ids.0", Value: bson.D{{Key: "$exists", Value: true}}},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(limit).
+		SetProjection(bson.D{{Key: "friend_ids", Value: 1}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
+
+	users := []*User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (m *UserModel) MutualFriends(user *User, other *User) (int64, []*MutualFriend, error) {
+	mutualIds := mutualFriendIDs(user.FriendIDs, other.FriendIDs)
+	if len(mutualIds) == 0 {
+		return 0, []*MutualFriend{}, nil
+	}
+
+	previewIds := mutualIds[:min(len(mutualIds), MutualFriendsPreviewSize)]
+	filter := bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: previewIds}}}}
+	opts := options.Find().SetProjection(bson.D{
+		{Key: "full_name", Value: 1},
+		{Key: "profile_pic", Value: 1},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	cursor, err := m.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, []*MutualFriend{}, err
+	}
+	defer cursor.Close(ctx)
+
+	preview := []*MutualFriend{}
+	if err := cursor.All(ctx, &preview); err != nil {
+		return 0, []*MutualFriend{}, err
+	}
+
+	return int64(len(mutualIds)), preview, nil
+}
+
+const (
+	// PeopleYouMayKnowMaxSeedFriends caps how many of the user's friends are
+	// traversed by $graphLookup, so users with large graphs stay within the
+	// aggregation memory limit.
+	PeopleYouMayKnowMaxSeedFriends = 200
+	// PeopleYouMayKnowMaxCandidates caps how many second degree connections
+	// are ranked, those with the most mutual friends are kept.
+	PeopleYouMayKnowMaxCandidates = 1000
+)
+
+type PeopleYouMayKnowParam struct {
+	CurrentUser *User
+	Page        int64
+	PageSize    int64
+}
+
+// PeopleYouMayKnow returns second degree connections of the current user ranked
+// by the number of mutual friends.
+func (m *UserModel) PeopleYouMayKnow(param PeopleYouMayKnowParam) ([]*UserWithFriendRequest, Metadata, error) {
+	excludeIds := append([]bson.ObjectID{param.CurrentUser.ID}, param.CurrentUser.FriendIDs...)
+
+	matchStage := bson.D{{Key: "$match", Value: bson.D{
+		{Key: "_id", Value: param.CurrentUser.ID},
+	}}}
+
+	// Step 1: Walk friends (degree 0) and friends of friends (degree 1)
+	graphLookupStage := bson.D{{Key: "$graphLookup", Value: bson.D{
+		{Key: "from", Value: "users"},
+		{Key: "startWith", Value: bson.D{{Key: "$slice", Value: bson.A{
+			bson.D{{Key: "$ifNull", Value: bson.A{"$friend_ids", bson.A{}}}},
+			PeopleYouMayKnowMaxSeedFriends,
+		}}}},
+		{Key: "connectFromField", Value: "friend_ids"},
+		{Key: "connectToField", Value: "_id"},
+		{Key: "as", Value: "network"},
+		{Key: "maxDepth", Value: 1},
+		{Key: "depthField", Value: "degree"},
+		{Key: "restrictSearchWithMatch", Value: bson.D{{Key: "is_onboarded", Value: true}}},
+	}}}
+
+	// Step 2: Flatten network into candidate documents
+	unwindStage := bson.D{{Key: "$unwind", Value: "$network"}}
+	candidateMatchStage := bson.D{{Key: "$match", Value: bson.D{
+		{Key: "network.degree", Value: 1},
+		{Key: "network._id", Value: bson.D{{Key: "$nin", Value: excludeIds}}},
+		{Key: "network.privacy.discoverable", Value: bson.D{{Key: "$ne", Value: false}}},
+		{Key: "$and", Value: bson.A{notBlockedCondition(param.CurrentUser, "network.")}},
+	}}}
+	replaceRootStage := bson.D{{Key: "$replaceRoot", Value: bson.D{
+		{Key: "newRoot", Value: "$network"},
+	}}}
+
+	// Step 3: Keep the candidates with the most mutual friends, counted before
+	// the cap so the strongest suggestions are never dropped
+	candidateSortStage := bson.D{{Key: "$sort", Value: bson.D{
+		{Key: "mutual_friends_count", Value: -1},
+		{Key: "_id", Value: 1},
+	}}}
+	candidateLimitStage := bson.D{{Key: "$limit", Value: PeopleYouMayKnowMaxCandidates}}
+
+	// Step 4: Rank by mutual friends, then by language match
+	sortStage := bson.D{{Key: "$sort", Value: bson.D{
+		{Key: "mutual_friends_count", Value: -1},
+		{Key: "match_score", Value: -1},
+		{Key: "_id", Value: 1},
+	}}}
 
-	// Step 3: Pagination
 	skipStage := bson.D{{Key: "$skip", Value: (param.Page - 1) * param.PageSize}}
 	limitStage := bson.D{{Key: "$limit", Value: param.PageSize}}
 
-	/* ---------------------------------------------------------------- */
-	/*                          Result Pipeline                         */
-	/* ---------------------------------------------------------------- */
+	resultsPipeline := mongo.Pipeline{
+		skipStage,
+		limitStage,
+		lookupSentFriendRequestStage(param.CurrentUser.ID),
+		lookupFromFriendRequestStage(param.CurrentUser.ID),
+		addFieldsHasFriendRequestStage(time.Now()),
+		lookupMutualFriendsStage(),
+		publicProfileStage(param.CurrentUser.ID, ""),
+	}
+	countPipeline := mongo.Pipeline{
+		bson.D{{Key: "$count", Value: "total"}},
+	}
+
+	facetStage := bson.D{{Key: "$facet", Value: bson.M{
+		"data":  resultsPipeline,
+		"count": countPipeline,
+	}}}
 
-	resultsPipeline := mongo.Pipeline{}
-	if searchStage != nil {
-		resultsPipeline = append(resultsPipeline, searchStage)
+	pipeline := mongo.Pipeline{
+		matchStage,
+		graphLookupStage,
+		unwindStage,
+		candidateMatchStage,
+		replaceRootStage,
+		addFieldsMutualFriendsStage(param.CurrentUser),
+		addFieldsMutualFriendsCountStage(),
+		candidateSortStage,
+		candidateLimitStage,
 	}
-	resultsPipeline = append(resultsPipeline, matchStage, skipStage, limitStage)
+	pipeline = append(pipeline, languageMatchStages(param.CurrentUser)...)
+	pipeline = append(pipeline, sortStage, facetStage)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	cursor, err := m.coll.Aggregate(ctx, resultsPipeline)
+	cursor, err := m.coll.Aggregate(ctx, pipeline)
 	if err != nil {
-		return []*User{}, Metadata{}, err
+		return []*UserWithFriendRequest{}, Metadata{}, err
 	}
 	defer cursor.Close(ctx)
 
-	var results []*User
-	if err := cursor.All(ctx, &results); err != nil {
-		return []*User{}, Metadata{}, err
+	var rawResult []struct {
+		Data  []*UserWithFriendRequest `bson:"data"`
+		Count []struct {
+			Total int64 `bson:"total"`
+		} `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rawResult); err != nil {
+		return []*UserWithFriendRequest{}, Metadata{}, err
 	}
 
-	if len(results) <= 0 {
-		return []*User{}, Metadata{}, err
+	if len(rawResult) == 0 || len(rawResult[0].Data) == 0 {
+		return []*UserWithFriendRequest{}, Metadata{}, nil
 	}
+	result := rawResult[0]
 
-	/* ---------------------------------------------------------------- */
-	/*                          Count Pipeline                          */
-	/* ---------------------------------------------------------------- */
+	var totalCount int64
+	if len(result.Count) > 0 {
+		totalCount = result.Count[0].Total
+	}
 
-	countPipeline := mongo.Pipeline{}
-	if searchStage != nil {
-		countPipeline = append(countPipeline, searchStage)
+	metadata := calculateMetadata(totalCount, param.Page, param.PageSize)
+	return result.Data, metadata, nil
+}
+
+type UserSearchParam struct {
+	CurrentUser  *User
+	Query        string
+	Fuzzy        bool
+	Autocomplete bool
+	NativeLng    []string // ISO 639 codes, any of
+	LearningLng  []string // ISO 639 codes, any of
+	Ranking      UserSearchRanking
+	Page         int64
+	PageSize     int64
+}
+
+// Search returns the users matching a free text query on their name, bio,
+// languages and location, ranked by relevance, with the number of matching
+// users per native and learning language.
+func (m *UserModel) Search(param UserSearchParam) ([]*UserSearchResult, UserSearchFacets, Metadata, error) {
+	ranking := param.Ranking
+	if ranking == (UserSearchRanking{}) {
+		ranking = DefaultUserSearchRanking
 	}
-	countPipeline = append(countPipeline, matchStage)
-	countPipeline = append(countPipeline, bson.D{{Key: "$count", Value: "total"}})
+	query := NewUserSearchQuery(param.Query, param.Fuzzy, param.Autocomplete, ranking)
+	query.ViewerID = param.CurrentUser.ID
 
-	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	conditions := bson.A{
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$ne", Value: param.CurrentUser.ID}}}},
+		bson.D{{Key: "is_onboarded", Value: true}},
+		discoverableCondition(),
+		notBlockedCondition(param.CurrentUser, ""),
+	}
+	if len(param.NativeLng) > 0 {
+		conditions = append(conditions, bson.D{{Key: "languages", Value: bson.D{{Key: "$elemMatch", Value: bson.D{
+			{Key: "code", Value: bson.D{{Key: "$in", Value: param.NativeLng}}},
+			{Key: "level", Value: LanguageLevelNative},
+		}}}}})
+	}
+	if len(param.LearningLng) > 0 {
+		conditions = append(conditions, bson.D{{Key: "languages", Value: bson.D{{Key: "$elemMatch", Value: bson.D{
+			{Key: "code", Value: bson.D{{Key: "$in", Value: param.LearningLng}}},
+			{Key: "learning", Value: true},
+		}}}}})
+	}
+
+	matchStage := bson.D{{Key: "$match", Value: bson.D{
+		{Key: "$and", Value: conditions},
+	}}}
+
+	sortStage := bson.D{{Key: "$sort", Value: bson.D{
+		{Key: "search_score", Value: -1},
+		{Key: "_id", Value: 1},
+	}}}
+
+	skipStage := bson.D{{Key: "$skip", Value: (param.Page - 1) * param.PageSize}}
+	limitStage := bson.D{{Key: "$limit", Value: param.PageSize}}
+
+	resultsPipeline := mongo.Pipeline{
+		sortStage,
+		skipStage,
+		limitStage,
+		lookupSentFriendRequestStage(param.CurrentUser.ID),
+		lookupFromFriendRequestStage(param.CurrentUser.ID),
+		addFieldsHasFriendRequestStage(time.Now()),
+		addFieldsMutualFriendsStage(param.CurrentUser),
+		addFieldsMutualFriendsCountStage(),
+		lookupMutualFriendsStage(),
+	}
+	resultsPipeline = append(resultsPipeline, languageMatchStages(param.CurrentUser)...)
+	resultsPipeline = append(resultsPipeline, publicProfileStage(param.CurrentUser.ID, ""))
+
+	countPipeline := mongo.Pipeline{
+		bson.D{{Key: "$count", Value: "total"}},
+	}
+
+	languageFacetPipeline := func(language bson.D) mongo.Pipeline {
+		return mongo.Pipeline{
+			bson.D{{Key: "$unwind", Value: "$languages"}},
+			bson.D{{Key: "$match", Value: language}},
+			bson.D{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: "$languages.code"},
+				{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			}}},
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+			bson.D{{Key: "$limit", Value: UserSearchFacetSize}},
+		}
+	}
+
+	facetStage := bson.D{{Key: "$facet", Value: bson.M{
+		"data":         resultsPipeline,
+		"count":        countPipeline,
+		"native_lng":   languageFacetPipeline(bson.D{{Key: "languages.level", Value: LanguageLevelNative}}),
+		"learning_lng": languageFacetPipeline(bson.D{{Key: "languages.learning", Value: true}}),
+	}}}
+
+	pipeline := m.search.UserSearchStages(query)
+	pipeline = append(pipeline, matchStage, facetStage)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	cursor, err = m.coll.Aggregate(ctx, countPipeline)
+	cursor, err := m.coll.Aggregate(ctx, pipeline)
 	if err != nil {
-		return []*User{}, Metadata{}, err
+		return []*UserSearchResult{}, UserSearchFacets{}, Metadata{}, err
 	}
 	defer cursor.Close(ctx)
 
-	var count []struct {
-		Total int64 `bson:"total"`
+	var rawResult []struct {
+		Data  []*UserSearchResult `bson:"data"`
+		Count []struct {
+			Total int64 `bson:"total"`
+		} `bson:"count"`
+		UserSearchFacets `bson:",inline"`
 	}
-	if err := cursor.All(ctx, &count); err != nil {
-		return []*User{}, Metadata{}, err
+	if err := cursor.All(ctx, &rawResult); err != nil {
+		return []*UserSearchResult{}, UserSearchFacets{}, Metadata{}, err
 	}
-	metadata := calculateMetadata(count[0].Total, param.Page, param.PageSize)
 
-	return results, metadata, nil
+	facets := UserSearchFacets{NativeLng: []LanguageFacet{}, LearningLng: []LanguageFacet{}}
+	if len(rawResult) == 0 || len(rawResult[0].Data) == 0 {
+		return []*UserSearchResult{}, facets, Metadata{}, nil
+	}
+	result := rawResult[0]
+
+	for _, user := range result.Data {
+		if len(user.Highlights) == 0 {
+			user.Highlights = highlightUser(query, &user.User)
+		}
+		if user.Location.City == "" {
+			// The location is hidden, Atlas Search highlights it anyway when
+			// another field matched.
+			user.Highlights = slices.DeleteFunc(user.Highlights, func(h SearchHighlight) bool {
+				return h.Path == "location.city"
+			})
+		}
+	}
+
+	var totalCount int64
+	if len(result.Count) > 0 {
+		totalCount = result.Count[0].Total
+	}
+
+	metadata := calculateMetadata(totalCount, param.Page, param.PageSize)
+	return result.Data, result.UserSearchFacets, metadata, nil
 }
 
-func (m *UserModel) AddFriends(id bson.ObjectID, friendId bson.ObjectID) error {
-	filter := bson.D{{"_id", id}}
-	update := bson.D{{"$push", bson.D{{"friend_ids", friendId}}}}
+func lookupSentFriendRequestStage(currentUserId bson.ObjectID) bson.D {
+	return bson.D{{Key: "$lookup", Value: bson.D{
+		{Key: "from", Value: "friend_request"},
+		{Key: "let", Value: bson.D{
+			{Key: "recipientId", Value: "$_id"},
+		}},
+		{Key: "pipeline", Value: bson.A{
+			bson.D{{Key: "$match", Value: bson.D{
+				{Key: "$expr", Value: bson.D{
+					{Key: "$and", Value: bson.A{
+						bson.D{{Key: "$eq", Value: bson.A{"$sender_id", currentUserId}}},
+						bson.D{{Key: "$eq", Value: bson.A{"$recipient_id", "$$recipientId"}}},
+					}},
+				}},
+			}}},
+		}},
+		{Key: "as", Value: "sent_friend_request"},
+	}}}
+}
+
+func lookupFromFriendRequestStage(currentUserId bson.ObjectID) bson.D {
+	return bson.D{{Key: "$lookup", Value: bson.D{
+		{Key: "from", Value: "friend_request"},
+		{Key: "let", Value: bson.D{
+			{Key: "recipientId", Value: "$_id"},
+		}},
+		{Key: "pipeline", Value: bson.A{
+			bson.D{{Key: "$match", Value: bson.D{
+				{Key: "$expr", Value: bson.D{
+					{Key: "$and", Value: bson.A{
+						bson.D{{Key: "$eq", Value: bson.A{"$sender_id", "$$recipientId"}}},
+						bson.D{{Key: "$eq", Value: bson.A{"$recipient_id", currentUserId}}},
+					}},
+				}},
+			}}},
+		}},
+		{Key: "as", Value: "from_friend_request"},
+	}}}
+}
+
+// addFieldsHasFriendRequestStage sets has_friend_request when a request was
+// sent either way until rankedAt, it must run after both friend request
+// lookups.
+func addFieldsHasFriendRequestStage(rankedAt time.Time) bson.D {
+	sentUntil := bson.D{{Key: "$filter", Value: bson.D{
+		{Key: "input", Value: bson.D{{Key: "$concatArrays", Value: bson.A{"$sent_friend_request", "$from_friend_request"}}}},
+		{Key: "as", Value: "request"},
+		{Key: "cond", Value: bson.D{{Key: "$lte", Value: bson.A{"$$request.created_at", rankedAt}}}},
+	}}}
+
+	return bson.D{{Key: "$addFields", Value: bson.D{
+		{Key: "has_friend_request", Value: bson.D{
+			{Key: "$gt", Value: bson.A{bson.D{{Key: "$size", Value: sentUntil}}, 0}},
+		}},
+	}}}
+}
+
+// timezonesOverlapping returns the timezones in use whose waking hours overlap
+// at least minOverlap with the waking hours of timezone. Offsets are computed
+// now, so daylight saving time is taken into account.
+func (m *UserModel) timezonesOverlapping(timezone string, minOverlap time.Duration) ([]string, error) {
+	now := time.Now()
+	offset, err := geo.UTCOffset(timezone, now)
+	if err != nil {
+		return nil, err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := m.coll.UpdateOne(ctx, filter, update, options.UpdateOne())
+	var inUse []string
+	err = m.coll.Distinct(ctx, "location.timezone", bson.D{{Key: "is_onboarded", Value: true}}).Decode(&inUse)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+
+	timezones := []string{}
+	for _, tz := range inUse {
+		other, err := geo.UTCOffset(tz, now)
+		if err != nil {
+			m.logger.Warn().Err(err).Str("timezone", tz).Msg("Unknown timezone in users collection")
+			continue
+		}
+		if geo.WakingOverlap(offset, other) >= minOverlap {
+			timezones = append(timezones, tz)
+		}
+	}
+	return timezones, nil
 }
@@ -0,0 +1,303 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+// walkRecommended lists every recommendation of viewer a user at a time,
+// following the next cursors. between runs after each page.
+func walkRecommended(t *testing.T, users *UserModel, viewer *User, between func(page int)) []string {
+	t.Helper()
+
+	param := RecommendedUserParam{CurrentUser: viewer, Page: 1, PageSize: 1}
+	names := []string{}
+	for page := 1; ; page++ {
+		if page > 10 {
+			t.Fatalf("too many pages: %v", names)
+		}
+
+		recommended, metadata, err := users.Recommended(param)
+		if err != nil {
+			t.Fatalf("page %d: %v", page, err)
+		}
+		for _, user := range recommended {
+			names = append(names, user.FullName)
+		}
+		if metadata.NextCursor == "" {
+			return names
+		}
+		between(page)
+		param.Cursor = metadata.NextCursor
+	}
+}
+
+func TestRecommendedCursorKeepsRankingWhileRequestsAreSent(t *testing.T) {
+	db := testDatabase(t)
+	users := newTestUserModel(db, &RegexSearchBackend{})
+
+	viewer := insertTestUser(t, users, "Victor Viewer", nil)
+	first := insertTestUser(t, users, "User 1", nil)
+	insertTestUser(t, users, "User 2", nil)
+	insertTestUser(t, users, "User 3", nil)
+
+	names := walkRecommended(t, users, viewer, func(page int) {
+		if page == 1 {
+			// Would rank the first user last
+			insertTestFriendRequest(t, db, viewer.ID, first.ID, FriendRequestStatusPending, time.Now())
+		}
+	})
+
+	want := []string{"User 1", "User 2", "User 3"}
+	if !slices.Equal(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
+
+func TestRecommendedCursorKeepsRankingWhileTimePasses(t *testing.T) {
+	db := testDatabase(t)
+	users := newTestUserModel(db, &RegexSearchBackend{})
+
+	viewer := insertTestUser(t, users, "Victor Viewer", nil)
+	// Active a day ago for two more seconds, then ranked after the others
+	almostDay := time.Now().AddDate(0, 0, -1).Add(2 * time.Second)
+	insertTestUser(t, users, "User 1", func(user *User) { user.LastActiveAt = &almostDay })
+	insertTestUser(t, users, "User 2", nil)
+	insertTestUser(t, users, "User 3", nil)
+
+	names := walkRecommended(t, users, viewer, func(page int) {
+		if page == 1 {
+			time.Sleep(time.Until(almostDay.AddDate(0, 0, 1)) + 500*time.Millisecond)
+		}
+	})
+
+	want := []string{"User 1", "User 2", "User 3"}
+	if !slices.Equal(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
+
+func TestRecommendedLocationFiltersSkipHiddenLocations(t *testing.T) {
+	db := testDatabase(t)
+	users := newTestUserModel(db, &RegexSearchBackend{})
+
+	lisbon := func(visibility Visibility) func(user *User) {
+		return func(user *User) {
+			user.Location = Location{
+				City:        "Lisbon",
+				CountryCode: "PT",
+				Point:       &GeoPoint{Type: "Point", Coordinates: []float64{-9.1393, 38.7223}},
+				Timezone:    "Europe/Lisbon",
+			}
+			user.Privacy = DefaultPrivacySettings
+			user.Privacy.LocationVisibility = visibility
+		}
+	}
+
+	viewer := insertTestUser(t, users, "Victor Viewer", lisbon(VisibilityOnlyMe))
+	insertTestUser(t, users, "Everyone", lisbon(VisibilityEveryone))
+	insertTestUser(t, users, "Friends", lisbon(VisibilityFriends))
+	insertTestUser(t, users, "Only Me", lisbon(VisibilityOnlyMe))
+
+	tests := []struct {
+		name  string
+		param RecommendedUserParam
+		want  []string
+	}{
+		{"no filter", RecommendedUserParam{}, []string{"Everyone", "Friends", "Only Me"}},
+		{"city", RecommendedUserParam{Location: "lisbon"}, []string{"Everyone"}},
+		{"country", RecommendedUserParam{Location: "pt"}, []string{"Everyone"}},
+		{"within km", RecommendedUserParam{WithinKm: 50}, []string{"Everyone"}},
+		{"overlap hours", RecommendedUserParam{OverlapHours: 8}, []string{"Everyone"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := tt.param
+			param.CurrentUser = viewer
+			param.Page = 1
+			param.PageSize = 10
+
+			recommended, _, err := users.Recommended(param)
+			if err != nil {
+				t.Fatalf("listing: %v", err)
+			}
+			names := []string{}
+			for _, user := range recommended {
+				names = append(names, user.FullName)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("got %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestListingsSkipBlockedUsers(t *testing.T) {
+	db := testDatabase(t)
+	users := newTestUserModel(db, &RegexSearchBackend{})
+
+	viewer := insertTestUser(t, users, "Victor Viewer", nil)
+	mutual := insertTestUser(t, users, "Mutual Friend", nil)
+	stranger := insertTestUser(t, users, "Anna Stranger", nil)
+	blocked := insertTestUser(t, users, "Eve Blocked", nil)
+	blocker := insertTestUser(t, users, "Fay Blocker", nil)
+
+	relations := []error{
+		users.Block(viewer.ID, blocked.ID),
+		users.Block(blocker.ID, viewer.ID),
+	}
+	// Every other user is a friend of a friend of the viewer
+	for _, other := range []*User{viewer, stranger, blocked, blocker} {
+		relations = append(relations, users.AddFriends(mutual.ID, other.ID), users.AddFriends(other.ID, mutual.ID))
+	}
+	for _, err := range relations {
+		if err != nil {
+			t.Fatalf("setting up relations: %v", err)
+		}
+	}
+	viewer, err := users.GetById(viewer.ID)
+	if err != nil {
+		t.Fatalf("getting viewer: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		list func() ([]*UserWithFriendRequest, Metadata, error)
+	}{
+		{"recommended", func() ([]*UserWithFriendRequest, Metadata, error) {
+			return users.Recommended(RecommendedUserParam{CurrentUser: viewer, Page: 1, PageSize: 10})
+		}},
+		{"people you may know", func() ([]*UserWithFriendRequest, Metadata, error) {
+			return users.PeopleYouMayKnow(PeopleYouMayKnowParam{CurrentUser: viewer, Page: 1, PageSize: 10})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed, _, err := tt.list()
+			if err != nil {
+				t.Fatalf("listing: %v", err)
+			}
+			names := []string{}
+			for _, user := range listed {
+				names = append(names, user.FullName)
+			}
+			want := []string{"Anna Stranger"}
+			if !slices.Equal(names, want) {
+				t.Errorf("got %v, want %v", names, want)
+			}
+		})
+	}
+}
+
+func TestMutualFriendsSkipHiddenFriendLists(t *testing.T) {
+	db := testDatabase(t)
+	users := newTestUserModel(db, &RegexSearchBackend{})
+
+	friendList := func(visibility Visibility) func(user *User) {
+		return func(user *User) {
+			user.Privacy = DefaultPrivacySettings
+			user.Privacy.FriendListVisibility = visibility
+		}
+	}
+
+	viewer := insertTestUser(t, users, "Victor Viewer", nil)
+	mutual := insertTestUser(t, users, "Mutual Friend", nil)
+	shared := insertTestUser(t, users, "Everyone", friendList(VisibilityEveryone))
+	hidden := insertTestUser(t, users, "Only Me", friendList(VisibilityOnlyMe))
+
+	for _, other := range []*User{viewer, shared, hidden} {
+		for _, err := range []error{users.AddFriends(mutual.ID, other.ID), users.AddFriends(other.ID, mutual.ID)} {
+			if err != nil {
+				t.Fatalf("setting up relations: %v", err)
+			}
+		}
+	}
+	viewer, err := users.GetById(viewer.ID)
+	if err != nil {
+		t.Fatalf("getting viewer: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		list func() ([]*UserWithFriendRequest, Metadata, error)
+	}{
+		{"recommended", func() ([]*UserWithFriendRequest, Metadata, error) {
+			return users.Recommended(RecommendedUserParam{CurrentUser: viewer, Page: 1, PageSize: 10})
+		}},
+		{"people you may know", func() ([]*UserWithFriendRequest, Metadata, error) {
+			return users.PeopleYouMayKnow(PeopleYouMayKnowParam{CurrentUser: viewer, Page: 1, PageSize: 10})
+		}},
+	}
+
+	want := map[string]int64{"Everyone": 1, "Only Me": 0}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed, _, err := tt.list()
+			if err != nil {
+				t.Fatalf("listing: %v", err)
+			}
+			got := map[string]int64{}
+			for _, user := range listed {
+				got[user.FullName] = user.MutualFriendsCount
+				if user.FullName == "Only Me" && len(user.MutualFriends) > 0 {
+					t.Errorf("mutual friends of a hidden friend list: %v", user.MutualFriends)
+				}
+			}
+			if !maps.Equal(got, want) {
+				t.Errorf("got mutual friends counts %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestActivityHiddenByPresence(t *testing.T) {
+	db := testDatabase(t)
+	users := newTestUserModel(db, &RegexSearchBackend{})
+
+	// Both active now, only one shares it with the viewer
+	activeNow := func(visibility Visibility) func(user *User) {
+		return func(user *User) {
+			lastActiveAt := time.Now()
+			user.LastActiveAt = &lastActiveAt
+			user.Privacy = DefaultPrivacySettings
+			user.Privacy.PresenceVisibility = visibility
+		}
+	}
+
+	viewer := insertTestUser(t, users, "Victor Viewer", nil)
+	insertTestUser(t, users, "Everyone", activeNow(VisibilityEveryone))
+	insertTestUser(t, users, "Only Me", activeNow(VisibilityOnlyMe))
+
+	t.Run("match score", func(t *testing.T) {
+		listed, _, err := users.Recommended(RecommendedUserParam{CurrentUser: viewer, Page: 1, PageSize: 10})
+		if err != nil {
+			t.Fatalf("listing: %v", err)
+		}
+		got := map[string]int{}
+		for _, user := range listed {
+			got[user.FullName] = user.MatchScore
+		}
+		if got["Everyone"]-got["Only Me"] != ActivityScoreDay {
+			t.Errorf("got match scores %v, want only Everyone boosted by %d", got, ActivityScoreDay)
+		}
+	})
+
+	t.Run("active within days", func(t *testing.T) {
+		listed, _, err := users.Recommended(RecommendedUserParam{CurrentUser: viewer, Page: 1, PageSize: 10, ActiveWithinDays: 1})
+		if err != nil {
+			t.Fatalf("listing: %v", err)
+		}
+		names := []string{}
+		for _, user := range listed {
+			names = append(names, user.FullName)
+		}
+		if want := []string{"Everyone"}; !slices.Equal(names, want) {
+			t.Errorf("got %v, want %v", names, want)
+		}
+	})
+}
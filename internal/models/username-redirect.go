@@ -0,0 +1,113 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// UsernameRedirect keeps an old username pointing at its owner until ExpiresAt.
+type UsernameRedirect struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UsernameKey string        `bson:"username_key" json:"username_key"`
+	UserID      bson.ObjectID `bson:"user_id" json:"user_id"`
+	ExpiresAt   time.Time     `bson:"expires_at" json:"expires_at"`
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+}
+
+type UsernameRedirectModel struct {
+	logger zerolog.Logger
+	coll   *mongo.Collection
+}
+
+func NewUsernameRedirectModel(coll *mongo.Collection, logger zerolog.Logger) *UsernameRedirectModel {
+	/* ----------------------- unique username key --------------------- */
+	uniqueKeyIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "username_key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	name, err := coll.Indexes().CreateOne(context.TODO(), uniqueKeyIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating unique username key index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	/* ------------------------- ttl expires at ------------------------ */
+	ttlIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	name, err = coll.Indexes().CreateOne(context.TODO(), ttlIdx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating expires at ttl index")
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	return &UsernameRedirectModel{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+// GetByUsername returns the redirect of an old username. Expired redirects are
+// ignored, the TTL monitor only removes them periodically.
+func (m *UsernameRedirectModel) GetByUsername(username string) (*UsernameRedirect, error) {
+	filter := bson.D{
+		{Key: "username_key", Value: UsernameKey(username)},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var redirect UsernameRedirect
+	err := m.coll.FindOne(ctx, filter).Decode(&redirect)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &redirect, nil
+}
+
+// Upsert points username at userID for the grace period.
+func (m *UsernameRedirectModel) Upsert(username string, userID bson.ObjectID) error {
+	current := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.UpdateOne(ctx,
+		bson.D{{Key: "username_key", Value: UsernameKey(username)}},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "user_id", Value: userID},
+				{Key: "expires_at", Value: current.Add(UsernameRedirectGracePeriod)},
+			}},
+			{Key: "$setOnInsert", Value: bson.D{
+				{Key: "created_at", Value: current},
+			}},
+		},
+		options.UpdateOne().SetUpsert(true),
+	)
+	return err
+}
+
+// Delete removes the redirect of username, when its owner claims it back.
+func (m *UsernameRedirectModel) Delete(username string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.coll.DeleteOne(ctx, bson.D{{Key: "username_key", Value: UsernameKey(username)}})
+	return err
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// UsernameChangeCooldown is the time a user has to wait between two
+	// username changes, setting the first username is always allowed.
+	UsernameChangeCooldown = 30 * 24 * time.Hour
+	// UsernameRedirectGracePeriod is how long an old username keeps resolving
+	// to its owner, it can't be claimed by another user meanwhile.
+	UsernameRedirectGracePeriod = 90 * 24 * time.Hour
+)
+
+// reservedUsernames can't be claimed, they collide with routes or could be
+// used to impersonate the service.
+var reservedUsernames = []string{
+	"about", "account", "admin", "administrator", "api", "app", "auth",
+	"blog", "chat", "contact", "dashboard", "explore", "friends", "help",
+	"home", "invite", "login", "logout", "me", "messages", "moderator",
+	"notifications", "onboarding", "privacy", "profile", "root", "search",
+	"security", "settings", "signin", "signout", "signup", "staff",
+	"streamify", "support", "system", "terms", "user", "users", "www",
+}
+
+// UsernameKey returns the case-insensitive form usernames are compared by.
+func UsernameKey(username string) string {
+	return strings.ToLower(username)
+}
+
+func IsReservedUsername(username string) bool {
+	return slices.Contains(reservedUsernames, UsernameKey(username))
+}
+
+// UsernameChangeAllowedAt returns when the user can change their username next.
+func (u *User) UsernameChangeAllowedAt() time.Time {
+	if u.UsernameChangedAt == nil {
+		return time.Time{}
+	}
+	return u.UsernameChangedAt.Add(UsernameChangeCooldown)
+}
@@ -0,0 +1,98 @@
+// Package notify records what happens to users as notifications and hands
+// them to the delivery channels registered, such as real-time streams.
+package notify
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Sender delivers a stored notification through another channel. A failing
+// sender doesn't fail the notification, it is kept in the notification center.
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, notification *models.Notification) error
+}
+
+type Service struct {
+	logger        zerolog.Logger
+	notifications *models.NotificationModel
+
+	mu      sync.RWMutex
+	senders []Sender
+}
+
+func New(notifications *models.NotificationModel, logger zerolog.Logger) *Service {
+	return &Service{
+		notifications: notifications,
+		logger:        logger,
+	}
+}
+
+// Register adds a delivery channel to every notification sent from now on.
+func (s *Service) Register(sender Sender) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.senders = append(s.senders, sender)
+}
+
+// Notify stores notification and delivers it through the registered senders.
+func (s *Service) Notify(ctx context.Context, notification *models.Notification) (*models.Notification, error) {
+	notification, err := s.notifications.Insert(notification)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	senders := s.senders
+	s.mu.RUnlock()
+
+	for _, sender := range senders {
+		if err := sender.Send(ctx, notification); err != nil {
+			s.logger.Error().Err(err).
+				Str("sender", sender.Name()).
+				Str("notification_id", notification.ID.Hex()).
+				Msg("Failed delivering notification")
+		}
+	}
+	return notification, nil
+}
+
+// FriendRequestReceived tells the recipient of friendRequest about it.
+func (s *Service) FriendRequestReceived(ctx context.Context, friendRequest *models.FriendRequest) error {
+	_, err := s.Notify(ctx, &models.Notification{
+		UserID:          friendRequest.RecipientID,
+		Type:            models.NotificationFriendRequestReceived,
+		ActorID:         friendRequest.SenderID,
+		FriendRequestID: &friendRequest.ID,
+	})
+	return err
+}
+
+// FriendRequestAccepted tells the sender of friendRequest it was accepted.
+func (s *Service) FriendRequestAccepted(ctx context.Context, friendRequest *models.FriendRequest) error {
+	_, err := s.Notify(ctx, &models.Notification{
+		UserID:          friendRequest.SenderID,
+		Type:            models.NotificationFriendRequestAccepted,
+		ActorID:         friendRequest.RecipientID,
+		FriendRequestID: &friendRequest.ID,
+	})
+	return err
+}
+
+// InviteRedeemed tells the inviter that redeemerID became their friend through
+// invite.
+func (s *Service) InviteRedeemed(ctx context.Context, invite *models.Invite, redeemerID bson.ObjectID, friendRequest *models.FriendRequest) error {
+	_, err := s.Notify(ctx, &models.Notification{
+		UserID:          invite.InviterID,
+		Type:            models.NotificationInviteRedeemed,
+		ActorID:         redeemerID,
+		FriendRequestID: &friendRequest.ID,
+		InviteID:        &invite.ID,
+	})
+	return err
+}
@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// The notifications are stored in a real MongoDB deployment, in a throwaway
+// database, when STREAMIFY_TEST_MONGO_URI is set.
+const testMongoURIEnv = "STREAMIFY_TEST_MONGO_URI"
+
+func newTestNotificationModel(t *testing.T) *models.NotificationModel {
+	t.Helper()
+
+	uri := os.Getenv(testMongoURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", testMongoURIEnv)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	db := client.Database("streamify_test_" + bson.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	return models.NewNotificationModel(db.Collection("notifications"), models.NewCursorCodec("test"), time.Hour, zerolog.Nop())
+}
+
+// recordingSender keeps the notifications it is given, and fails with err.
+type recordingSender struct {
+	name          string
+	err           error
+	notifications []*models.Notification
+}
+
+func (s *recordingSender) Name() string {
+	return s.name
+}
+
+func (s *recordingSender) Send(ctx context.Context, notification *models.Notification) error {
+	s.notifications = append(s.notifications, notification)
+	return s.err
+}
+
+func TestEmitters(t *testing.T) {
+	notifications := newTestNotificationModel(t)
+
+	senderID, recipientID := bson.NewObjectID(), bson.NewObjectID()
+	friendRequest := &models.FriendRequest{ID: bson.NewObjectID(), SenderID: senderID, RecipientID: recipientID}
+	invite := &models.Invite{ID: bson.NewObjectID(), InviterID: recipientID}
+
+	tests := []struct {
+		name         string
+		emit         func(s *Service) error
+		wantUser     bson.ObjectID
+		wantType     models.NotificationType
+		wantActor    bson.ObjectID
+		wantInviteID bool
+	}{
+		{
+			name:      "friend request received",
+			emit:      func(s *Service) error { return s.FriendRequestReceived(context.Background(), friendRequest) },
+			wantUser:  recipientID,
+			wantType:  models.NotificationFriendRequestReceived,
+			wantActor: senderID,
+		},
+		{
+			name:      "friend request accepted",
+			emit:      func(s *Service) error { return s.FriendRequestAccepted(context.Background(), friendRequest) },
+			wantUser:  senderID,
+			wantType:  models.NotificationFriendRequestAccepted,
+			wantActor: recipientID,
+		},
+		{
+			name:         "invite redeemed",
+			emit:         func(s *Service) error { return s.InviteRedeemed(context.Background(), invite, senderID, friendRequest) },
+			wantUser:     recipientID,
+			wantType:     models.NotificationInviteRedeemed,
+			wantActor:    senderID,
+			wantInviteID: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(notifications, zerolog.Nop())
+			sender := &recordingSender{name: "recording"}
+			s.Register(sender)
+
+			if err := tt.emit(s); err != nil {
+				t.Fatalf("emitting: %v", err)
+			}
+			if len(sender.notifications) != 1 {
+				t.Fatalf("got %d notifications sent, want 1", len(sender.notifications))
+			}
+
+			got := sender.notifications[0]
+			if got.ID.IsZero() {
+				t.Error("sent a notification not stored")
+			}
+			if got.UserID != tt.wantUser || got.Type != tt.wantType || got.ActorID != tt.wantActor {
+				t.Errorf("got %s for %s by %s, want %s for %s by %s",
+					got.Type, got.UserID.Hex(), got.ActorID.Hex(), tt.wantType, tt.wantUser.Hex(), tt.wantActor.Hex())
+			}
+			if got.FriendRequestID == nil || *got.FriendRequestID != friendRequest.ID {
+				t.Errorf("got friend request %v, want %s", got.FriendRequestID, friendRequest.ID.Hex())
+			}
+			if hasInvite := got.InviteID != nil && *got.InviteID == invite.ID; hasInvite != tt.wantInviteID {
+				t.Errorf("got invite %v, want it set %t", got.InviteID, tt.wantInviteID)
+			}
+		})
+	}
+}
+
+func TestNotifyKeepsDeliveringPastFailingSenders(t *testing.T) {
+	notifications := newTestNotificationModel(t)
+
+	s := New(notifications, zerolog.Nop())
+	failing := &recordingSender{name: "failing", err: errors.New("unreachable")}
+	working := &recordingSender{name: "working"}
+	s.Register(failing)
+	s.Register(working)
+
+	userID := bson.NewObjectID()
+	notification, err := s.Notify(context.Background(), &models.Notification{
+		UserID:  userID,
+		Type:    models.NotificationFriendRequestReceived,
+		ActorID: bson.NewObjectID(),
+	})
+	if err != nil {
+		t.Fatalf("notifying: %v", err)
+	}
+
+	if len(failing.notifications) != 1 || len(working.notifications) != 1 {
+		t.Errorf("got %d and %d notifications sent, want 1 each", len(failing.notifications), len(working.notifications))
+	}
+	// The notification center keeps it whatever the senders did
+	unread, err := notifications.CountUnread(userID)
+	if err != nil {
+		t.Fatalf("counting unread: %v", err)
+	}
+	if unread != 1 {
+		t.Errorf("got %d unread, want 1", unread)
+	}
+	if !notification.ExpiresAt.After(notification.CreatedAt) {
+		t.Errorf("got expiry %v not after creation %v", notification.ExpiresAt, notification.CreatedAt)
+	}
+}
@@ -0,0 +1,61 @@
+// Package qr renders QR codes in-process, as PNG or SVG.
+package qr
+
+import (
+	"fmt"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// ContentType returns the media type of a rendered format.
+func ContentType(format string) string {
+	if format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Render encodes content into a QR code of about size pixels wide.
+func Render(content string, format string, size int) ([]byte, error) {
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatPNG:
+		return code.PNG(size)
+	case FormatSVG:
+		return svg(code.Bitmap(), size), nil
+	default:
+		return nil, fmt.Errorf("unsupported qr format %q", format)
+	}
+}
+
+// svg draws every dark module of bitmap, quiet zone included, as a square of
+// one unit in a viewBox scaled to size.
+func svg(bitmap [][]bool, size int) []byte {
+	modules := len(bitmap)
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, modules, modules)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#ffffff"/>`, modules, modules)
+	fmt.Fprintf(&b, `<path d="%s" fill="#000000"/>`, path.String())
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
@@ -0,0 +1,57 @@
+// Package realtime fans out events to the users connected to the API, through
+// a PubSub shared by every replica.
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type EventType = string
+
+const (
+	EventFriendRequestReceived EventType = "friend_request.received"
+	EventFriendshipCreated     EventType = "friendship.created"
+	EventNotificationCreated   EventType = "notification.created"
+	EventPresenceChanged       EventType = "presence.changed"
+	EventTypingChanged         EventType = "typing.changed"
+	EventMessageCreated        EventType = "message.created"
+	EventMessageUpdated        EventType = "message.updated"
+	EventMessageDeleted        EventType = "message.deleted"
+	EventMessageRead           EventType = "message.read"
+	// EventResync tells a client resuming from an event no longer in the
+	// replay buffer to refetch its state instead.
+	EventResync EventType = "resync"
+)
+
+// Event is delivered to the connections of UserID. ID is what clients resume
+// after, NewEvent sets an object id in hex that a PubSub may replace with its
+// own position of the event.
+type Event struct {
+	ID        string          `bson:"event_id" json:"id"`
+	UserID    bson.ObjectID   `bson:"user_id" json:"-"`
+	Type      EventType       `bson:"type" json:"type"`
+	Data      json.RawMessage `bson:"data" json:"data"`
+	CreatedAt time.Time       `bson:"created_at" json:"created_at"`
+	// Ephemeral events only matter live, they are never replayed.
+	Ephemeral bool `bson:"ephemeral,omitempty" json:"-"`
+}
+
+// NewEvent returns an event for userID with data encoded as JSON.
+func NewEvent(userID bson.ObjectID, eventType EventType, data any) (Event, error) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	id := bson.NewObjectID()
+	return Event{
+		ID:        id.Hex(),
+		UserID:    userID,
+		Type:      eventType,
+		Data:      js,
+		CreatedAt: id.Timestamp(),
+	}, nil
+}
@@ -0,0 +1,442 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"golang.org/x/time/rate"
+)
+
+type MessageType = string
+
+// Messages sent by the client.
+const (
+	MessageSubscribe   MessageType = "subscribe"
+	MessageUnsubscribe MessageType = "unsubscribe"
+	MessagePing        MessageType = "ping"
+	MessagePresence    MessageType = "presence"
+	MessageTyping      MessageType = "typing"
+)
+
+// Messages sent by the server.
+const (
+	MessageAck   MessageType = "ack"
+	MessagePong  MessageType = "pong"
+	MessageEvent MessageType = "event"
+	MessageError MessageType = "error"
+)
+
+// Error codes of the error messages.
+const (
+	ErrorInvalidMessage   = "invalid_message"
+	ErrorUnknownType      = "unknown_type"
+	ErrorUnknownTopic     = "unknown_topic"
+	ErrorInvalidPresence  = "invalid_presence"
+	ErrorInvalidRecipient = "invalid_recipient"
+	ErrorRateLimited      = "rate_limited"
+	ErrorInternal         = "internal"
+)
+
+// Topics are the event types a connection can subscribe to.
+var Topics = []EventType{
+	EventFriendRequestReceived,
+	EventFriendshipCreated,
+	EventNotificationCreated,
+	EventPresenceChanged,
+	EventTypingChanged,
+	EventMessageCreated,
+	EventMessageUpdated,
+	EventMessageDeleted,
+	EventMessageRead,
+}
+
+// Message is a frame of the gateway protocol, sent both ways as JSON text.
+// The fields used depend on Type.
+type Message struct {
+	Type MessageType `json:"type"`
+	// ID is chosen by the client, the ack, pong or error replying echoes it.
+	ID       string          `json:"id,omitempty"`
+	Topics   []EventType     `json:"topics,omitempty"`   // subscribe, unsubscribe, all when empty
+	Presence models.Presence `json:"presence,omitempty"` // presence, online or away
+	To       string          `json:"to,omitempty"`       // typing, id of a friend
+	Typing   bool            `json:"typing,omitempty"`   // typing
+	Event    *Event          `json:"event,omitempty"`    // event
+	Error    string          `json:"error,omitempty"`    // error
+}
+
+type GatewayOptions struct {
+	// SendBuffer is the number of replies queued for a connection, it is
+	// closed when they pile up.
+	SendBuffer int
+	// RateLimit is the number of messages per second a connection can send,
+	// with bursts of RateBurst. Messages over the limit are rejected, the
+	// connection is closed past RateBurst rejections in a row.
+	RateLimit float64
+	RateBurst int
+	// PingInterval is how often the connection is checked alive.
+	PingInterval time.Duration
+	// WriteTimeout bounds a write or a ping to the client.
+	WriteTimeout time.Duration
+	// MaxMessageSize is the size of the largest message a client can send.
+	MaxMessageSize int64
+}
+
+var DefaultGatewayOptions = GatewayOptions{
+	SendBuffer:     16,
+	RateLimit:      10,
+	RateBurst:      20,
+	PingInterval:   25 * time.Second,
+	WriteTimeout:   10 * time.Second,
+	MaxMessageSize: 4096,
+}
+
+// Gateway serves the WebSocket connections of the users. A connection gets
+// the events of its topics as they are published, it isn't replayed what it
+// missed, clients refetch their state when reconnecting.
+type Gateway struct {
+	pubsub   PubSub
+	users    *models.UserModel
+	presence *PresenceTracker
+	opts     GatewayOptions
+	logger   zerolog.Logger
+
+	mu      sync.Mutex
+	closing bool
+	conns   map[*gatewayConn]struct{}
+	wg      sync.WaitGroup
+}
+
+func NewGateway(pubsub PubSub, users *models.UserModel, presence *PresenceTracker, opts GatewayOptions, logger zerolog.Logger) *Gateway {
+	return &Gateway{
+		pubsub:   pubsub,
+		users:    users,
+		presence: presence,
+		opts:     opts,
+		logger:   logger,
+		conns:    make(map[*gatewayConn]struct{}),
+	}
+}
+
+// Serve runs the protocol on ws for user until either side closes it or the
+// gateway shuts down.
+func (g *Gateway) Serve(ctx context.Context, ws *websocket.Conn, user *models.User) {
+	c := &gatewayConn{
+		gateway: g,
+		ws:      ws,
+		user:    user,
+		out:     make(chan Message, g.opts.SendBuffer),
+		topics:  make(map[EventType]struct{}),
+		limiter: rate.NewLimiter(rate.Limit(g.opts.RateLimit), g.opts.RateBurst),
+	}
+
+	if !g.track(c) {
+		ws.Close(websocket.StatusGoingAway, "server shutting down")
+		return
+	}
+	defer g.untrack(c)
+
+	sub, err := g.pubsub.Subscribe(user.ID)
+	if err != nil {
+		g.logger.Error().Err(err).Str("user_id", user.ID.Hex()).Msg("Failed subscribing gateway connection")
+		ws.Close(websocket.StatusTryAgainLater, "events unavailable")
+		return
+	}
+	defer sub.Close()
+
+	ws.SetReadLimit(g.opts.MaxMessageSize)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.writeLoop(ctx, sub)
+	}()
+
+	c.readLoop(ctx)
+	cancel()
+	<-done
+	c.close(websocket.StatusNormalClosure, "")
+}
+
+// Shutdown closes every connection as going away and waits for them to end,
+// or for ctx to be done. Connections attempted afterwards are refused.
+func (g *Gateway) Shutdown(ctx context.Context) error {
+	g.mu.Lock()
+	g.closing = true
+	conns := make([]*gatewayConn, 0, len(g.conns))
+	for c := range g.conns {
+		conns = append(conns, c)
+	}
+	g.mu.Unlock()
+
+	// Closing waits for the client handshake, don't wait for them one by one
+	for _, c := range conns {
+		go c.close(websocket.StatusGoingAway, "server shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *Gateway) track(c *gatewayConn) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closing {
+		return false
+	}
+	g.conns[c] = struct{}{}
+	g.wg.Add(1)
+	return true
+}
+
+func (g *Gateway) untrack(c *gatewayConn) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.conns, c)
+	g.wg.Done()
+}
+
+type gatewayConn struct {
+	gateway *Gateway
+	ws      *websocket.Conn
+	user    *models.User
+	// out queues the replies, events are written straight from the
+	// subscription which drops the connection when it falls behind.
+	out     chan Message
+	limiter *rate.Limiter
+	// rejected counts the messages over the rate limit in a row.
+	rejected int
+	// presence is the last presence published by the connection.
+	presence models.Presence
+
+	mu        sync.Mutex
+	topics    map[EventType]struct{}
+	closeOnce sync.Once
+}
+
+func (c *gatewayConn) readLoop(ctx context.Context) {
+	for {
+		typ, data, err := c.ws.Read(ctx)
+		if err != nil {
+			return
+		}
+		if typ != websocket.MessageText {
+			c.close(websocket.StatusUnsupportedData, "text messages only")
+			return
+		}
+
+		if !c.limiter.Allow() {
+			c.rejected++
+			if c.rejected > c.gateway.opts.RateBurst {
+				c.close(websocket.StatusPolicyViolation, "rate limit exceeded")
+				return
+			}
+			c.reply(Message{Type: MessageError, Error: ErrorRateLimited})
+			continue
+		}
+		c.rejected = 0
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.reply(Message{Type: MessageError, Error: ErrorInvalidMessage})
+			continue
+		}
+		c.handle(ctx, msg)
+	}
+}
+
+func (c *gatewayConn) handle(ctx context.Context, msg Message) {
+	switch msg.Type {
+	case MessagePing:
+		c.reply(Message{Type: MessagePong, ID: msg.ID})
+
+	case MessageSubscribe, MessageUnsubscribe:
+		topics := msg.Topics
+		if len(topics) == 0 {
+			topics = Topics
+		}
+		for _, topic := range topics {
+			if !slices.Contains(Topics, topic) {
+				c.reply(Message{Type: MessageError, ID: msg.ID, Error: ErrorUnknownTopic})
+				return
+			}
+		}
+
+		c.mu.Lock()
+		for _, topic := range topics {
+			if msg.Type == MessageSubscribe {
+				c.topics[topic] = struct{}{}
+			} else {
+				delete(c.topics, topic)
+			}
+		}
+		c.mu.Unlock()
+		c.reply(Message{Type: MessageAck, ID: msg.ID})
+
+	case MessagePresence:
+		if msg.Presence != models.PresenceOnline && msg.Presence != models.PresenceAway {
+			c.reply(Message{Type: MessageError, ID: msg.ID, Error: ErrorInvalidPresence})
+			return
+		}
+		if err := c.publishPresence(ctx, msg.Presence); err != nil {
+			c.gateway.logger.Error().Err(err).Str("user_id", c.user.ID.Hex()).Msg("Failed publishing presence")
+			c.reply(Message{Type: MessageError, ID: msg.ID, Error: ErrorInternal})
+			return
+		}
+		c.reply(Message{Type: MessageAck, ID: msg.ID})
+
+	case MessageTyping:
+		// The friends are those of the user at connection time
+		friendID, err := bson.ObjectIDFromHex(msg.To)
+		if err != nil || !slices.Contains(c.user.FriendIDs, friendID) {
+			c.reply(Message{Type: MessageError, ID: msg.ID, Error: ErrorInvalidRecipient})
+			return
+		}
+		if err := c.publishTyping(ctx, friendID, msg.Typing); err != nil {
+			c.gateway.logger.Error().Err(err).Str("user_id", c.user.ID.Hex()).Msg("Failed publishing typing")
+			c.reply(Message{Type: MessageError, ID: msg.ID, Error: ErrorInternal})
+			return
+		}
+		c.reply(Message{Type: MessageAck, ID: msg.ID})
+
+	default:
+		c.reply(Message{Type: MessageError, ID: msg.ID, Error: ErrorUnknownType})
+	}
+}
+
+// publishPresence tells the friends of the user that they are now in
+// presence, reporting online also counts as activity. The tracker announces
+// the user away and offline once they stop reporting.
+func (c *gatewayConn) publishPresence(ctx context.Context, presence models.Presence) error {
+	if presence == models.PresenceOnline {
+		if err := c.gateway.users.TouchLastActive(c.user); err != nil {
+			return err
+		}
+	}
+	if presence == c.presence {
+		return nil
+	}
+
+	events, err := PresenceEvents(c.user, presence)
+	if err != nil {
+		return err
+	}
+	if err := c.gateway.pubsub.Publish(ctx, events...); err != nil {
+		return err
+	}
+	c.presence = presence
+	c.gateway.presence.Announced(c.user, presence)
+	return nil
+}
+
+func (c *gatewayConn) publishTyping(ctx context.Context, friendID bson.ObjectID, typing bool) error {
+	event, err := NewEvent(friendID, EventTypingChanged, map[string]any{
+		"user_id": c.user.ID.Hex(),
+		"typing":  typing,
+	})
+	if err != nil {
+		return err
+	}
+	event.Ephemeral = true
+	return c.gateway.pubsub.Publish(ctx, event)
+}
+
+// reply queues msg for the client, a client not reading its replies is
+// disconnected.
+func (c *gatewayConn) reply(msg Message) {
+	select {
+	case c.out <- msg:
+	default:
+		c.close(websocket.StatusPolicyViolation, "too many pending messages")
+	}
+}
+
+func (c *gatewayConn) writeLoop(ctx context.Context, sub *Subscription) {
+	ping := time.NewTicker(c.gateway.opts.PingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case msg := <-c.out:
+			if err := c.write(ctx, msg); err != nil {
+				c.close(websocket.StatusInternalError, "write failed")
+				return
+			}
+
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind, the client reconnects and
+				// refetches its state
+				c.close(websocket.StatusTryAgainLater, "connection fell behind")
+				return
+			}
+			if !c.subscribed(event.Type) {
+				continue
+			}
+			if err := c.write(ctx, Message{Type: MessageEvent, Event: &event}); err != nil {
+				c.close(websocket.StatusInternalError, "write failed")
+				return
+			}
+
+		case <-ping.C:
+			pingCtx, cancel := context.WithTimeout(ctx, c.gateway.opts.WriteTimeout)
+			err := c.ws.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				c.close(websocket.StatusPolicyViolation, "ping timeout")
+				return
+			}
+		}
+	}
+}
+
+func (c *gatewayConn) subscribed(topic EventType) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.topics[topic]
+	return ok
+}
+
+func (c *gatewayConn) write(ctx context.Context, msg Message) error {
+	js, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.gateway.opts.WriteTimeout)
+	defer cancel()
+
+	return c.ws.Write(ctx, websocket.MessageText, js)
+}
+
+// close closes the connection once, the read and write loops return.
+func (c *gatewayConn) close(code websocket.StatusCode, reason string) {
+	c.closeOnce.Do(func() {
+		c.ws.Close(code, reason)
+	})
+}
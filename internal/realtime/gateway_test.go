@@ -0,0 +1,147 @@
+package realtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// dialTestGateway serves a gateway over pubsub for user and returns a client
+// connection to it. The messages tested don't reach the users model.
+func dialTestGateway(t *testing.T, pubsub PubSub, user *models.User, opts GatewayOptions) *websocket.Conn {
+	t.Helper()
+
+	gateway := NewGateway(pubsub, nil, nil, opts, zerolog.Nop())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		gateway.Serve(r.Context(), ws, user)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, _, err := websocket.Dial(ctx, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("dialing gateway: %v", err)
+	}
+	t.Cleanup(func() { ws.CloseNow() })
+	return ws
+}
+
+// roundTrip sends msg and returns the reply.
+func roundTrip(t *testing.T, ws *websocket.Conn, msg Message) Message {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := wsjson.Write(ctx, ws, msg); err != nil {
+		t.Fatalf("writing %s: %v", msg.Type, err)
+	}
+	var reply Message
+	if err := wsjson.Read(ctx, ws, &reply); err != nil {
+		t.Fatalf("reading reply to %s: %v", msg.Type, err)
+	}
+	return reply
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	pubsub := NewMemoryPubSub(DefaultOptions)
+	defer pubsub.Close()
+
+	// The tokens spent aren't given back during the test
+	opts := DefaultGatewayOptions
+	opts.RateLimit = 0.001
+	opts.RateBurst = 2
+	ws := dialTestGateway(t, pubsub, &models.User{ID: bson.NewObjectID()}, opts)
+
+	want := []Message{
+		{Type: MessagePong, ID: "1"},
+		{Type: MessagePong, ID: "2"},
+		{Type: MessageError, Error: ErrorRateLimited},
+		{Type: MessageError, Error: ErrorRateLimited},
+	}
+	for i, want := range want {
+		got := roundTrip(t, ws, Message{Type: MessagePing, ID: strconv.Itoa(i + 1)})
+		if got.Type != want.Type || got.ID != want.ID || got.Error != want.Error {
+			t.Errorf("ping %d: got %+v, want %+v", i+1, got, want)
+		}
+	}
+
+	// Past the burst in rejections in a row, the connection is closed
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := wsjson.Write(ctx, ws, Message{Type: MessagePing, ID: "5"}); err != nil {
+		t.Fatalf("writing ping 5: %v", err)
+	}
+	_, _, err := ws.Read(ctx)
+	if status := websocket.CloseStatus(err); status != websocket.StatusPolicyViolation {
+		t.Errorf("got %v closing, want %v", err, websocket.StatusPolicyViolation)
+	}
+}
+
+func TestGatewayDropsSlowConnections(t *testing.T) {
+	const subscriberBuffer = 2
+	pubsub := NewMemoryPubSub(Options{ReplaySize: 1, ReplayTTL: time.Minute, SubscriberBuffer: subscriberBuffer})
+	defer pubsub.Close()
+
+	user := &models.User{ID: bson.NewObjectID()}
+	ws := dialTestGateway(t, pubsub, user, DefaultGatewayOptions)
+	ws.SetReadLimit(-1)
+
+	reply := roundTrip(t, ws, Message{Type: MessageSubscribe, ID: "1", Topics: []EventType{EventMessageCreated}})
+	if reply.Type != MessageAck {
+		t.Fatalf("got %+v subscribing, want an ack", reply)
+	}
+
+	// Large enough events for the connection to fall behind while the client
+	// doesn't read
+	const published = 100
+	events := make([]Event, 0, published)
+	for range published {
+		event, err := NewEvent(user.ID, EventMessageCreated, strings.Repeat("x", 256<<10))
+		if err != nil {
+			t.Fatalf("building event: %v", err)
+		}
+		events = append(events, event)
+	}
+	if err := pubsub.Publish(context.Background(), events...); err != nil {
+		t.Fatalf("publishing: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := 0
+	for {
+		var msg Message
+		err := wsjson.Read(ctx, ws, &msg)
+		if err != nil {
+			if status := websocket.CloseStatus(err); status != websocket.StatusTryAgainLater {
+				t.Errorf("got %v closing, want %v", err, websocket.StatusTryAgainLater)
+			}
+			break
+		}
+		received++
+	}
+
+	// The event being written when the buffer filled up and the buffered ones
+	if received > subscriberBuffer+1 {
+		t.Errorf("got %d events, want at most %d", received, subscriberBuffer+1)
+	}
+}
@@ -0,0 +1,191 @@
+package realtime
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Options tunes the delivery shared by every PubSub implementation.
+type Options struct {
+	// ReplaySize is the number of recent events kept per user for resuming.
+	ReplaySize int
+	// ReplayTTL is how long an event can be replayed.
+	ReplayTTL time.Duration
+	// SubscriberBuffer is the number of events queued for a slow connection
+	// before it is dropped, the client resumes with Last-Event-ID.
+	SubscriberBuffer int
+}
+
+var DefaultOptions = Options{
+	ReplaySize:       100,
+	ReplayTTL:        10 * time.Minute,
+	SubscriberBuffer: 64,
+}
+
+// Subscription receives the events of a user on C until it is closed, either
+// by Close or by the hub when the subscriber falls behind or on shutdown.
+type Subscription struct {
+	C <-chan Event
+
+	ch     chan Event
+	userID bson.ObjectID
+	hub    *hub
+	once   sync.Once
+}
+
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+// hub tracks the local subscriptions and the replay buffers, the PubSub
+// implementations feed it the events published by any replica.
+type hub struct {
+	opts Options
+
+	mu          sync.Mutex
+	closed      bool
+	subscribers map[bson.ObjectID]map[*Subscription]struct{}
+	buffers     map[bson.ObjectID][]Event
+	done        chan struct{}
+}
+
+func newHub(opts Options) *hub {
+	h := &hub{
+		opts:        opts,
+		subscribers: make(map[bson.ObjectID]map[*Subscription]struct{}),
+		buffers:     make(map[bson.ObjectID][]Event),
+		done:        make(chan struct{}),
+	}
+	go h.prune()
+	return h
+}
+
+func (h *hub) subscribe(userID bson.ObjectID) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+
+	ch := make(chan Event, h.opts.SubscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, userID: userID, hub: h}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+	return sub, nil
+}
+
+func (h *hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// remove must be called with mu held.
+func (h *hub) remove(sub *Subscription) {
+	sub.once.Do(func() {
+		delete(h.subscribers[sub.userID], sub)
+		if len(h.subscribers[sub.userID]) == 0 {
+			delete(h.subscribers, sub.userID)
+		}
+		close(sub.ch)
+	})
+}
+
+// dispatch buffers event for replay and hands it to the local subscribers.
+func (h *hub) dispatch(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	if !event.Ephemeral {
+		buffer := append(h.buffers[event.UserID], event)
+		if len(buffer) > h.opts.ReplaySize {
+			buffer = slices.Clone(buffer[len(buffer)-h.opts.ReplaySize:])
+		}
+		h.buffers[event.UserID] = buffer
+	}
+
+	for sub := range h.subscribers[event.UserID] {
+		select {
+		case sub.ch <- event:
+		default:
+			// Don't let one slow connection hold the others back
+			h.remove(sub)
+		}
+	}
+}
+
+// replay returns the events of userID published after lastEventID. found is
+// false when lastEventID isn't in the buffer anymore.
+func (h *hub) replay(userID bson.ObjectID, lastEventID string) (events []Event, found bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := time.Now().Add(-h.opts.ReplayTTL)
+	buffer := h.buffers[userID]
+	for i, event := range buffer {
+		if event.ID == lastEventID {
+			if event.CreatedAt.Before(cutoff) {
+				return nil, false
+			}
+			return slices.Clone(buffer[i+1:]), true
+		}
+	}
+	return nil, false
+}
+
+// prune drops the buffered events older than the replay TTL.
+func (h *hub) prune() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			cutoff := time.Now().Add(-h.opts.ReplayTTL)
+
+			h.mu.Lock()
+			for userID, buffer := range h.buffers {
+				i := 0
+				for i < len(buffer) && buffer[i].CreatedAt.Before(cutoff) {
+					i++
+				}
+				if i == len(buffer) {
+					delete(h.buffers, userID)
+				} else if i > 0 {
+					h.buffers[userID] = slices.Clone(buffer[i:])
+				}
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// close ends every subscription, their connections return.
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	close(h.done)
+
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+}
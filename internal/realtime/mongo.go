@@ -0,0 +1,244 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MongoPubSub publishes events into a collection every replica watches with
+// a change stream, which requires a replica set. Events are identified by
+// their position in the oplog, which orders them the same for every replica,
+// and the oplog doubles as the replay buffer of events published before this
+// replica started.
+type MongoPubSub struct {
+	logger zerolog.Logger
+	coll   *mongo.Collection
+	opts   Options
+	hub    *hub
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewMongoPubSub(coll *mongo.Collection, opts Options, logger zerolog.Logger) (*MongoPubSub, error) {
+	/* ------------------------- ttl created at ------------------------ */
+	ttlIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(opts.ReplayTTL.Seconds())),
+	}
+
+	name, err := coll.Indexes().CreateOne(context.TODO(), ttlIdx)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info().Str("index_name", name).Msg("Success creating index")
+
+	// Fail early when change streams aren't supported
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := coll.Watch(ctx, insertsPipeline())
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	p := &MongoPubSub{
+		logger: logger,
+		coll:   coll,
+		opts:   opts,
+		hub:    newHub(opts),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go p.watch(ctx, stream)
+	return p, nil
+}
+
+func insertsPipeline() mongo.Pipeline {
+	return mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "operationType", Value: "insert"}}}},
+	}
+}
+
+func (p *MongoPubSub) Publish(ctx context.Context, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	docs := make([]any, 0, len(events))
+	for _, event := range events {
+		docs = append(docs, event)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := p.coll.InsertMany(ctx, docs)
+	return err
+}
+
+func (p *MongoPubSub) Subscribe(userID bson.ObjectID) (*Subscription, error) {
+	return p.hub.subscribe(userID)
+}
+
+// Replay reads the oplog when the event isn't buffered in memory, typically
+// after a client reconnected to another replica.
+func (p *MongoPubSub) Replay(ctx context.Context, userID bson.ObjectID, lastEventID string) ([]Event, bool, error) {
+	if events, found := p.hub.replay(userID, lastEventID); found {
+		return events, true, nil
+	}
+
+	after, ok := parseEventPosition(lastEventID)
+	if !ok || time.Unix(int64(after.T), 0).Before(time.Now().Add(-p.opts.ReplayTTL)) {
+		return nil, false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	pipeline := append(insertsPipeline(), bson.D{{Key: "$match", Value: bson.D{
+		{Key: "fullDocument.user_id", Value: userID},
+		{Key: "fullDocument.ephemeral", Value: bson.D{{Key: "$ne", Value: true}}},
+	}}})
+	opts := options.ChangeStream().
+		SetStartAtOperationTime(&after).
+		SetMaxAwaitTime(100 * time.Millisecond)
+
+	stream, err := p.coll.Watch(ctx, pipeline, opts)
+	if err != nil {
+		var serverErr mongo.ServerError
+		if errors.As(err, &serverErr) && serverErr.HasErrorCode(changeStreamHistoryLost) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer stream.Close(context.Background())
+
+	// Read up to the end of the oplog, the events published from now on
+	// reach the subscription instead
+	events := []Event{}
+	for stream.TryNext(ctx) {
+		event, clusterTime, err := decodeInsert(stream)
+		if err != nil {
+			return nil, false, err
+		}
+		// The start is inclusive
+		if !clusterTime.After(after) {
+			continue
+		}
+		// One more than replayable tells events were missed
+		if len(events) == p.opts.ReplaySize {
+			return nil, false, nil
+		}
+		events = append(events, event)
+	}
+	if err := stream.Err(); err != nil {
+		return nil, false, err
+	}
+	return events, true, nil
+}
+
+// changeStreamHistoryLost is the error of a change stream starting before the
+// oldest oplog entry.
+const changeStreamHistoryLost = 286
+
+// decodeInsert returns the event the current change of stream inserted, with
+// its ID set to its position in the oplog.
+func decodeInsert(stream *mongo.ChangeStream) (Event, bson.Timestamp, error) {
+	var change struct {
+		ClusterTime bson.Timestamp `bson:"clusterTime"`
+		Event       Event          `bson:"fullDocument"`
+	}
+	if err := stream.Decode(&change); err != nil {
+		return Event{}, bson.Timestamp{}, err
+	}
+	change.Event.ID = eventPosition(change.ClusterTime)
+	return change.Event, change.ClusterTime, nil
+}
+
+// eventPosition is the ID of the event written at clusterTime. Each insert
+// has its own oplog entry and time outside transactions, and the IDs sort as
+// the times do.
+func eventPosition(clusterTime bson.Timestamp) string {
+	return fmt.Sprintf("%08x%08x", clusterTime.T, clusterTime.I)
+}
+
+func parseEventPosition(id string) (bson.Timestamp, bool) {
+	if len(id) != 16 {
+		return bson.Timestamp{}, false
+	}
+	n, err := strconv.ParseUint(id, 16, 64)
+	if err != nil {
+		return bson.Timestamp{}, false
+	}
+	return bson.Timestamp{T: uint32(n >> 32), I: uint32(n)}, true
+}
+
+// watch feeds the hub with the events inserted by every replica, resuming the
+// change stream after transient errors.
+func (p *MongoPubSub) watch(ctx context.Context, stream *mongo.ChangeStream) {
+	defer close(p.done)
+
+	var resumeToken bson.Raw
+	backoff := time.Second
+
+	for {
+		if stream != nil {
+			for stream.Next(ctx) {
+				backoff = time.Second
+				resumeToken = stream.ResumeToken()
+
+				event, _, err := decodeInsert(stream)
+				if err != nil {
+					p.logger.Error().Err(err).Msg("Error decoding event")
+					continue
+				}
+				p.hub.dispatch(event)
+			}
+
+			err := stream.Err()
+			stream.Close(context.Background())
+			stream = nil
+			if ctx.Err() != nil {
+				return
+			}
+			p.logger.Error().Err(err).Dur("retry_in", backoff).Msg("Event change stream interrupted")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, time.Minute)
+
+		var err error
+		opts := options.ChangeStream()
+		if resumeToken != nil {
+			opts.SetResumeAfter(resumeToken)
+		}
+		stream, err = p.coll.Watch(ctx, insertsPipeline(), opts)
+		if err != nil && resumeToken != nil {
+			// The token may have left the oplog, events in between are lost
+			// and clients resync when resuming from them.
+			resumeToken = nil
+			stream, err = p.coll.Watch(ctx, insertsPipeline())
+		}
+		if err != nil {
+			p.logger.Error().Err(err).Dur("retry_in", backoff).Msg("Error watching events")
+			stream = nil
+		}
+	}
+}
+
+func (p *MongoPubSub) Close() {
+	p.hub.close()
+	p.cancel()
+	<-p.done
+}
@@ -0,0 +1,49 @@
+package realtime
+
+import (
+	"context"
+
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+// NotificationSender pushes the notifications to the connections of their
+// user, along with the domain event they stand for. It implements notify.Sender.
+type NotificationSender struct {
+	pubsub PubSub
+}
+
+func NewNotificationSender(pubsub PubSub) *NotificationSender {
+	return &NotificationSender{pubsub: pubsub}
+}
+
+func (s *NotificationSender) Name() string {
+	return "realtime"
+}
+
+func (s *NotificationSender) Send(ctx context.Context, notification *models.Notification) error {
+	created, err := NewEvent(notification.UserID, EventNotificationCreated, notification)
+	if err != nil {
+		return err
+	}
+	events := []Event{created}
+
+	var domainType EventType
+	switch notification.Type {
+	case models.NotificationFriendRequestReceived:
+		domainType = EventFriendRequestReceived
+	case models.NotificationFriendRequestAccepted, models.NotificationInviteRedeemed:
+		domainType = EventFriendshipCreated
+	}
+	if domainType != "" {
+		domain, err := NewEvent(notification.UserID, domainType, map[string]any{
+			"user_id":           notification.ActorID.Hex(),
+			"friend_request_id": notification.FriendRequestID,
+		})
+		if err != nil {
+			return err
+		}
+		events = append(events, domain)
+	}
+
+	return s.pubsub.Publish(ctx, events...)
+}
@@ -0,0 +1,101 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// receive returns the events of sub received before it stays quiet.
+func receive(t *testing.T, sub *Subscription) []Event {
+	t.Helper()
+
+	events := []Event{}
+	for {
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		case <-time.After(100 * time.Millisecond):
+			return events
+		}
+	}
+}
+
+func TestNotificationSender(t *testing.T) {
+	tests := []struct {
+		notificationType models.NotificationType
+		wantTypes        []EventType
+	}{
+		{models.NotificationFriendRequestReceived, []EventType{EventNotificationCreated, EventFriendRequestReceived}},
+		{models.NotificationFriendRequestAccepted, []EventType{EventNotificationCreated, EventFriendshipCreated}},
+		{models.NotificationInviteRedeemed, []EventType{EventNotificationCreated, EventFriendshipCreated}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.notificationType, func(t *testing.T) {
+			pubsub := NewMemoryPubSub(DefaultOptions)
+			defer pubsub.Close()
+
+			userID, actorID, friendRequestID := bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()
+			sub, err := pubsub.Subscribe(userID)
+			if err != nil {
+				t.Fatalf("subscribing: %v", err)
+			}
+			other, err := pubsub.Subscribe(actorID)
+			if err != nil {
+				t.Fatalf("subscribing the actor: %v", err)
+			}
+
+			notification := &models.Notification{
+				ID:              bson.NewObjectID(),
+				UserID:          userID,
+				Type:            tt.notificationType,
+				ActorID:         actorID,
+				FriendRequestID: &friendRequestID,
+			}
+			if err := NewNotificationSender(pubsub).Send(context.Background(), notification); err != nil {
+				t.Fatalf("sending: %v", err)
+			}
+
+			events := receive(t, sub)
+			types := []EventType{}
+			for _, event := range events {
+				types = append(types, event.Type)
+			}
+			if !slices.Equal(types, tt.wantTypes) {
+				t.Fatalf("got events %v, want %v", types, tt.wantTypes)
+			}
+
+			var created models.Notification
+			if err := json.Unmarshal(events[0].Data, &created); err != nil {
+				t.Fatalf("decoding notification: %v", err)
+			}
+			if created.ID != notification.ID {
+				t.Errorf("got notification %s, want %s", created.ID.Hex(), notification.ID.Hex())
+			}
+
+			var domain struct {
+				UserID          string `json:"user_id"`
+				FriendRequestID string `json:"friend_request_id"`
+			}
+			if err := json.Unmarshal(events[1].Data, &domain); err != nil {
+				t.Fatalf("decoding %s: %v", events[1].Type, err)
+			}
+			if domain.UserID != actorID.Hex() || domain.FriendRequestID != friendRequestID.Hex() {
+				t.Errorf("got %+v, want user %s and friend request %s", domain, actorID.Hex(), friendRequestID.Hex())
+			}
+
+			if events := receive(t, other); len(events) > 0 {
+				t.Errorf("the actor got %d events, want none", len(events))
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// presenceCheckInterval is how often the tracked users are checked for a
+// lapsed activity window.
+const presenceCheckInterval = 30 * time.Second
+
+// PresenceEvents returns the presence.changed events telling the friends of
+// user allowed to see it that they are now in presence.
+func PresenceEvents(user *models.User, presence models.Presence) ([]Event, error) {
+	data := map[string]any{
+		"user_id":        user.ID.Hex(),
+		"presence":       presence,
+		"last_active_at": user.LastActiveAt,
+	}
+
+	events := []Event{}
+	for _, friendID := range user.FriendIDs {
+		// A friend sees the friends visibility as the user lists them
+		viewer := &models.User{ID: friendID, FriendIDs: []bson.ObjectID{user.ID}}
+		if !user.CanView(viewer, user.Privacy.PresenceVisibility) {
+			continue
+		}
+
+		event, err := NewEvent(friendID, EventPresenceChanged, data)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// CameOnline reports whether a request made now by a user last active at
+// previous brings them online.
+func CameOnline(previous *time.Time, now time.Time) bool {
+	return models.PresenceAt(previous, now) != models.PresenceOnline
+}
+
+// PresenceTracker announces the users going away and then offline as their
+// activity windows lapse, coming online is announced by the requests. It
+// tracks the users announced on this replica and reads their last activity
+// again before announcing, so activity seen by another replica keeps them
+// online.
+type PresenceTracker struct {
+	pubsub PubSub
+	users  *models.UserModel
+	logger zerolog.Logger
+
+	mu      sync.Mutex
+	tracked map[bson.ObjectID]*trackedPresence
+}
+
+type trackedPresence struct {
+	announced models.Presence
+	checkAt   time.Time
+}
+
+func NewPresenceTracker(pubsub PubSub, users *models.UserModel, logger zerolog.Logger) *PresenceTracker {
+	return &PresenceTracker{
+		pubsub:  pubsub,
+		users:   users,
+		logger:  logger,
+		tracked: make(map[bson.ObjectID]*trackedPresence),
+	}
+}
+
+// Announced records that user was announced in presence, the tracker takes
+// over from there.
+func (t *PresenceTracker) Announced(user *models.User, presence models.Presence) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	checkAt, ok := presenceLapsesAt(user.LastActiveAt, presence)
+	if !ok {
+		delete(t.tracked, user.ID)
+		return
+	}
+	t.tracked[user.ID] = &trackedPresence{announced: presence, checkAt: checkAt}
+}
+
+// Run checks the tracked users until ctx is done.
+func (t *PresenceTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(presenceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.check(ctx, time.Now())
+		}
+	}
+}
+
+// check announces the presence of the users whose window lapsed by now.
+func (t *PresenceTracker) check(ctx context.Context, now time.Time) {
+	t.mu.Lock()
+	due := map[bson.ObjectID]models.Presence{}
+	for userID, tracked := range t.tracked {
+		if !tracked.checkAt.After(now) {
+			due[userID] = tracked.announced
+		}
+	}
+	t.mu.Unlock()
+
+	for userID, announced := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := t.checkUser(ctx, userID, announced, now); err != nil {
+			t.logger.Error().Err(err).Str("user_id", userID.Hex()).Msg("Failed announcing presence")
+		}
+	}
+}
+
+func (t *PresenceTracker) checkUser(ctx context.Context, userID bson.ObjectID, announced models.Presence, now time.Time) error {
+	user, err := t.users.GetById(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			t.forget(userID, announced)
+			return nil
+		}
+		return err
+	}
+
+	presence := models.PresenceAt(user.LastActiveAt, now)
+	if presence != announced {
+		events, err := PresenceEvents(user, presence)
+		if err != nil {
+			return err
+		}
+		if err := t.pubsub.Publish(ctx, events...); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Announced again by a request in the meantime, that one stands
+	if tracked, ok := t.tracked[userID]; !ok || tracked.announced != announced {
+		return nil
+	}
+	checkAt, ok := presenceLapsesAt(user.LastActiveAt, presence)
+	if !ok {
+		delete(t.tracked, userID)
+		return nil
+	}
+	t.tracked[userID] = &trackedPresence{announced: presence, checkAt: checkAt}
+	return nil
+}
+
+func (t *PresenceTracker) forget(userID bson.ObjectID, announced models.Presence) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tracked, ok := t.tracked[userID]; ok && tracked.announced == announced {
+		delete(t.tracked, userID)
+	}
+}
+
+// presenceLapsesAt returns when a user last active at lastActiveAt leaves
+// presence, ok is false for offline users.
+func presenceLapsesAt(lastActiveAt *time.Time, presence models.Presence) (at time.Time, ok bool) {
+	if lastActiveAt == nil {
+		return time.Time{}, false
+	}
+	switch presence {
+	case models.PresenceOnline:
+		return lastActiveAt.Add(models.PresenceOnlineWindow), true
+	case models.PresenceAway:
+		return lastActiveAt.Add(models.PresenceAwayWindow), true
+	default:
+		return time.Time{}, false
+	}
+}
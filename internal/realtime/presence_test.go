@@ -0,0 +1,163 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// The tests reading users run against a real MongoDB deployment, in a
+// throwaway database, when STREAMIFY_TEST_MONGO_URI is set.
+const testMongoURIEnv = "STREAMIFY_TEST_MONGO_URI"
+
+func newTestUserModel(t *testing.T) *models.UserModel {
+	t.Helper()
+
+	uri := os.Getenv(testMongoURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", testMongoURIEnv)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	db := client.Database("streamify_test_" + bson.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	return models.NewUserModel(db.Collection("users"), &models.RegexSearchBackend{}, models.NewCursorCodec("test"), zerolog.Nop())
+}
+
+func TestPresenceEvents(t *testing.T) {
+	friendID := bson.NewObjectID()
+
+	tests := []struct {
+		visibility models.Visibility
+		wantEvents int
+	}{
+		{models.VisibilityEveryone, 1},
+		{models.VisibilityFriends, 1},
+		{models.VisibilityOnlyMe, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.visibility, func(t *testing.T) {
+			user := &models.User{
+				ID:        bson.NewObjectID(),
+				FriendIDs: []bson.ObjectID{friendID},
+				Privacy:   models.PrivacySettings{PresenceVisibility: tt.visibility},
+			}
+
+			events, err := PresenceEvents(user, models.PresenceAway)
+			if err != nil {
+				t.Fatalf("building events: %v", err)
+			}
+			if len(events) != tt.wantEvents {
+				t.Fatalf("got %d events, want %d", len(events), tt.wantEvents)
+			}
+			for _, event := range events {
+				if event.UserID != friendID || event.Type != EventPresenceChanged {
+					t.Errorf("got %s for %s, want %s for the friend", event.Type, event.UserID.Hex(), EventPresenceChanged)
+				}
+			}
+		})
+	}
+}
+
+func TestPresenceLapsesAt(t *testing.T) {
+	lastActiveAt := time.Now()
+
+	tests := []struct {
+		presence models.Presence
+		want     time.Time
+		wantOk   bool
+	}{
+		{models.PresenceOnline, lastActiveAt.Add(models.PresenceOnlineWindow), true},
+		{models.PresenceAway, lastActiveAt.Add(models.PresenceAwayWindow), true},
+		{models.PresenceOffline, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.presence, func(t *testing.T) {
+			got, ok := presenceLapsesAt(&lastActiveAt, tt.presence)
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("got %v, %t, want %v, %t", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	if _, ok := presenceLapsesAt(nil, models.PresenceOnline); ok {
+		t.Error("a user never active lapses")
+	}
+}
+
+func TestPresenceTrackerAnnouncesLapses(t *testing.T) {
+	users := newTestUserModel(t)
+	pubsub := NewMemoryPubSub(DefaultOptions)
+	defer pubsub.Close()
+
+	friendID := bson.NewObjectID()
+	user, err := users.Insert(&models.User{
+		FullName: "Olivia Owner",
+		Email:    bson.NewObjectID().Hex() + "@example.com",
+	})
+	if err != nil {
+		t.Fatalf("inserting user: %v", err)
+	}
+	// The presence only goes to the friends
+	if err := users.AddFriends(user.ID, friendID); err != nil {
+		t.Fatalf("adding friend: %v", err)
+	}
+	if err := users.TouchLastActive(user); err != nil {
+		t.Fatalf("touching last active: %v", err)
+	}
+
+	sub, err := pubsub.Subscribe(friendID)
+	if err != nil {
+		t.Fatalf("subscribing: %v", err)
+	}
+
+	tracker := NewPresenceTracker(pubsub, users, zerolog.Nop())
+	tracker.Announced(user, models.PresenceOnline)
+
+	lastActiveAt := *user.LastActiveAt
+	checks := []time.Time{
+		lastActiveAt.Add(time.Minute),                               // still online
+		lastActiveAt.Add(models.PresenceOnlineWindow),               // away
+		lastActiveAt.Add(models.PresenceOnlineWindow + time.Minute), // still away
+		lastActiveAt.Add(models.PresenceAwayWindow),                 // offline
+		lastActiveAt.Add(time.Hour),                                 // not tracked anymore
+	}
+	for _, now := range checks {
+		tracker.check(context.Background(), now)
+	}
+
+	presences := []models.Presence{}
+	for _, event := range receive(t, sub) {
+		var data struct {
+			Presence models.Presence `json:"presence"`
+		}
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			t.Fatalf("decoding event: %v", err)
+		}
+		presences = append(presences, data.Presence)
+	}
+	if want := []models.Presence{models.PresenceAway, models.PresenceOffline}; !slices.Equal(presences, want) {
+		t.Errorf("got presences %v, want %v", presences, want)
+	}
+}
@@ -0,0 +1,67 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrClosed = errors.New("pubsub closed")
+
+// PubSub delivers the events published on any API replica to the
+// subscriptions of this replica.
+type PubSub interface {
+	Publish(ctx context.Context, events ...Event) error
+	Subscribe(userID bson.ObjectID) (*Subscription, error)
+	// Replay returns the events of userID published after lastEventID, found
+	// is false when it can't tell, the client has to resync.
+	Replay(ctx context.Context, userID bson.ObjectID, lastEventID string) (events []Event, found bool, err error)
+	// Close ends every subscription.
+	Close()
+}
+
+// NewPubSub returns the PubSub named by backend, memory or mongo.
+func NewPubSub(backend string, coll *mongo.Collection, opts Options, logger zerolog.Logger) (PubSub, error) {
+	switch backend {
+	case "memory":
+		return NewMemoryPubSub(opts), nil
+	case "mongo":
+		return NewMongoPubSub(coll, opts, logger)
+	default:
+		return nil, fmt.Errorf("unknown pubsub backend %q", backend)
+	}
+}
+
+// MemoryPubSub delivers events within the process, it only suits a single
+// replica.
+type MemoryPubSub struct {
+	hub *hub
+}
+
+func NewMemoryPubSub(opts Options) *MemoryPubSub {
+	return &MemoryPubSub{hub: newHub(opts)}
+}
+
+func (p *MemoryPubSub) Publish(ctx context.Context, events ...Event) error {
+	for _, event := range events {
+		p.hub.dispatch(event)
+	}
+	return nil
+}
+
+func (p *MemoryPubSub) Subscribe(userID bson.ObjectID) (*Subscription, error) {
+	return p.hub.subscribe(userID)
+}
+
+func (p *MemoryPubSub) Replay(ctx context.Context, userID bson.ObjectID, lastEventID string) ([]Event, bool, error) {
+	events, found := p.hub.replay(userID, lastEventID)
+	return events, found, nil
+}
+
+func (p *MemoryPubSub) Close() {
+	p.hub.close()
+}
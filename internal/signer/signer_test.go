@@ -0,0 +1,61 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	s := New("secret", "test")
+	payload := []byte("user:event")
+
+	token := s.Sign(payload)
+	got, err := s.Verify(token)
+	if err != nil {
+		t.Fatalf("verifying: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("got payload %q, want %q", got, payload)
+	}
+
+	encodedPayload, encodedSignature, _ := strings.Cut(token, ".")
+	tampered := New("secret", "test").Sign([]byte("user:other"))
+	tamperedPayload, _, _ := strings.Cut(tampered, ".")
+
+	tests := []struct {
+		name  string
+		s     *Signer
+		token string
+	}{
+		{"other purpose", New("secret", "other"), token},
+		{"other secret", New("other", "test"), token},
+		{"tampered payload", s, tamperedPayload + "." + encodedSignature},
+		{"no signature", s, encodedPayload},
+		{"empty signature", s, encodedPayload + "."},
+		{"not base64", s, "!!." + encodedSignature},
+		{"empty", s, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.s.Verify(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("got %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	key := DeriveKey("secret", "test")
+	if len(key) != 32 {
+		t.Errorf("got a key of %d bytes, want 32", len(key))
+	}
+	if bytes.Equal(key, DeriveKey("secret", "other")) {
+		t.Error("got the same key for two purposes")
+	}
+	if bytes.Equal(key, []byte("secret")) {
+		t.Error("got the secret as key")
+	}
+}
@@ -0,0 +1,8 @@
+package validator
+
+import z "github.com/Oudwins/zog"
+
+var changePasswordSchema = z.Struct(z.Schema{
+	"CurrentPassword": z.String().Required().Max(32),
+	"NewPassword":     z.String().Min(8).Max(32).ContainsUpper().ContainsDigit().ContainsSpecial(),
+})
@@ -0,0 +1,30 @@
+package validator
+
+import z "github.com/Oudwins/zog"
+
+// MaxMessageLength is the longest text of a chat message, in characters.
+const MaxMessageLength = 4000
+
+var listConversationsSchema = z.Struct(z.Schema{
+	"Page":     z.Int().Required().GTE(1).LTE(100),
+	"PageSize": z.Int().Required().GTE(1).LTE(100),
+	"Cursor":   z.String().Trim().Max(1024),
+})
+
+var listMessagesSchema = z.Struct(z.Schema{
+	"Page":     z.Int().Required().GTE(1).LTE(100),
+	"PageSize": z.Int().Required().GTE(1).LTE(100),
+	"Cursor":   z.String().Trim().Max(1024),
+})
+
+var openConversationSchema = z.Struct(z.Schema{
+	"FriendID": z.String().Required().Trim().Len(24),
+})
+
+var sendMessageSchema = z.Struct(z.Schema{
+	"Text": z.String().Required().Trim().Min(1).Max(MaxMessageLength),
+})
+
+var markChannelReadSchema = z.Struct(z.Schema{
+	"MessageID": z.String().Required().Trim().Len(24),
+})
@@ -1,12 +1,19 @@
 package validator
 
 import (
+	"strings"
+	"time"
+
 	z "github.com/Oudwins/zog"
+	"github.com/ucok-man/streamify/internal/webpush"
 )
 
 var configSchema = z.Struct(z.Schema{
 	"Port": z.Int().Required().LT(65535, z.Message("Port must be at most 65535")),
 	"Env":  z.String().Required().OneOf([]string{"development", "staging", "production"}),
+	"App": z.Struct(z.Schema{
+		"URL": z.String().Required().URL(z.Message("Must be valid url")),
+	}),
 	"Log": z.Struct(z.Schema{
 		"level": z.String().Required().OneOf([]string{"trace", "debug", "info", "warn", "error", "fatal", "panic"}),
 	}),
@@ -19,10 +26,50 @@ var configSchema = z.Struct(z.Schema{
 		"MaxConnecting": Uint64().Required().GT(0, z.Message("Must be positive greater than 0")).LT(100, z.Message("Must be less than 100")),
 		"MaxPoolSize":   Uint64().GT(0, z.Message("Must be positive greater than 0")).LT(100, z.Message("Must be less than 100")),
 		"MaxIdleTime":   Duration(),
+		"SearchBackend": z.String().Required().OneOf([]string{"auto", "atlas", "regex"}),
+	}),
+	"Search": z.Struct(z.Schema{
+		"BoostName":      z.Float().GT(0, z.Message("Must be positive greater than 0")).LTE(100),
+		"BoostBio":       z.Float().GTE(0).LTE(100),
+		"BoostLanguages": z.Float().GTE(0).LTE(100),
+		"BoostLocation":  z.Float().GTE(0).LTE(100),
+	}),
+	"Notify": z.Struct(z.Schema{
+		"Retention": Duration(),
+	}),
+	"Events": z.Struct(z.Schema{
+		"Backend":    z.String().Required().OneOf([]string{"memory", "mongo"}),
+		"Heartbeat":  Duration(),
+		"ReplaySize": z.Int().GTE(1).LTE(10000),
+		"ReplayTTL":  Duration(),
+	}),
+	"WebSocket": z.Struct(z.Schema{
+		"RateLimit":  z.Float().GT(0, z.Message("Must be positive greater than 0")).LTE(1000),
+		"RateBurst":  z.Int().GTE(1).LTE(1000),
+		"SendBuffer": z.Int().GTE(1).LTE(1024),
+	}),
+	"Mail": z.Struct(z.Schema{
+		"Sender":          z.String().Required().OneOf([]string{"smtp", "file"}),
+		"From":            z.String().Required(),
+		"SMTPPort":        z.Int().GT(0).LT(65535, z.Message("Port must be at most 65535")),
+		"FileDir":         z.String().Required(),
+		"MaxAttempts":     z.Int().GTE(1).LTE(20),
+		"InactivityAfter": Duration(),
+		"DigestHour":      z.Int().GTE(0).LTE(23),
+	}),
+	"WebPush": z.Struct(z.Schema{
+		"Subject": z.String().Required().TestFunc(func(val *string, ctx z.Ctx) bool {
+			return strings.HasPrefix(*val, "mailto:") || strings.HasPrefix(*val, "https://")
+		}, z.Message("Must be a mailto: or https: url")),
+		"TTL":     Duration(),
+		"Urgency": z.String().Required().OneOf(webpush.Urgencies),
 	}),
-	"GetStreamIO": z.Struct(z.Schema{
-		"ApiKey":    z.String().Required(),
-		"ApiSecret": z.String().Required(),
+	"Chat": z.Struct(z.Schema{
+		"Provider": z.String().Required().OneOf([]string{"getstream", "native", "memory"}),
+		// Short lived, a leaked token is only good until it expires
+		"TokenTTL": z.CustomFunc(func(ptr *time.Duration, ctx z.Ctx) bool {
+			return *ptr >= time.Minute && *ptr <= 24*time.Hour
+		}, z.Message("Must be between 1m and 24h")),
 	}),
 	"JWT": z.Struct(z.Schema{
 		"AuthSecret": z.String().Required(),
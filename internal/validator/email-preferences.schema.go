@@ -0,0 +1,17 @@
+package validator
+
+import (
+	z "github.com/Oudwins/zog"
+	"github.com/ucok-man/streamify/internal/email"
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+var updateEmailPreferencesSchema = z.Struct(z.Schema{
+	// Empty follows the native language of the user
+	"Locale": z.String().Trim().OneOf(email.Locales),
+	"Digest": z.String().Default(models.DigestOff).OneOf(models.DigestCadences),
+})
+
+var unsubscribeEmailSchema = z.Struct(z.Schema{
+	"Token": z.String().Required().Trim().Max(256),
+})
@@ -5,6 +5,8 @@ import z "github.com/Oudwins/zog"
 var getAllSendFriendRequestSchema = z.Struct(z.Schema{
 	"Page":            z.Int().Required().GTE(1).LTE(100),
 	"PageSize":        z.Int().Required().GTE(1).LTE(1000),
+	"Cursor":          z.String().Trim().Max(1024),
 	"SearchRecipient": z.String().Trim(),
 	"Status":          z.String().OneOf([]string{"All", "Pending", "Accepted"}),
+	"Sort":            z.String().OneOf([]string{"newest", "oldest", "name"}),
 })
@@ -0,0 +1,18 @@
+package validator
+
+import z "github.com/Oudwins/zog"
+
+var createInviteSchema = z.Struct(z.Schema{
+	"MaxUses":        z.Int().GTE(0).LTE(1000),
+	"ExpiresInHours": z.Int().GTE(0).LTE(24 * 365),
+})
+
+var updateInviteSchema = z.Struct(z.Schema{
+	"MaxUses":        z.Int().GTE(0).LTE(1000),
+	"ExpiresInHours": z.Int().GTE(0).LTE(24 * 365),
+})
+
+var inviteQRSchema = z.Struct(z.Schema{
+	"Format": z.String().Required().OneOf([]string{"png", "svg"}),
+	"Size":   z.Int().Required().GTE(64).LTE(1024),
+})
@@ -0,0 +1,72 @@
+package validator
+
+import (
+	"reflect"
+
+	z "github.com/Oudwins/zog"
+	"github.com/ucok-man/streamify/internal/languages"
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+// LanguageCode accepts any code, name or alias known to the languages catalogue
+// and normalizes it to the canonical ISO 639 code.
+func LanguageCode() *z.StringSchema[string] {
+	return z.String().
+		Trim().
+		Required().
+		Transform(func(val *string, ctx z.Ctx) error {
+			if code, ok := languages.Normalize(*val); ok {
+				*val = code
+			}
+			return nil
+		}).
+		TestFunc(func(val *string, ctx z.Ctx) bool {
+			return languages.Valid(*val)
+		}, z.Message("Must be a known language, see GET /languages"))
+}
+
+// Languages validates a slice of structs with Code, Level and Learning fields.
+// It requires at least one native and one learning language, without duplicate codes.
+func Languages() *z.SliceSchema {
+	return z.Slice(z.Struct(z.Schema{
+		"Code":     LanguageCode(),
+		"Level":    z.String().Trim().Required().OneOf(models.LanguageLevels),
+		"Learning": z.Bool(),
+	})).
+		Required().
+		Min(2).
+		Max(10).
+		TestFunc(func(val any, ctx z.Ctx) bool {
+			return languageEntriesValid(val)
+		}, z.Message("Must contain at least one native and one learning language, without duplicates"))
+}
+
+func languageEntriesValid(val any) bool {
+	entries := reflect.Indirect(reflect.ValueOf(val))
+	if entries.Kind() != reflect.Slice {
+		return false
+	}
+
+	var hasNative, hasLearning bool
+	seen := map[string]bool{}
+	for i := 0; i < entries.Len(); i++ {
+		entry := reflect.Indirect(entries.Index(i))
+		code := entry.FieldByName("Code").String()
+		level := entry.FieldByName("Level").String()
+		learning := entry.FieldByName("Learning").Bool()
+
+		if seen[code] {
+			return false
+		}
+		seen[code] = true
+
+		// A native language can't be a learning target at the same time.
+		if level == models.LanguageLevelNative && learning {
+			return false
+		}
+		hasNative = hasNative || level == models.LanguageLevelNative
+		hasLearning = hasLearning || learning
+	}
+
+	return hasNative && hasLearning
+}
@@ -0,0 +1,8 @@
+package validator
+
+import z "github.com/Oudwins/zog"
+
+var listLanguagesSchema = z.Struct(z.Schema{
+	"Query": z.String().Trim().Max(100),
+	"Limit": z.Int().Required().GTE(1).LTE(500),
+})
@@ -0,0 +1,9 @@
+package validator
+
+import z "github.com/Oudwins/zog"
+
+var listNotificationsSchema = z.Struct(z.Schema{
+	"Page":     z.Int().Required().GTE(1).LTE(100),
+	"PageSize": z.Int().Required().GTE(1).LTE(100),
+	"Cursor":   z.String().Trim().Max(1024),
+})
@@ -5,5 +5,6 @@ import z "github.com/Oudwins/zog"
 var myFriendsSchema = z.Struct(z.Schema{
 	"Page":     z.Int().Required().GTE(1).LTE(100),
 	"PageSize": z.Int().Required().GTE(1).LTE(1000),
+	"Cursor":   z.String().Trim().Max(1024),
 	"Query":    z.String().Trim(),
 })
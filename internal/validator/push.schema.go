@@ -0,0 +1,16 @@
+package validator
+
+import z "github.com/Oudwins/zog"
+
+var subscribePushSchema = z.Struct(z.Schema{
+	// The server posts to it, plain http endpoints aren't push services
+	"Endpoint": z.String().Required().Trim().Max(2048).URL().HasPrefix("https://"),
+	"Keys": z.Struct(z.Schema{
+		"P256dh": z.String().Required().Trim().Max(128),
+		"Auth":   z.String().Required().Trim().Max(64),
+	}),
+})
+
+var unsubscribePushSchema = z.Struct(z.Schema{
+	"Endpoint": z.String().Required().Trim().Max(2048),
+})
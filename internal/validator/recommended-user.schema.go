@@ -3,7 +3,14 @@ package validator
 import z "github.com/Oudwins/zog"
 
 var recommendedUserSchema = z.Struct(z.Schema{
-	"Page":     z.Int().Required().GTE(1).LTE(100),
-	"PageSize": z.Int().Required().GTE(1).LTE(1000),
-	"Query":    z.String(),
+	"Page":             z.Int().Required().GTE(1).LTE(100),
+	"PageSize":         z.Int().Required().GTE(1).LTE(1000),
+	"Cursor":           z.String().Trim().Max(1024),
+	"Query":            z.String(),
+	"Location":         z.String().Trim().Max(255),
+	"ActiveWithinDays": z.Int().GTE(0).LTE(365),
+	"NativeLng":        z.Slice(LanguageCode()).Max(10),
+	"LearningLng":      z.Slice(LanguageCode()).Max(10),
+	"WithinKm":         z.Int().GTE(0).LTE(20000),
+	"OverlapHours":     z.Int().GTE(0).LTE(14),
 })
@@ -0,0 +1,11 @@
+package validator
+
+import z "github.com/Oudwins/zog"
+
+var searchUsersSchema = z.Struct(z.Schema{
+	"Page":        z.Int().Required().GTE(1).LTE(100),
+	"PageSize":    z.Int().Required().GTE(1).LTE(100),
+	"Query":       z.String().Trim().Required().Min(1).Max(255),
+	"NativeLng":   z.Slice(LanguageCode()).Max(10),
+	"LearningLng": z.Slice(LanguageCode()).Max(10),
+})
@@ -0,0 +1,14 @@
+package validator
+
+import z "github.com/Oudwins/zog"
+
+var visibilities = []string{"everyone", "friends", "only_me"}
+
+var updatePrivacySchema = z.Struct(z.Schema{
+	"FriendRequests":       z.String().Required().OneOf([]string{"everyone", "friends_of_friends", "nobody"}),
+	"EmailVisibility":      z.String().Required().OneOf(visibilities),
+	"LocationVisibility":   z.String().Required().OneOf(visibilities),
+	"FriendListVisibility": z.String().Required().OneOf(visibilities),
+	"InviteAction":         z.String().Default("befriend").OneOf([]string{"befriend", "request"}),
+	"PresenceVisibility":   z.String().Default("friends").OneOf(visibilities),
+})
@@ -0,0 +1,23 @@
+package validator
+
+import (
+	"regexp"
+
+	z "github.com/Oudwins/zog"
+)
+
+// usernameRx allows letters, digits, underscores and inner dots.
+var usernameRx = regexp.MustCompile(`^[A-Za-z0-9_](?:[A-Za-z0-9_.]*[A-Za-z0-9_])?$`)
+
+func Username() *z.StringSchema[string] {
+	return z.String().Trim().Required().Min(3).Max(30).
+		Match(usernameRx, z.Message("Must contain only letters, digits, underscores and dots, and can't start or end with a dot"))
+}
+
+var updateUsernameSchema = z.Struct(z.Schema{
+	"Username": Username(),
+})
+
+var checkUsernameSchema = z.Struct(z.Schema{
+	"Username": Username(),
+})
@@ -14,6 +14,26 @@ type schema struct {
 	MyFriendsSchema         *z.StructSchema
 	GetAllFromFriendRequest *z.StructSchema
 	GetAllSendFriendRequest *z.StructSchema
+	PeopleYouMayKnow        *z.StructSchema
+	ListLanguages           *z.StructSchema
+	SearchUsers             *z.StructSchema
+	UpdatePrivacy           *z.StructSchema
+	UpdateUsername          *z.StructSchema
+	CheckUsername           *z.StructSchema
+	CreateInvite            *z.StructSchema
+	UpdateInvite            *z.StructSchema
+	InviteQR                *z.StructSchema
+	ListNotifications       *z.StructSchema
+	UpdateEmailPreferences  *z.StructSchema
+	UnsubscribeEmail        *z.StructSchema
+	SubscribePush           *z.StructSchema
+	UnsubscribePush         *z.StructSchema
+	ListConversations       *z.StructSchema
+	ListMessages            *z.StructSchema
+	OpenConversation        *z.StructSchema
+	SendMessage             *z.StructSchema
+	MarkChannelRead         *z.StructSchema
+	ChangePassword          *z.StructSchema
 }
 
 func Schema() schema {
@@ -26,6 +46,26 @@ func Schema() schema {
 		MyFriendsSchema:         myFriendsSchema,
 		GetAllFromFriendRequest: getAllFromFriendRequestSchema,
 		GetAllSendFriendRequest: getAllSendFriendRequestSchema,
+		PeopleYouMayKnow:        peopleYouMayKnowSchema,
+		ListLanguages:           listLanguagesSchema,
+		SearchUsers:             searchUsersSchema,
+		UpdatePrivacy:           updatePrivacySchema,
+		UpdateUsername:          updateUsernameSchema,
+		CheckUsername:           checkUsernameSchema,
+		CreateInvite:            createInviteSchema,
+		UpdateInvite:            updateInviteSchema,
+		InviteQR:                inviteQRSchema,
+		ListNotifications:       listNotificationsSchema,
+		UpdateEmailPreferences:  updateEmailPreferencesSchema,
+		UnsubscribeEmail:        unsubscribeEmailSchema,
+		SubscribePush:           subscribePushSchema,
+		UnsubscribePush:         unsubscribePushSchema,
+		ListConversations:       listConversationsSchema,
+		ListMessages:            listMessagesSchema,
+		OpenConversation:        openConversationSchema,
+		SendMessage:             sendMessageSchema,
+		MarkChannelRead:         markChannelReadSchema,
+		ChangePassword:          changePasswordSchema,
 	}
 }
 
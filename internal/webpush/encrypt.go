@@ -0,0 +1,134 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// recordSize is the record size of the aes128gcm header, the payload is
+	// a single record.
+	recordSize = 4096
+	// MaxPayloadSize is the largest payload fitting in a single record with
+	// its 86 bytes header, the 16 bytes tag and the padding delimiter.
+	MaxPayloadSize = recordSize - 86 - 16 - 1
+)
+
+var (
+	ErrPayloadTooLarge     = errors.New("payload too large for a push message")
+	ErrInvalidSubscription = errors.New("invalid subscription keys")
+)
+
+// Validate reports ErrInvalidSubscription when the keys of sub can't be
+// encrypted to.
+func (sub Subscription) Validate() error {
+	if _, _, err := sub.keys(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sub Subscription) keys() (*ecdh.PublicKey, []byte, error) {
+	uaPublicBytes, err := decode(sub.P256dh)
+	if err != nil {
+		return nil, nil, ErrInvalidSubscription
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, nil, ErrInvalidSubscription
+	}
+	authSecret, err := decode(sub.Auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, nil, ErrInvalidSubscription
+	}
+	return uaPublic, authSecret, nil
+}
+
+// encrypt encrypts plaintext for the browser of sub, Message Encryption for
+// Web Push, RFC 8291, with the aes128gcm content coding of RFC 8188.
+func encrypt(sub Subscription, plaintext []byte) ([]byte, error) {
+	if len(plaintext) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+
+	// A new key pair and salt per message
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return seal(sub, plaintext, asPrivate, salt)
+}
+
+// seal is encrypt with the key pair of the application server and the salt
+// given.
+func seal(sub Subscription, plaintext []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	uaPublic, authSecret, err := sub.keys()
+	if err != nil {
+		return nil, err
+	}
+	uaPublicBytes := uaPublic.Bytes()
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	sharedSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public, 32)
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublicBytes...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := derive(sharedSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	cek, err := derive(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := derive(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt, record size, key id length and the key id, which is the
+	// public key of the application server
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	// The last record is delimited by 0x02, without further padding
+	record := append(plaintext[:len(plaintext):len(plaintext)], 0x02)
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+func derive(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,156 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"errors"
+	"testing"
+)
+
+// The example of RFC 8291, appendix A.
+var (
+	testPlaintext    = "When I grow up, I want to be a watermelon"
+	testASPrivate    = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+	testUAPrivate    = "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"
+	testSubscription = Subscription{
+		Endpoint: "https://push.example.net/push/JzLQ3raZJfFBR0aqvOMsLrt54w4rJUsV",
+		P256dh:   "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4",
+		Auth:     "BTBZMqHH6r4Tts7J_aSIgg",
+	}
+	testSalt = "DGv6ra1nlYgDCS1FRnbzlw"
+	testBody = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+// mustDecode decodes the base64url of the test data.
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := decode(s)
+	if err != nil {
+		t.Fatalf("decoding %q: %v", s, err)
+	}
+	return b
+}
+
+// open decrypts body the way the browser holding uaPrivate and authSecret
+// does.
+func open(uaPrivate *ecdh.PrivateKey, authSecret, body []byte) ([]byte, error) {
+	if len(body) < 21 || len(body) < 21+int(body[20]) {
+		return nil, errors.New("truncated header")
+	}
+	salt, keyID := body[:16], body[21:21+int(body[20])]
+
+	asPublic, err := ecdh.P256().NewPublicKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPrivate.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, keyID...)
+	ikm, err := derive(sharedSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := derive(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := derive(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	record, err := gcm.Open(nil, nonce, body[21+len(keyID):], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	record = bytes.TrimRight(record, "\x00")
+	if len(record) == 0 || record[len(record)-1] != 0x02 {
+		return nil, errors.New("missing last record delimiter")
+	}
+	return record[:len(record)-1], nil
+}
+
+func TestSealRFC8291Example(t *testing.T) {
+	asPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, testASPrivate))
+	if err != nil {
+		t.Fatalf("loading the application server key: %v", err)
+	}
+
+	got, err := seal(testSubscription, []byte(testPlaintext), asPrivate, mustDecode(t, testSalt))
+	if err != nil {
+		t.Fatalf("sealing: %v", err)
+	}
+	if want := mustDecode(t, testBody); !bytes.Equal(got, want) {
+		t.Errorf("got %s, want %s", encode(got), testBody)
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	uaPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, testUAPrivate))
+	if err != nil {
+		t.Fatalf("loading the user agent key: %v", err)
+	}
+
+	body, err := encrypt(testSubscription, []byte(testPlaintext))
+	if err != nil {
+		t.Fatalf("encrypting: %v", err)
+	}
+	got, err := open(uaPrivate, mustDecode(t, testSubscription.Auth), body)
+	if err != nil {
+		t.Fatalf("decrypting: %v", err)
+	}
+	if string(got) != testPlaintext {
+		t.Errorf("got %q, want %q", got, testPlaintext)
+	}
+
+	// Every message gets its own key pair and salt
+	again, err := encrypt(testSubscription, []byte(testPlaintext))
+	if err != nil {
+		t.Fatalf("encrypting again: %v", err)
+	}
+	if bytes.Equal(again[:16], body[:16]) || bytes.Equal(again[21:86], body[21:86]) {
+		t.Error("got the same salt or key for two messages")
+	}
+}
+
+func TestEncryptRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		sub       Subscription
+		plaintext []byte
+		want      error
+	}{
+		{"payload too large", testSubscription, make([]byte, MaxPayloadSize+1), ErrPayloadTooLarge},
+		{"not base64", Subscription{P256dh: "not base64!", Auth: testSubscription.Auth}, nil, ErrInvalidSubscription},
+		{"not a P-256 key", Subscription{P256dh: testSubscription.Auth, Auth: testSubscription.Auth}, nil, ErrInvalidSubscription},
+		{"short auth secret", Subscription{P256dh: testSubscription.P256dh, Auth: "BTBZMqHH6r4"}, nil, ErrInvalidSubscription},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := encrypt(tt.sub, tt.plaintext); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := encrypt(testSubscription, make([]byte, MaxPayloadSize)); err != nil {
+		t.Errorf("got %v encrypting the largest payload, want no error", err)
+	}
+}
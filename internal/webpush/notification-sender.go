@@ -0,0 +1,125 @@
+package webpush
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+)
+
+// sendTimeout bounds the push of a notification to one browser.
+const sendTimeout = 10 * time.Second
+
+// Payload is the JSON pushed to the service worker, which shows it as a
+// system notification opening URL when clicked.
+type Payload struct {
+	NotificationID string `json:"notification_id"`
+	Type           string `json:"type"`
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	Icon           string `json:"icon,omitempty"`
+	URL            string `json:"url"`
+}
+
+// NotificationSender pushes the notifications to the browsers their users
+// subscribed. It implements notify.Sender, the pushes run in the background
+// and the subscriptions the push service says are gone are deleted.
+type NotificationSender struct {
+	client        *Client
+	subscriptions *models.PushSubscriptionModel
+	users         *models.UserModel
+	appURL        string
+	opts          Options
+	background    func(fn func())
+	logger        zerolog.Logger
+}
+
+// NewNotificationSender links the notifications to the web app at appURL and
+// runs the pushes with background, which must outlive the request.
+func NewNotificationSender(client *Client, subscriptions *models.PushSubscriptionModel, users *models.UserModel, appURL string, opts Options, background func(fn func()), logger zerolog.Logger) *NotificationSender {
+	return &NotificationSender{
+		client:        client,
+		subscriptions: subscriptions,
+		users:         users,
+		appURL:        strings.TrimRight(appURL, "/"),
+		opts:          opts,
+		background:    background,
+		logger:        logger,
+	}
+}
+
+func (s *NotificationSender) Name() string {
+	return "webpush"
+}
+
+func (s *NotificationSender) Send(ctx context.Context, notification *models.Notification) error {
+	var title, body, path string
+	switch notification.Type {
+	case models.NotificationFriendRequestReceived:
+		title, body, path = "New friend request", "%s wants to be your language partner.", "/notifications"
+	case models.NotificationFriendRequestAccepted:
+		title, body, path = "Friend request accepted", "%s accepted your friend request, say hello!", "/chat/"+notification.ActorID.Hex()
+	case models.NotificationInviteRedeemed:
+		title, body, path = "Invite redeemed", "%s joined you through your invite.", "/chat/"+notification.ActorID.Hex()
+	default:
+		return nil
+	}
+
+	subscriptions, err := s.subscriptions.GetAllByUser(notification.UserID)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	actor, err := s.users.GetById(notification.ActorID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(Payload{
+		NotificationID: notification.ID.Hex(),
+		Type:           notification.Type,
+		Title:          title,
+		Body:           fmt.Sprintf(body, actor.FullName),
+		Icon:           actor.ProfilePic,
+		URL:            s.appURL + path,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.background(func() {
+		for _, subscription := range subscriptions {
+			s.push(subscription, payload)
+		}
+	})
+	return nil
+}
+
+func (s *NotificationSender) push(subscription *models.PushSubscription, payload []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	err := s.client.Send(ctx, Subscription{
+		Endpoint: subscription.Endpoint,
+		P256dh:   subscription.P256dh,
+		Auth:     subscription.Auth,
+	}, payload, s.opts)
+
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrSubscriptionGone), errors.Is(err, ErrInvalidSubscription):
+		if err := s.subscriptions.Delete(subscription.ID); err != nil {
+			s.logger.Error().Err(err).Str("subscription_id", subscription.ID.Hex()).Msg("Failed deleting dead push subscription")
+		}
+	default:
+		s.logger.Error().Err(err).Str("subscription_id", subscription.ID.Hex()).Msg("Failed pushing notification")
+	}
+}
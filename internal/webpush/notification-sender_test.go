@@ -0,0 +1,146 @@
+package webpush
+
+import (
+	"context"
+	"crypto/ecdh"
+	"encoding/json"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ucok-man/streamify/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// The subscriptions are stored in a real MongoDB deployment, in a throwaway
+// database, when STREAMIFY_TEST_MONGO_URI is set.
+const testMongoURIEnv = "STREAMIFY_TEST_MONGO_URI"
+
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv(testMongoURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", testMongoURIEnv)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	db := client.Database("streamify_test_" + bson.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db
+}
+
+func TestNotificationSenderPrunesDeadSubscriptions(t *testing.T) {
+	db := testDatabase(t)
+	users := models.NewUserModel(db.Collection("users"), &models.RegexSearchBackend{}, models.NewCursorCodec("test"), zerolog.Nop())
+	subscriptions := models.NewPushSubscriptionModel(db.Collection("push_subscriptions"), zerolog.Nop())
+
+	actor, err := users.Insert(&models.User{FullName: "Sam Sender", Email: bson.NewObjectID().Hex() + "@example.com"})
+	if err != nil {
+		t.Fatalf("inserting actor: %v", err)
+	}
+	userID := bson.NewObjectID()
+
+	live, liveRequests := newTestPushService(t, http.StatusCreated)
+	gone, goneRequests := newTestPushService(t, http.StatusGone)
+	missing, missingRequests := newTestPushService(t, http.StatusNotFound)
+
+	browsers := []struct {
+		endpoint string
+		p256dh   string
+		wantKept bool
+	}{
+		{live.URL + "/push/live", testSubscription.P256dh, true},
+		{gone.URL + "/push/gone", testSubscription.P256dh, false},
+		{missing.URL + "/push/missing", testSubscription.P256dh, false},
+		// Never reaches a push service
+		{live.URL + "/push/invalid", testSubscription.Auth, false},
+	}
+	wantKept := []string{}
+	for _, b := range browsers {
+		_, err := subscriptions.Upsert(&models.PushSubscription{
+			UserID:   userID,
+			Endpoint: b.endpoint,
+			P256dh:   b.p256dh,
+			Auth:     testSubscription.Auth,
+		})
+		if err != nil {
+			t.Fatalf("subscribing %s: %v", b.endpoint, err)
+		}
+		if b.wantKept {
+			wantKept = append(wantKept, b.endpoint)
+		}
+	}
+
+	sender := NewNotificationSender(
+		NewClient(newTestVAPID(t)),
+		subscriptions,
+		users,
+		"https://streamify.example.com/",
+		Options{TTL: time.Hour},
+		func(fn func()) { fn() },
+		zerolog.Nop(),
+	)
+	notification := &models.Notification{
+		ID:      bson.NewObjectID(),
+		UserID:  userID,
+		Type:    models.NotificationFriendRequestReceived,
+		ActorID: actor.ID,
+	}
+	if err := sender.Send(context.Background(), notification); err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+
+	if len(liveRequests) != 1 || len(goneRequests) != 1 || len(missingRequests) != 1 {
+		t.Errorf("got %d, %d and %d pushes, want 1 each", len(liveRequests), len(goneRequests), len(missingRequests))
+	}
+
+	kept, err := subscriptions.GetAllByUser(userID)
+	if err != nil {
+		t.Fatalf("getting subscriptions: %v", err)
+	}
+	endpoints := []string{}
+	for _, subscription := range kept {
+		endpoints = append(endpoints, subscription.Endpoint)
+	}
+	if !slices.Equal(endpoints, wantKept) {
+		t.Errorf("got subscriptions %v kept, want %v", endpoints, wantKept)
+	}
+
+	uaPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, testUAPrivate))
+	if err != nil {
+		t.Fatalf("loading the user agent key: %v", err)
+	}
+	plaintext, err := open(uaPrivate, mustDecode(t, testSubscription.Auth), (<-liveRequests).body)
+	if err != nil {
+		t.Fatalf("decrypting: %v", err)
+	}
+	var payload Payload
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	want := Payload{
+		NotificationID: notification.ID.Hex(),
+		Type:           models.NotificationFriendRequestReceived,
+		Title:          "New friend request",
+		Body:           "Sam Sender wants to be your language partner.",
+		URL:            "https://streamify.example.com/notifications",
+	}
+	if payload != want {
+		t.Errorf("got payload %+v, want %+v", payload, want)
+	}
+}
@@ -0,0 +1,109 @@
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// vapidExpiry is how long the VAPID token of a request is valid, push
+// services reject more than 24 hours.
+const vapidExpiry = 12 * time.Hour
+
+var ErrInvalidVAPIDKeys = errors.New("invalid VAPID keys")
+
+// GenerateVAPIDKeys returns a new application server key pair, RFC 8292, as
+// unpadded base64url: the uncompressed P-256 public key, which browsers
+// subscribe with, and the private scalar.
+func GenerateVAPIDKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return encode(key.PublicKey().Bytes()), encode(key.Bytes()), nil
+}
+
+// VAPID identifies the application server to the push services, so only it
+// can push to the subscriptions made with its public key.
+type VAPID struct {
+	publicKey string
+	key       *ecdsa.PrivateKey
+	subject   string
+}
+
+// NewVAPID loads the keys of GenerateVAPIDKeys. Subject is a mailto: or
+// https: contact the push services can reach the operator at.
+func NewVAPID(publicKey, privateKey, subject string) (*VAPID, error) {
+	d, err := decode(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVAPIDKeys, err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVAPIDKeys, err)
+	}
+	public := key.PublicKey().Bytes()
+	if encode(public) != publicKey {
+		return nil, fmt.Errorf("%w: the public key doesn't match the private key", ErrInvalidVAPIDKeys)
+	}
+
+	// Uncompressed point, 0x04 then X and Y
+	return &VAPID{
+		publicKey: publicKey,
+		key: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(public[1:33]),
+				Y:     new(big.Int).SetBytes(public[33:]),
+			},
+			D: new(big.Int).SetBytes(d),
+		},
+		subject: subject,
+	}, nil
+}
+
+// PublicKey returns the key browsers pass as applicationServerKey when they
+// subscribe.
+func (v *VAPID) PublicKey() string {
+	return v.publicKey
+}
+
+// authorization returns the Authorization header of a push to endpoint.
+func (v *VAPID) authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidExpiry).Unix(),
+		"sub": v.subject,
+	})
+	signed, err := token.SignedString(v.key)
+	if err != nil {
+		return "", err
+	}
+	return "vapid t=" + signed + ", k=" + v.publicKey, nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decode accepts the padded and unpadded base64url the browsers may give.
+func decode(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
@@ -0,0 +1,137 @@
+package webpush
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestVAPID(t *testing.T) *VAPID {
+	t.Helper()
+
+	publicKey, privateKey, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatalf("generating keys: %v", err)
+	}
+	vapid, err := NewVAPID(publicKey, privateKey, "mailto:ops@example.com")
+	if err != nil {
+		t.Fatalf("loading keys: %v", err)
+	}
+	return vapid
+}
+
+// parseVAPID verifies the token of authorization with its key, and returns
+// its claims and key.
+func parseVAPID(t *testing.T, authorization string) (jwt.MapClaims, string) {
+	t.Helper()
+
+	token, key, ok := strings.Cut(strings.TrimPrefix(authorization, "vapid t="), ", k=")
+	if !ok || !strings.HasPrefix(authorization, "vapid t=") {
+		t.Fatalf("got authorization %q, want vapid t=..., k=...", authorization)
+	}
+	// The key is the uncompressed point the ES256 key is built from
+	public, err := decode(key)
+	if err != nil || len(public) != 65 {
+		t.Fatalf("got key %q, want an uncompressed P-256 point", key)
+	}
+	verifyingKey := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(public[1:33]),
+		Y:     new(big.Int).SetBytes(public[33:]),
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
+		return verifyingKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}))
+	if err != nil {
+		t.Fatalf("verifying token: %v", err)
+	}
+	return claims, key
+}
+
+func TestNewVAPID(t *testing.T) {
+	publicKey, privateKey, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatalf("generating keys: %v", err)
+	}
+	otherPublicKey, _, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatalf("generating keys: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		publicKey  string
+		privateKey string
+		wantErr    bool
+	}{
+		{"matching keys", publicKey, privateKey, false},
+		{"keys of another pair", otherPublicKey, privateKey, true},
+		{"not base64", publicKey, "not base64!", true},
+		{"not a P-256 scalar", publicKey, encode([]byte("short")), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vapid, err := NewVAPID(tt.publicKey, tt.privateKey, "mailto:ops@example.com")
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidVAPIDKeys) {
+					t.Errorf("got %v, want %v", err, ErrInvalidVAPIDKeys)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loading keys: %v", err)
+			}
+			if vapid.PublicKey() != tt.publicKey {
+				t.Errorf("got public key %q, want %q", vapid.PublicKey(), tt.publicKey)
+			}
+		})
+	}
+}
+
+func TestVAPIDAuthorization(t *testing.T) {
+	vapid := newTestVAPID(t)
+	now := time.Now()
+
+	authorization, err := vapid.authorization("https://push.example.net:8443/push/JzLQ3raZ?token=1", now)
+	if err != nil {
+		t.Fatalf("signing: %v", err)
+	}
+	claims, key := parseVAPID(t, authorization)
+
+	if key != vapid.PublicKey() {
+		t.Errorf("got key %q, want %q", key, vapid.PublicKey())
+	}
+	// The audience is the origin of the push service, without the path
+	if aud, _ := claims.GetAudience(); len(aud) != 1 || aud[0] != "https://push.example.net:8443" {
+		t.Errorf("got audience %v, want %q", aud, "https://push.example.net:8443")
+	}
+	if sub, _ := claims.GetSubject(); sub != "mailto:ops@example.com" {
+		t.Errorf("got subject %q, want %q", sub, "mailto:ops@example.com")
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil || exp.Unix() != now.Add(vapidExpiry).Unix() {
+		t.Errorf("got expiry %v, want %v", exp, now.Add(vapidExpiry))
+	}
+	if vapidExpiry > 24*time.Hour {
+		t.Errorf("got expiry in %v, push services reject more than 24h", vapidExpiry)
+	}
+
+	// Signed by this key only
+	other := newTestVAPID(t)
+	token, _, _ := strings.Cut(strings.TrimPrefix(authorization, "vapid t="), ", k=")
+	_, err = jwt.Parse(token, func(token *jwt.Token) (any, error) {
+		return &other.key.PublicKey, nil
+	})
+	if err == nil {
+		t.Error("got the token verified with another key")
+	}
+}
@@ -0,0 +1,110 @@
+// Package webpush sends Web Push messages, RFC 8030, to the browsers of the
+// users: encrypted per RFC 8291 and authenticated with VAPID, RFC 8292.
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Urgency tells the push service how soon the message must reach a device
+// saving its battery, RFC 8030 section 5.3.
+type Urgency = string
+
+const (
+	UrgencyVeryLow Urgency = "very-low"
+	UrgencyLow     Urgency = "low"
+	UrgencyNormal  Urgency = "normal"
+	UrgencyHigh    Urgency = "high"
+)
+
+var Urgencies = []Urgency{UrgencyVeryLow, UrgencyLow, UrgencyNormal, UrgencyHigh}
+
+// ErrSubscriptionGone is returned when the push service doesn't know the
+// subscription anymore, it must be deleted.
+var ErrSubscriptionGone = errors.New("push subscription expired or unsubscribed")
+
+// Subscription is the PushSubscription of a browser: where to push and the
+// keys to encrypt with.
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// Options of a push message.
+type Options struct {
+	// How long the push service keeps the message of an offline device,
+	// zero delivers it now or never
+	TTL     time.Duration
+	Urgency Urgency
+	// Replaces the message of the same topic still pending, if any
+	Topic string
+}
+
+type Client struct {
+	vapid *VAPID
+	http  *http.Client
+}
+
+func NewClient(vapid *VAPID) *Client {
+	return &Client{
+		vapid: vapid,
+		http:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// PublicKey returns the VAPID public key browsers subscribe with.
+func (c *Client) PublicKey() string {
+	return c.vapid.PublicKey()
+}
+
+// Send pushes payload to sub. It returns ErrSubscriptionGone when the push
+// service answered 404 or 410.
+func (c *Client) Send(ctx context.Context, sub Subscription, payload []byte, opts Options) error {
+	body, err := encrypt(sub, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := c.vapid.authorization(sub.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(opts.TTL.Seconds())))
+	if opts.Urgency != "" {
+		req.Header.Set("Urgency", opts.Urgency)
+	}
+	if opts.Topic != "" {
+		req.Header.Set("Topic", opts.Topic)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	default:
+		reason, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("push service answered %s: %s", res.Status, bytes.TrimSpace(reason))
+	}
+}
@@ -0,0 +1,111 @@
+package webpush
+
+import (
+	"context"
+	"crypto/ecdh"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// pushRequest is what the push service of a test got.
+type pushRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newTestPushService answers every push with status, and sends the requests
+// to the channel returned.
+func newTestPushService(t *testing.T, status int) (*httptest.Server, <-chan pushRequest) {
+	t.Helper()
+
+	requests := make(chan pushRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- pushRequest{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func TestClientSend(t *testing.T) {
+	tests := []struct {
+		status  int
+		wantErr error
+		wantOk  bool
+	}{
+		{http.StatusCreated, nil, true},
+		{http.StatusNotFound, ErrSubscriptionGone, false},
+		{http.StatusGone, ErrSubscriptionGone, false},
+		{http.StatusTooManyRequests, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv, requests := newTestPushService(t, tt.status)
+			sub := testSubscription
+			sub.Endpoint = srv.URL + "/push/JzLQ3raZ"
+
+			err := NewClient(newTestVAPID(t)).Send(context.Background(), sub, []byte(testPlaintext), Options{})
+			switch {
+			case tt.wantOk && err != nil:
+				t.Errorf("got %v, want no error", err)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			case !tt.wantOk && err == nil:
+				t.Error("got no error")
+			}
+			if len(requests) != 1 {
+				t.Errorf("got %d requests, want 1", len(requests))
+			}
+		})
+	}
+}
+
+func TestClientSendRequest(t *testing.T) {
+	srv, requests := newTestPushService(t, http.StatusCreated)
+	sub := testSubscription
+	sub.Endpoint = srv.URL + "/push/JzLQ3raZ"
+
+	vapid := newTestVAPID(t)
+	opts := Options{TTL: time.Hour, Urgency: UrgencyHigh, Topic: "friend-requests"}
+	if err := NewClient(vapid).Send(context.Background(), sub, []byte(testPlaintext), opts); err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+	req := <-requests
+
+	headers := map[string]string{
+		"Content-Encoding": "aes128gcm",
+		"Content-Type":     "application/octet-stream",
+		"TTL":              "3600",
+		"Urgency":          UrgencyHigh,
+		"Topic":            "friend-requests",
+	}
+	for name, want := range headers {
+		if got := req.header.Get(name); got != want {
+			t.Errorf("got %s %q, want %q", name, got, want)
+		}
+	}
+
+	claims, _ := parseVAPID(t, req.header.Get("Authorization"))
+	if aud, _ := claims.GetAudience(); len(aud) != 1 || aud[0] != srv.URL {
+		t.Errorf("got audience %v, want %q", aud, srv.URL)
+	}
+
+	// Only the browser can read the payload
+	uaPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, testUAPrivate))
+	if err != nil {
+		t.Fatalf("loading the user agent key: %v", err)
+	}
+	got, err := open(uaPrivate, mustDecode(t, sub.Auth), req.body)
+	if err != nil {
+		t.Fatalf("decrypting: %v", err)
+	}
+	if string(got) != testPlaintext {
+		t.Errorf("got %q, want %q", got, testPlaintext)
+	}
+}